@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,16 +13,22 @@ import (
 	"syscall"
 
 	"github.com/alecthomas/kong"
+	"github.com/opencontainers/go-digest"
 	"github.com/openshift/bpfman-catalog/pkg/analysis"
 	"github.com/openshift/bpfman-catalog/pkg/bundle"
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
 	"github.com/openshift/bpfman-catalog/pkg/manifests"
+	"github.com/openshift/bpfman-catalog/pkg/provenance"
 	"github.com/openshift/bpfman-catalog/pkg/writer"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"sigs.k8s.io/yaml"
 )
 
 // Default output directories
 const (
 	DefaultArtefactsDir = "auto-generated/artefacts"
 	DefaultManifestsDir = "auto-generated/manifests"
+	DefaultInstallDir   = "auto-generated/bundle-install"
 )
 
 // GlobalContext contains global dependencies injected into commands.
@@ -32,10 +39,22 @@ type GlobalContext struct {
 
 // CLI defines the command-line interface structure.
 type CLI struct {
-	PrepareCatalogBuildFromBundle     PrepareCatalogBuildFromBundleCmd     `cmd:"prepare-catalog-build-from-bundle" help:"Prepare catalog build artefacts from a bundle image"`
+	PrepareCatalogBuildFromBundle     PrepareCatalogBuildFromBundleCmd     `cmd:"prepare-catalog-build-from-bundle" help:"Prepare catalog build artefacts from one or more bundle images"`
 	PrepareCatalogBuildFromYAML       PrepareCatalogBuildFromYAMLCmd       `cmd:"prepare-catalog-build-from-yaml" help:"Prepare catalog build artefacts from an existing catalog.yaml file"`
 	PrepareCatalogDeploymentFromImage PrepareCatalogDeploymentFromImageCmd `cmd:"prepare-catalog-deployment-from-image" help:"Prepare deployment manifests from existing catalog image"`
+	PrepareBundleInstallManifests     PrepareBundleInstallManifestsCmd     `cmd:"prepare-bundle-install-manifests" help:"Prepare manifests that install a bundle directly, without OLM"`
+	ValidateManifests                 ValidateManifestsCmd                 `cmd:"validate-manifests" help:"Validate generated manifests against the OLM and OpenShift CRD schemas"`
 	BundleInfo                        BundleInfoCmd                        `cmd:"bundle-info" help:"Show bundle contents and dependencies"`
+	LintCatalog                       LintCatalogCmd                       `cmd:"lint-catalog" help:"Lint the bundles in a catalog file, directory or image"`
+	DiffBundles                       DiffBundlesCmd                       `cmd:"diff-bundles" help:"Compare the CRDs, RBAC, APIs and images of two bundles"`
+	CheckCRDCompat                    CheckCRDCompatCmd                    `cmd:"check-crd-compat" help:"Check CRD schema compatibility along a channel's replaces chain"`
+	CheckVersions                     CheckVersionsCmd                     `cmd:"check-versions" help:"Check that bundle versions agree across templates, CSVs, labels and Dockerfile-args"`
+	UpgradePath                       UpgradePathCmd                       `cmd:"upgrade-path" help:"Show the upgrades OLM performs between two bundles in a channel"`
+	Graph                             GraphCmd                             `cmd:"graph" help:"Render a catalog's channel update graphs as Graphviz DOT or Mermaid"`
+	ConvertTemplate                   ConvertTemplateCmd                   `cmd:"convert-template" help:"Convert a basic catalog template to the semver template form"`
+	Deprecate                         DeprecateCmd                         `cmd:"deprecate" help:"Add olm.deprecations entries for a package, channels or bundles to a catalog template"`
+	BuildCatalogImage                 BuildCatalogImageCmd                 `cmd:"build-catalog-image" help:"Build a catalog image from catalog.yaml without a container engine"`
+	PushAndDeploy                     PushAndDeployCmd                     `cmd:"push-and-deploy" help:"Push a locally built catalog image and prepare deployment manifests for its digest"`
 	ListBundles                       ListBundlesCmd                       `cmd:"list-bundles" help:"List available bundle images"`
 
 	// Global flags
@@ -43,29 +62,283 @@ type CLI struct {
 	LogFormat string `env:"LOG_FORMAT" default:"text" help:"Log format (text, json)"`
 }
 
-// PrepareCatalogBuildFromBundleCmd prepares catalog build artefacts from a bundle image.
+// PrepareCatalogBuildFromBundleCmd prepares catalog build artefacts from bundle images.
 type PrepareCatalogBuildFromBundleCmd struct {
-	BundleImage string `arg:"" required:"" help:"Bundle image reference"`
-	OutputDir   string `default:"${default_artefacts_dir}" help:"Output directory for generated artefacts"`
-	OpmBin      string `type:"path" help:"Path to opm binary for external rendering (uses library by default)"`
+	BundleImages   []string `arg:"" required:"" help:"Bundle image references; several bundles are chained in version order"`
+	OutputDir      string   `default:"${default_artefacts_dir}" help:"Output directory for generated artefacts"`
+	OpmBin         string   `type:"path" help:"Path to opm binary for external rendering (uses library by default)"`
+	TemplateType   string   `default:"basic" enum:"basic,semver" help:"Catalog template type to generate (basic, semver)"`
+	PackageFrom    string   `help:"Template, catalog or catalog image to copy the olm.package icon and description from (default: the newest bundle's CSV)"`
+	Channel        []string `help:"Channel to place the bundles in (repeatable; default: preview, or candidate for semver templates)"`
+	DefaultChannel string   `help:"Default channel of the package (default: the first --channel)"`
+	SkipRange      bool     `help:"Give each bundle a skipRange from the oldest bundle, so it can be installed directly over any earlier one"`
+	MajorChannels  bool     `name:"generate-major-channels" help:"Generate major version channels from a semver template"`
+	MinorChannels  bool     `name:"generate-minor-channels" help:"Generate minor version channels from a semver template (the default when neither is set)"`
+	Seed           string   `help:"Generate deterministic artefacts, seeding generated names and TTLs from this value (default with $SOURCE_DATE_EPOCH: its value)"`
+	TemplateDir    string   `type:"existingdir" help:"Directory of templates overriding the embedded Makefile.tmpl, WORKFLOW.txt.tmpl and Dockerfile.tmpl by name"`
+
+	DockerfileFlags `embed:""`
+	OutputFlags     `embed:""`
 }
 
 // PrepareCatalogBuildFromYAMLCmd prepares catalog build artefacts from existing catalog.yaml.
 type PrepareCatalogBuildFromYAMLCmd struct {
 	CatalogYAML string `arg:"" type:"path" required:"" help:"Path to existing catalog.yaml file"`
 	OutputDir   string `default:"${default_artefacts_dir}" help:"Output directory for generated artefacts"`
+	Seed        string `help:"Generate deterministic artefacts, seeding generated names and TTLs from this value (default with $SOURCE_DATE_EPOCH: its value)"`
+	TemplateDir string `type:"existingdir" help:"Directory of templates overriding the embedded Makefile.tmpl, WORKFLOW.txt.tmpl and Dockerfile.tmpl by name"`
+
+	DockerfileFlags `embed:""`
+	OutputFlags     `embed:""`
+}
+
+// DockerfileFlags configures the base image and labels of catalog
+// images, in the Dockerfile generated by the prepare-catalog-build
+// commands or built by build-catalog-image.
+type DockerfileFlags struct {
+	BaseImage  string `xor:"base-image" help:"Base image for the catalog image (default: the OpenShift operator registry image for --ocp-version)"`
+	OCPVersion string `name:"ocp-version" xor:"base-image" help:"OpenShift version (e.g. 4.20) whose operator registry image the catalog is based on (default: ${default_ocp_version})"`
+	Commit     string `help:"Source commit recorded in the catalog image's upstream-vcs-ref label"`
+}
+
+// options validates the flags and returns the Dockerfile options.
+func (f DockerfileFlags) options() (bundle.DockerfileOptions, error) {
+	if f.OCPVersion != "" {
+		if err := analysis.ValidateOCPVersion(f.OCPVersion); err != nil {
+			return bundle.DockerfileOptions{}, err
+		}
+	}
+	return bundle.DockerfileOptions{
+		BaseImage:  f.BaseImage,
+		OCPVersion: f.OCPVersion,
+		Commit:     f.Commit,
+	}, nil
 }
 
 // PrepareCatalogDeploymentFromImageCmd prepares deployment manifests from catalog image.
 type PrepareCatalogDeploymentFromImageCmd struct {
 	CatalogImage string `arg:"" required:"" help:"Catalog image reference"`
-	OutputDir    string `default:"${default_manifests_dir}" help:"Output directory for generated manifests"`
+
+	DeploymentFlags `embed:""`
+}
+
+// DeploymentFlags configures the catalog deployment manifests
+// generated for a catalog image.
+type DeploymentFlags struct {
+	OutputDir        string `default:"${default_manifests_dir}" help:"Output directory for generated manifests"`
+	GitOps           string `name:"gitops" enum:",argocd" default:"" help:"Also generate GitOps resources for the manifests (argocd)"`
+	GitOpsRepoURL    string `name:"gitops-repo-url" help:"Git repository the generated manifests are committed to (required with --gitops)"`
+	GitOpsRevision   string `name:"gitops-revision" default:"HEAD" help:"Git revision for the GitOps resources to track"`
+	GitOpsPath       string `name:"gitops-path" help:"Path of the output directory within the git repository (default: --output-dir)"`
+	ArgoCDNamespace  string `name:"argocd-namespace" default:"openshift-gitops" help:"Namespace Argo CD runs in"`
+	ArgoCDProject    string `name:"argocd-project" default:"default" help:"Argo CD project for the generated Applications"`
+	PullSecretFrom   string `name:"pull-secret-from-authfile" type:"existingfile" help:"Container auth file (e.g. ~/.docker/config.json) to build a CatalogSource pull secret from"`
+	GlobalPullSecret string `name:"global-pull-secret" type:"existingfile" help:"Current cluster pull secret (.dockerconfigjson) to generate a merge patch for; requires --pull-secret-from-authfile"`
+	SkipValidation   bool   `help:"Skip schema validation of the generated manifests"`
+
+	OutputFlags `embed:""`
+}
+
+// gitOpsConfig returns the Argo CD Application settings. The
+// manifests are expected at --output-dir in the repository unless
+// --gitops-path is given.
+func (f DeploymentFlags) gitOpsConfig() manifests.GitOpsConfig {
+	gitOpsPath := f.GitOpsPath
+	if gitOpsPath == "" {
+		gitOpsPath = filepath.ToSlash(filepath.Clean(f.OutputDir))
+	}
+	return manifests.GitOpsConfig{
+		RepoURL:   f.GitOpsRepoURL,
+		Revision:  f.GitOpsRevision,
+		Path:      gitOpsPath,
+		Namespace: f.ArgoCDNamespace,
+		Project:   f.ArgoCDProject,
+	}
+}
+
+// OutputFlags controls how the prepare commands replace their output
+// directory.
+type OutputFlags struct {
+	Force  bool   `help:"Replace the output directory even if it is not empty and was not generated by bpfman-catalog"`
+	Diff   bool   `help:"Show how the generated files differ from the output directory's contents, without replacing them"`
+	Format string `default:"text" enum:"text,json" help:"Output format (text, json); json describes the generated files and next steps"`
+}
+
+// open checks that the output directory may be replaced and returns
+// it with a staging directory to write the generated files to. With
+// --diff the directory is only compared, so it is not checked.
+func (f OutputFlags) open(dir string) (*writer.OutputDir, error) {
+	if f.Diff {
+		return writer.NewPreviewDir(dir)
+	}
+	return writer.NewOutputDir(dir, f.Force)
+}
+
+// finish replaces the output directory with the staged files and
+// reports the result: as JSON, or with the command's text output.
+// With --diff it only shows the changes, leaving the output directory
+// as it was. The JSON result, which may need to resolve image
+// digests, is built before the directory is replaced, so that a
+// failure leaves the previous output in place.
+func (f OutputFlags) finish(out *writer.OutputDir, result func() (*writer.Result, error), printText func()) error {
+	if f.Diff {
+		return out.Diff(os.Stdout)
+	}
+
+	if f.Format != "json" {
+		if err := out.Commit(); err != nil {
+			return err
+		}
+		printText()
+		return nil
+	}
+
+	res, err := result()
+	if err != nil {
+		return err
+	}
+	res.OutputDir = out.Path()
+	if res.Files, err = out.StagedFiles(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return fmt.Errorf("formatting JSON output: %w", err)
+	}
+	if err := out.Commit(); err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// statusOut returns where progress messages go: stdout, or stderr
+// when stdout carries JSON.
+func (f OutputFlags) statusOut() *os.File {
+	if f.Format == "json" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// PrepareBundleInstallManifestsCmd prepares manifests that install a
+// bundle without OLM.
+type PrepareBundleInstallManifestsCmd struct {
+	BundleImage    string `arg:"" required:"" help:"Bundle image reference"`
+	OutputDir      string `default:"${default_install_dir}" help:"Output directory for generated manifests"`
+	Namespace      string `default:"bpfman" help:"Namespace to install the operator into"`
+	SkipValidation bool   `help:"Skip schema validation of the generated manifests"`
+
+	OutputFlags `embed:""`
+}
+
+// ValidateManifestsCmd validates a directory of generated manifests.
+type ValidateManifestsCmd struct {
+	Dir    string `arg:"" optional:"" type:"path" default:"${default_manifests_dir}" help:"Directory of manifests to validate"`
+	Format string `default:"text" enum:"text,json" help:"Output format (text, json)"`
 }
 
 // BundleInfoCmd shows bundle contents and dependencies.
 type BundleInfoCmd struct {
 	BundleImages []string `arg:"" required:"" help:"Bundle image references"`
 	Format       string   `default:"text" enum:"text,json" help:"Output format (text, json)"`
+	Validate     bool     `help:"Validate bundle manifests with the operator-framework validators"`
+	K8sVersion   string   `name:"k8s-version" help:"Kubernetes version to check for removed APIs with --validate (default: CSV minKubeVersion)"`
+	TargetOCP    string   `name:"target-ocp" help:"OpenShift version (e.g. 4.20) to check the bundle will install on"`
+	Catalog      string   `help:"Catalog file, directory or image to report the bundle's deprecation status from"`
+}
+
+// LintCatalogCmd lints the bundles in a catalog.
+type LintCatalogCmd struct {
+	Catalog string `arg:"" required:"" help:"Catalog file, directory or image reference"`
+	Package string `default:"bpfman-operator" help:"Package whose bundles are linted"`
+	Format  string `default:"text" enum:"text,json" help:"Output format (text, json)"`
+}
+
+// DiffBundlesCmd compares two bundles.
+type DiffBundlesCmd struct {
+	From   string `arg:"" required:"" help:"Older bundle image reference or unpacked bundle directory"`
+	To     string `arg:"" required:"" help:"Newer bundle image reference or unpacked bundle directory"`
+	Format string `default:"text" enum:"text,markdown,json" help:"Output format (text, markdown, json)"`
+}
+
+// CheckCRDCompatCmd checks CRD compatibility across upgrades.
+type CheckCRDCompatCmd struct {
+	Catalog string `arg:"" required:"" help:"Catalog file, directory or image reference"`
+	Package string `default:"bpfman-operator" help:"Package to check"`
+	Channel string `help:"Channel whose replaces chain is walked (default: the package's default channel)"`
+	Format  string `default:"text" enum:"text,json" help:"Output format (text, json)"`
+}
+
+// CheckVersionsCmd checks version consistency across catalog
+// templates.
+type CheckVersionsCmd struct {
+	Templates   string `arg:"" optional:"" type:"path" default:"templates" help:"Template file or directory of templates"`
+	RenderedDir string `name:"rendered-dir" type:"path" help:"Read rendered catalogs (e.g. auto-generated/catalog) instead of rendering the templates"`
+	SkipLabels  bool   `name:"skip-labels" help:"Do not inspect bundle image version labels"`
+	Format      string `default:"text" enum:"text,json" help:"Output format (text, json)"`
+}
+
+// UpgradePathCmd calculates an upgrade path through a catalog.
+type UpgradePathCmd struct {
+	Catalog string `arg:"" required:"" help:"Catalog file, directory or image reference"`
+	From    string `required:"" help:"Installed bundle, as a CSV name (e.g. bpfman-operator.v0.5.8) or version"`
+	To      string `help:"Target bundle, as a CSV name or version (default: the channel head)"`
+	Channel string `help:"Channel to upgrade within (default: the package's default channel)"`
+	Package string `default:"bpfman-operator" help:"Package to upgrade"`
+	Format  string `default:"text" enum:"text,json" help:"Output format (text, json)"`
+}
+
+// GraphCmd renders channel update graphs.
+type GraphCmd struct {
+	Catalog string `arg:"" required:"" help:"Catalog file, template, directory or image reference"`
+	Package string `default:"bpfman-operator" help:"Package to graph"`
+	Channel string `help:"Only graph this channel"`
+	Format  string `default:"mermaid" enum:"dot,mermaid" help:"Output format (dot, mermaid)"`
+}
+
+// ConvertTemplateCmd converts a basic catalog template to a semver
+// template.
+type ConvertTemplateCmd struct {
+	Template        string `arg:"" type:"existingfile" help:"Basic catalog template to convert"`
+	Output          string `short:"o" type:"path" help:"File to write the semver template to (default: stdout)"`
+	FallbackChannel string `default:"candidate" enum:"candidate,fast,stable" help:"Semver channel for bundles whose channel matches none (candidate, fast, stable)"`
+	MajorChannels   bool   `name:"generate-major-channels" help:"Generate major version channels"`
+	MinorChannels   bool   `name:"generate-minor-channels" help:"Generate minor version channels (the default when neither is set)"`
+}
+
+// DeprecateCmd adds olm.deprecations entries to a basic catalog
+// template.
+type DeprecateCmd struct {
+	Template     string   `arg:"" type:"existingfile" help:"Basic catalog template to edit"`
+	Bundle       []string `help:"Bundle to deprecate, by name or version (repeatable)"`
+	Channel      []string `help:"Channel to deprecate (repeatable)"`
+	WholePackage bool     `help:"Deprecate the whole package"`
+	Message      string   `required:"" help:"Deprecation message shown to users"`
+	Package      string   `help:"Package the deprecations belong to (default: the template's only package)"`
+	Output       string   `short:"o" type:"path" help:"File to write the edited template to (default: edit the template in place)"`
+}
+
+// BuildCatalogImageCmd builds a catalog image in-process.
+type BuildCatalogImageCmd struct {
+	Catalog    string `arg:"" type:"existingfile" help:"Rendered catalog.yaml to build the image from"`
+	Output     string `short:"o" required:"" help:"Where to write the image: oci:<dir>[:<tag>], oci-archive:<file>[:<tag>] or docker://<reference>"`
+	ServeCache bool   `default:"true" negatable:"" help:"Pre-build the opm serve cache into the image"`
+	Arch       string `default:"amd64" help:"Architecture to select from a multi-arch base image"`
+	AuthFile   string `type:"path" help:"Registry credentials file (default: the containers auth file)"`
+
+	DockerfileFlags `embed:""`
+}
+
+// PushAndDeployCmd pushes a locally built catalog image and prepares
+// deployment manifests for the pushed digest.
+type PushAndDeployCmd struct {
+	Source    string `arg:"" help:"Locally built catalog image: oci:<dir>[:<tag>] or oci-archive:<file>[:<tag>]"`
+	Image     string `required:"" help:"Registry reference to push the catalog image to"`
+	AuthFile  string `type:"path" help:"Registry credentials file (default: the containers auth file)"`
+	TLSVerify bool   `name:"tls-verify" default:"true" negatable:"" help:"Require HTTPS and verify certificates when pushing"`
+
+	DeploymentFlags `embed:""`
 }
 
 // ListBundlesCmd lists available bundle images.
@@ -80,15 +353,42 @@ func (r *PrepareCatalogBuildFromBundleCmd) Run(globals *GlobalContext) error {
 		return fmt.Errorf("output directory cannot be the current working directory, please specify a named subdirectory like '%s'", DefaultArtefactsDir)
 	}
 
-	if err := os.RemoveAll(r.OutputDir); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("cleaning output directory: %w", err)
+	dockerfile, err := r.options()
+	if err != nil {
+		return err
+	}
+
+	settings, err := provenance.FromEnvironment(r.Seed)
+	if err != nil {
+		return err
+	}
+
+	templates, err := bundle.LoadTemplates(r.TemplateDir)
+	if err != nil {
+		return err
+	}
+
+	out, err := r.open(r.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer out.Cleanup()
+
+	options := bundle.FBCOptions{
+		TemplateType:          r.TemplateType,
+		PackageFrom:           r.PackageFrom,
+		Channels:              r.Channel,
+		DefaultChannel:        r.DefaultChannel,
+		SkipRange:             r.SkipRange,
+		GenerateMajorChannels: r.MajorChannels,
+		GenerateMinorChannels: r.MinorChannels,
 	}
 
 	var gen *bundle.Generator
 	if r.OpmBin != "" {
-		gen = bundle.NewGeneratorWithOmp(r.BundleImage, "preview", r.OpmBin)
+		gen = bundle.NewGeneratorWithOmp(r.BundleImages, options, dockerfile, settings, templates, r.OpmBin)
 	} else {
-		gen = bundle.NewGenerator(r.BundleImage, "preview")
+		gen = bundle.NewGenerator(r.BundleImages, options, dockerfile, settings, templates)
 	}
 
 	artefacts, err := gen.Generate(globals.Context)
@@ -96,7 +396,7 @@ func (r *PrepareCatalogBuildFromBundleCmd) Run(globals *GlobalContext) error {
 		return fmt.Errorf("generating bundle artefacts: %w", err)
 	}
 
-	w := writer.New(r.OutputDir)
+	w := writer.New(out.Staging())
 
 	if err := w.WriteSingle("fbc-template.yaml", []byte(artefacts.FBCTemplate)); err != nil {
 		return fmt.Errorf("writing FBC template: %w", err)
@@ -114,17 +414,32 @@ func (r *PrepareCatalogBuildFromBundleCmd) Run(globals *GlobalContext) error {
 	if err := w.WriteSingle("Makefile", []byte(artefacts.Makefile)); err != nil {
 		return fmt.Errorf("writing Makefile: %w", err)
 	}
+	if err := writeProvenance(w, artefacts.Provenance); err != nil {
+		return err
+	}
 
-	catalogRendered := artefacts.CatalogYAML != ""
-	imageUUID, randomTTL := bundle.GenerateImageUUIDAndTTL()
-	workflow := bundle.GenerateWorkflow(0, catalogRendered, r.OutputDir, imageUUID, randomTTL)
+	imageUUID, randomTTL := bundle.GenerateImageUUIDAndTTL(settings.Rand())
+	workflow, err := templates.GenerateWorkflow(artefacts.Catalog, r.OutputDir, imageUUID, randomTTL)
+	if err != nil {
+		return err
+	}
 	if err := w.WriteSingle("WORKFLOW.txt", []byte(workflow)); err != nil {
 		return fmt.Errorf("writing WORKFLOW.txt: %w", err)
 	}
 
-	fmt.Print(workflow)
-	fmt.Printf("\nThis information is saved in %s/WORKFLOW.txt\n", r.OutputDir)
-	return nil
+	result := func() (*writer.Result, error) {
+		res := &writer.Result{
+			Command:    "prepare-catalog-build-from-bundle",
+			Bundles:    artefacts.Provenance.Bundles,
+			RenderMode: artefacts.Provenance.OpmMode,
+			NextSteps:  buildNextSteps(r.OutputDir),
+		}
+		return res, describeCatalog(res, []byte(artefacts.CatalogYAML))
+	}
+	return r.finish(out, result, func() {
+		fmt.Print(workflow)
+		fmt.Printf("\nThis information is saved in %s/WORKFLOW.txt\n", r.OutputDir)
+	})
 }
 
 func (r *PrepareCatalogBuildFromYAMLCmd) Run(globals *GlobalContext) error {
@@ -132,83 +447,335 @@ func (r *PrepareCatalogBuildFromYAMLCmd) Run(globals *GlobalContext) error {
 		return fmt.Errorf("output directory cannot be the current working directory, please specify a named subdirectory like '%s'", DefaultArtefactsDir)
 	}
 
-	if err := os.RemoveAll(r.OutputDir); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("cleaning output directory: %w", err)
+	dockerfileOptions, err := r.options()
+	if err != nil {
+		return err
+	}
+
+	settings, err := provenance.FromEnvironment(r.Seed)
+	if err != nil {
+		return err
+	}
+
+	templates, err := bundle.LoadTemplates(r.TemplateDir)
+	if err != nil {
+		return err
+	}
+
+	out, err := r.open(r.OutputDir)
+	if err != nil {
+		return err
 	}
+	defer out.Cleanup()
 
 	catalogContent, err := os.ReadFile(r.CatalogYAML)
 	if err != nil {
 		return fmt.Errorf("reading catalog.yaml: %w", err)
 	}
 
-	w := writer.New(r.OutputDir)
+	w := writer.New(out.Staging())
 
 	if err := w.WriteSingle("catalog.yaml", catalogContent); err != nil {
 		return fmt.Errorf("writing catalog.yaml: %w", err)
 	}
 
-	dockerfile := bundle.GenerateCatalogDockerfile()
+	catalogData := bundle.NewCatalogData(nil, string(catalogContent))
+	dockerfile, err := templates.GenerateCatalogDockerfile(catalogData, dockerfileOptions)
+	if err != nil {
+		return err
+	}
 	if err := w.WriteSingle("Dockerfile", []byte(dockerfile)); err != nil {
 		return fmt.Errorf("writing Dockerfile: %w", err)
 	}
 
-	execPath, err := os.Executable()
+	imageUUID, randomTTL := bundle.GenerateImageUUIDAndTTL(settings.Rand())
+
+	makefile, err := templates.GenerateMakefile(catalogData, settings.Executable(), imageUUID, randomTTL)
 	if err != nil {
-		execPath = "bpfman-catalog"
+		return err
 	}
-
-	imageUUID, randomTTL := bundle.GenerateImageUUIDAndTTL()
-
-	makefile := bundle.GenerateMakefile("from-yaml", execPath, imageUUID, randomTTL)
 	if err := w.WriteSingle("Makefile", []byte(makefile)); err != nil {
 		return fmt.Errorf("writing Makefile: %w", err)
 	}
 
-	workflow := bundle.GenerateWorkflow(0, true, r.OutputDir, imageUUID, randomTTL)
+	record := provenance.NewRecord("prepare-catalog-build-from-yaml", settings)
+	record.Catalog = &provenance.Catalog{
+		Path:   relativePath(r.CatalogYAML),
+		Digest: digest.FromBytes(catalogContent).String(),
+	}
+	record.Templates = templates.Overrides()
+	if err := writeProvenance(w, record); err != nil {
+		return err
+	}
+
+	workflow, err := templates.GenerateWorkflow(catalogData, r.OutputDir, imageUUID, randomTTL)
+	if err != nil {
+		return err
+	}
 	if err := w.WriteSingle("WORKFLOW.txt", []byte(workflow)); err != nil {
 		return fmt.Errorf("writing WORKFLOW.txt: %w", err)
 	}
 
-	fmt.Print(workflow)
-	fmt.Printf("\nThis information is saved in %s/WORKFLOW.txt\n", r.OutputDir)
-
-	return nil
+	result := func() (*writer.Result, error) {
+		res := &writer.Result{
+			Command:   "prepare-catalog-build-from-yaml",
+			NextSteps: buildNextSteps(r.OutputDir),
+		}
+		if err := describeCatalog(res, catalogContent); err != nil {
+			return nil, err
+		}
+		return res, resolveCatalogBundles(globals.Context, res, catalogContent)
+	}
+	return r.finish(out, result, func() {
+		fmt.Print(workflow)
+		fmt.Printf("\nThis information is saved in %s/WORKFLOW.txt\n", r.OutputDir)
+	})
 }
 
 func (r *PrepareCatalogDeploymentFromImageCmd) Run(globals *GlobalContext) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	return r.generate(globals.Context, "prepare-catalog-deployment-from-image", r.CatalogImage)
+}
+
+// validate checks the flags before anything is pulled or written.
+func (r DeploymentFlags) validate() error {
 	if filepath.Clean(r.OutputDir) == "." {
 		return fmt.Errorf("output directory cannot be the current working directory, please specify a named subdirectory like '%s'", DefaultManifestsDir)
 	}
 
-	if err := os.RemoveAll(r.OutputDir); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("cleaning output directory: %w", err)
+	if r.GitOps != "" && r.GitOpsRepoURL == "" {
+		return fmt.Errorf("--gitops-repo-url is required when --gitops is set")
 	}
 
+	// With --gitops the catalog directory is committed to git, and the
+	// pull secret would be committed with it in plain text.
+	if r.GitOps != "" && r.PullSecretFrom != "" {
+		return fmt.Errorf("--pull-secret-from-authfile cannot be used with --gitops, as it would commit registry credentials to git; create the pull secret on the cluster, or with a secrets manager, instead")
+	}
+
+	if r.GlobalPullSecret != "" && r.PullSecretFrom == "" {
+		return fmt.Errorf("--global-pull-secret requires --pull-secret-from-authfile")
+	}
+	if r.Diff {
+		return nil
+	}
+	return writer.CheckOutputDir(r.OutputDir, r.Force)
+}
+
+// generate writes the deployment manifests for a catalog image to
+// the output directory.
+func (r DeploymentFlags) generate(ctx context.Context, command, catalogImage string) error {
+	settings, err := provenance.FromEnvironment("")
+	if err != nil {
+		return err
+	}
+
+	out, err := r.open(r.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer out.Cleanup()
+
 	config := manifests.GeneratorConfig{
-		Namespace:     "bpfman",
-		UseDigestName: true,
-		ImageRef:      r.CatalogImage,
+		Namespace:        "bpfman",
+		UseDigestName:    true,
+		ImageRef:         catalogImage,
+		AuthFile:         r.PullSecretFrom,
+		GlobalPullSecret: r.GlobalPullSecret,
+		Timestamp:        settings.Now(),
 	}
 
 	generator := manifests.NewGenerator(config)
 
-	manifestSet, err := generator.GenerateFromCatalog(globals.Context)
+	manifestSet, err := generator.GenerateFromCatalog(ctx)
 	if err != nil {
 		return fmt.Errorf("generating manifests: %w", err)
 	}
 
-	writer := writer.New(r.OutputDir)
-	if err := writer.WriteAll(manifestSet); err != nil {
+	var argoCDSet *manifests.ArgoCDSet
+	if r.GitOps == "argocd" {
+		argoCDSet, err = generator.GenerateArgoCD(manifestSet, r.gitOpsConfig())
+		if err != nil {
+			return fmt.Errorf("generating Argo CD resources: %w", err)
+		}
+	}
+
+	if !r.SkipValidation {
+		issues, err := manifests.ValidateManifestSet(manifestSet)
+		if err != nil {
+			return fmt.Errorf("validating manifests: %w", err)
+		}
+		if len(issues) > 0 {
+			printValidationIssues(issues)
+			return fmt.Errorf("generated manifests failed validation with %d issue(s)", len(issues))
+		}
+	}
+
+	w := writer.New(out.Staging())
+	if err := w.WriteAll(manifestSet); err != nil {
 		return fmt.Errorf("writing manifests: %w", err)
 	}
 
-	fmt.Printf("Manifests generated in %s\n", r.OutputDir)
+	if argoCDSet != nil {
+		if err := w.WriteArgoCD(argoCDSet); err != nil {
+			return fmt.Errorf("writing Argo CD resources: %w", err)
+		}
+	}
+	result := func() (*writer.Result, error) {
+		catalogImage := manifestSet.CatalogSource.Spec.Image
+		res := &writer.Result{
+			Command:      command,
+			CatalogImage: catalogImage,
+			NextSteps: []string{
+				"kubectl apply -f " + filepath.Join(r.OutputDir, "catalog"),
+				"kubectl apply -f " + filepath.Join(r.OutputDir, "subscription"),
+			},
+		}
+		if _, d, ok := strings.Cut(catalogImage, "@"); ok {
+			res.CatalogImageDigest = d
+		}
+		if sub := manifestSet.Subscription; sub != nil {
+			res.Package = sub.Spec.Name
+			res.DefaultChannel = sub.Spec.Channel
+		}
+		if manifestSet.GlobalPullSecretPatch != nil {
+			res.NextSteps = append(res.NextSteps, "kubectl patch secret/pull-secret -n openshift-config --type merge --patch-file "+
+				filepath.Join(r.OutputDir, "patches", "global-pull-secret.yaml"))
+		}
+		return res, nil
+	}
+	return r.finish(out, result, func() {
+		if argoCDSet != nil {
+			fmt.Printf("Argo CD Applications generated in %s\n", filepath.Join(r.OutputDir, "argocd"))
+		}
+
+		fmt.Printf("Manifests generated in %s\n", r.OutputDir)
+		if manifestSet.GlobalPullSecretPatch != nil {
+			fmt.Printf("Apply the global pull secret patch with:\n  kubectl patch secret/pull-secret -n openshift-config --type merge --patch-file %s\n",
+				filepath.Join(r.OutputDir, "patches", "global-pull-secret.yaml"))
+		}
+	})
+}
+
+func (r *PrepareBundleInstallManifestsCmd) Run(globals *GlobalContext) error {
+	if filepath.Clean(r.OutputDir) == "." {
+		return fmt.Errorf("output directory cannot be the current working directory, please specify a named subdirectory like '%s'", DefaultInstallDir)
+	}
+
+	out, err := r.open(r.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer out.Cleanup()
+
+	config := manifests.GeneratorConfig{
+		Namespace:     r.Namespace,
+		UseDigestName: true,
+		ImageRef:      r.BundleImage,
+	}
+
+	generator := manifests.NewGenerator(config)
+
+	installSet, err := generator.GenerateFromBundle(globals.Context)
+	if err != nil {
+		return fmt.Errorf("generating install manifests: %w", err)
+	}
+
+	if !r.SkipValidation {
+		issues, err := manifests.ValidateBundleInstallSet(installSet)
+		if err != nil {
+			return fmt.Errorf("validating manifests: %w", err)
+		}
+		if len(issues) > 0 {
+			printValidationIssues(issues)
+			return fmt.Errorf("generated manifests failed validation with %d issue(s)", len(issues))
+		}
+	}
+
+	w := writer.New(out.Staging())
+	if err := w.WriteBundleInstall(installSet); err != nil {
+		return fmt.Errorf("writing manifests: %w", err)
+	}
+	result := func() (*writer.Result, error) {
+		res := &writer.Result{
+			Command:   "prepare-bundle-install-manifests",
+			NextSteps: []string{"kubectl apply -f " + r.OutputDir},
+		}
+		d, err := catalog.ResolveDigest(globals.Context, r.BundleImage)
+		if err != nil {
+			return nil, fmt.Errorf("resolving digest of %s: %w", r.BundleImage, err)
+		}
+		res.Bundles = []provenance.Bundle{{Image: r.BundleImage, Digest: d.String()}}
+		return res, nil
+	}
+	return r.finish(out, result, func() {
+		fmt.Printf("Install manifests generated in %s\n", r.OutputDir)
+	})
+}
+
+func (r *ValidateManifestsCmd) Run(globals *GlobalContext) error {
+	issues, err := manifests.ValidateDir(r.Dir)
+	if err != nil {
+		return fmt.Errorf("validating manifests: %w", err)
+	}
+
+	if r.Format == "json" {
+		if issues == nil {
+			issues = []manifests.ValidationIssue{}
+		}
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting JSON output: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if len(issues) == 0 {
+		fmt.Printf("All manifests in %s are valid\n", r.Dir)
+	} else {
+		printValidationIssues(issues)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d validation issue(s) found in %s", len(issues), r.Dir)
+	}
 	return nil
 }
 
+func printValidationIssues(issues []manifests.ValidationIssue) {
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "  %s\n", issue)
+	}
+}
+
 func (r *BundleInfoCmd) Run(globals *GlobalContext) error {
+	if r.K8sVersion != "" && !r.Validate {
+		return fmt.Errorf("--k8s-version requires --validate")
+	}
+
+	if r.TargetOCP != "" {
+		if err := analysis.ValidateOCPVersion(r.TargetOCP); err != nil {
+			return err
+		}
+	}
+
+	config := analysis.AnalyseConfig{
+		Validate:   r.Validate,
+		K8sVersion: r.K8sVersion,
+		TargetOCP:  r.TargetOCP,
+	}
+
+	if r.Catalog != "" {
+		cfg, err := catalog.Load(globals.Context, r.Catalog)
+		if err != nil {
+			return fmt.Errorf("loading catalog: %w", err)
+		}
+		config.Catalog = cfg
+	}
+
+	var invalid []string
 	for _, bundleImage := range r.BundleImages {
-		result, err := analysis.AnalyseBundle(globals.Context, bundleImage)
+		result, err := analysis.AnalyseBundle(globals.Context, bundleImage, config)
 		if err != nil {
 			return fmt.Errorf("failed to analyse bundle %s: %w", bundleImage, err)
 		}
@@ -219,11 +786,267 @@ func (r *BundleInfoCmd) Run(globals *GlobalContext) error {
 		}
 
 		fmt.Print(output)
+
+		if result.Validation.HasErrors() || result.Compatibility.Failed() {
+			invalid = append(invalid, bundleImage)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("bundle checks failed for %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+func (r *LintCatalogCmd) Run(globals *GlobalContext) error {
+	cfg, err := catalog.Load(globals.Context, r.Catalog)
+	if err != nil {
+		return fmt.Errorf("loading catalog: %w", err)
+	}
+
+	report, err := analysis.LintCatalog(globals.Context, cfg, r.Package)
+	if err != nil {
+		return fmt.Errorf("linting catalog: %w", err)
+	}
+
+	output, err := analysis.FormatLintReport(report, r.Format)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+	fmt.Print(output)
+
+	if report.Failures > 0 {
+		return fmt.Errorf("%d bundle(s) failed linting", report.Failures)
+	}
+	return nil
+}
+
+func (r *DiffBundlesCmd) Run(globals *GlobalContext) error {
+	diff, err := analysis.DiffBundleRefs(globals.Context, r.From, r.To)
+	if err != nil {
+		return fmt.Errorf("comparing bundles: %w", err)
+	}
+
+	output, err := analysis.FormatBundleDiff(diff, r.Format)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+
+	fmt.Print(output)
+	return nil
+}
+
+func (r *CheckCRDCompatCmd) Run(globals *GlobalContext) error {
+	cfg, err := catalog.Load(globals.Context, r.Catalog)
+	if err != nil {
+		return fmt.Errorf("loading catalog: %w", err)
+	}
+
+	report, err := analysis.CheckCRDCompat(globals.Context, cfg, r.Package, r.Channel)
+	if err != nil {
+		return fmt.Errorf("checking CRD compatibility: %w", err)
+	}
+
+	output, err := analysis.FormatCRDCompatReport(report, r.Format)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+	fmt.Print(output)
+
+	if report.Failures > 0 {
+		return fmt.Errorf("%d upgrade(s) failed the CRD compatibility check", report.Failures)
+	}
+	return nil
+}
+
+func (r *CheckVersionsCmd) Run(globals *GlobalContext) error {
+	templates := []string{r.Templates}
+	if info, err := os.Stat(r.Templates); err == nil && info.IsDir() {
+		templates, err = analysis.FindTemplates(r.Templates)
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := analysis.CheckVersions(globals.Context, templates, analysis.VersionCheckConfig{
+		RenderedDir: r.RenderedDir,
+		SkipLabels:  r.SkipLabels,
+	})
+	if err != nil {
+		return fmt.Errorf("checking versions: %w", err)
+	}
+
+	output, err := analysis.FormatVersionReport(report, r.Format)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+	fmt.Print(output)
+
+	if report.Failures > 0 {
+		return fmt.Errorf("%d template(s) failed version checks", report.Failures)
+	}
+	return nil
+}
+
+func (r *UpgradePathCmd) Run(globals *GlobalContext) error {
+	cfg, err := catalog.Load(globals.Context, r.Catalog)
+	if err != nil {
+		return fmt.Errorf("loading catalog: %w", err)
+	}
+
+	path, err := catalog.FindUpgradePath(cfg, r.Package, r.Channel, r.From, r.To)
+	if err != nil {
+		return err
+	}
+
+	output, err := catalog.FormatUpgradePath(path, r.Format)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+
+	fmt.Print(output)
+	return nil
+}
+
+func (r *GraphCmd) Run(globals *GlobalContext) error {
+	cfg, err := catalog.LoadOrRender(globals.Context, r.Catalog)
+	if err != nil {
+		return fmt.Errorf("loading catalog: %w", err)
 	}
 
+	graphs, err := catalog.BuildChannelGraphs(cfg, r.Package, r.Channel)
+	if err != nil {
+		return err
+	}
+
+	output, err := catalog.FormatGraphs(graphs, r.Format)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+
+	fmt.Print(output)
 	return nil
 }
 
+func (r *ConvertTemplateCmd) Run(globals *GlobalContext) error {
+	data, err := os.ReadFile(r.Template)
+	if err != nil {
+		return fmt.Errorf("reading template: %w", err)
+	}
+
+	conv, err := catalog.ConvertToSemver(data, r.FallbackChannel, r.MajorChannels, r.MinorChannels)
+	if err != nil {
+		return fmt.Errorf("converting %s: %w", r.Template, err)
+	}
+	for _, warning := range conv.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	output, err := yaml.Marshal(conv.Template)
+	if err != nil {
+		return fmt.Errorf("marshaling semver template: %w", err)
+	}
+	if r.Output == "" {
+		fmt.Print(string(output))
+		return nil
+	}
+	if err := os.WriteFile(r.Output, output, 0644); err != nil {
+		return fmt.Errorf("writing semver template: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Semver template written to %s\n", r.Output)
+	return nil
+}
+
+func (r *DeprecateCmd) Run(globals *GlobalContext) error {
+	data, err := os.ReadFile(r.Template)
+	if err != nil {
+		return fmt.Errorf("reading template: %w", err)
+	}
+
+	targets := catalog.DeprecationTargets{
+		Package:  r.WholePackage,
+		Channels: r.Channel,
+		Bundles:  r.Bundle,
+	}
+	output, applied, err := catalog.Deprecate(data, r.Package, targets, r.Message)
+	if err != nil {
+		return fmt.Errorf("deprecating in %s: %w", r.Template, err)
+	}
+
+	path := r.Output
+	if path == "" {
+		path = r.Template
+	}
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return fmt.Errorf("writing template: %w", err)
+	}
+	for _, d := range applied {
+		if d.Name == "" {
+			fmt.Printf("Deprecated %s\n", d.Scope)
+		} else {
+			fmt.Printf("Deprecated %s %s\n", d.Scope, d.Name)
+		}
+	}
+	fmt.Printf("Template written to %s\n", path)
+	return nil
+}
+
+func (r *BuildCatalogImageCmd) Run(globals *GlobalContext) error {
+	dockerfile, err := r.options()
+	if err != nil {
+		return err
+	}
+
+	settings, err := provenance.FromEnvironment("")
+	if err != nil {
+		return err
+	}
+
+	catalogYAML, err := os.ReadFile(r.Catalog)
+	if err != nil {
+		return fmt.Errorf("reading catalog: %w", err)
+	}
+
+	pkg, version := bundle.CatalogBuildInfo(string(catalogYAML))
+	opts := catalog.ImageBuildOptions{
+		BaseImage:   dockerfile.ResolvedBaseImage(),
+		Destination: r.Output,
+		CatalogYAML: catalogYAML,
+		Package:     pkg,
+		Labels:      bundle.CatalogLabels(version, dockerfile.Commit),
+		ServeCache:  r.ServeCache,
+		Arch:        r.Arch,
+		Created:     settings.Now().UTC(),
+		AuthFile:    r.AuthFile,
+	}
+	globals.Logger.Info("building catalog image", "base", opts.BaseImage, "package", pkg, "version", version)
+
+	result, err := catalog.BuildImage(globals.Context, opts)
+	if err != nil {
+		return fmt.Errorf("building catalog image: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Catalog image written to %s\n", result.Destination)
+	fmt.Println(result.Digest)
+	return nil
+}
+
+func (r *PushAndDeployCmd) Run(globals *GlobalContext) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	digestRef, err := catalog.PushImage(globals.Context, r.Source, r.Image, catalog.PushOptions{
+		AuthFile:      r.AuthFile,
+		SkipTLSVerify: !r.TLSVerify,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.statusOut(), "Pushed %s\n", digestRef)
+
+	return r.generate(globals.Context, "push-and-deploy", digestRef)
+}
+
 func (r *ListBundlesCmd) Run(globals *GlobalContext) error {
 	var bundleRef bundle.BundleRef
 	var err error
@@ -284,6 +1107,86 @@ func formatBundlesJSON(bundles []*bundle.BundleMetadata) (string, error) {
 	return string(data), nil
 }
 
+// writeProvenance writes the provenance record of generated
+// artefacts.
+func writeProvenance(w *writer.ManifestWriter, record *provenance.Record) error {
+	data, err := record.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := w.WriteSingle(provenance.File, data); err != nil {
+		return fmt.Errorf("writing %s: %w", provenance.File, err)
+	}
+	return nil
+}
+
+// relativePath returns path relative to the working directory when
+// it is below it, so that recorded inputs do not depend on where the
+// checkout is.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// buildNextSteps returns the commands that build, push and deploy
+// the catalog from generated build artefacts.
+func buildNextSteps(outputDir string) []string {
+	var steps []string
+	for _, target := range []string{"build-catalog-image", "push-catalog-image", "deploy-catalog", "subscribe"} {
+		steps = append(steps, fmt.Sprintf("make -C %s %s", outputDir, target))
+	}
+	return steps
+}
+
+// describeCatalog records the package and channels of a rendered
+// catalog and the digest of its content.
+func describeCatalog(res *writer.Result, catalogYAML []byte) error {
+	if len(catalogYAML) == 0 {
+		return nil
+	}
+	res.CatalogFileDigest = digest.FromBytes(catalogYAML).String()
+
+	cfg, err := declcfg.LoadReader(bytes.NewReader(catalogYAML))
+	if err != nil {
+		return fmt.Errorf("parsing catalog: %w", err)
+	}
+	if len(cfg.Packages) != 1 {
+		return nil
+	}
+	res.Package = cfg.Packages[0].Name
+	res.DefaultChannel = cfg.Packages[0].DefaultChannel
+	for _, ch := range cfg.Channels {
+		if ch.Package == res.Package {
+			res.Channels = append(res.Channels, ch.Name)
+		}
+	}
+	return nil
+}
+
+// resolveCatalogBundles records the bundles of a catalog and their
+// digests.
+func resolveCatalogBundles(ctx context.Context, res *writer.Result, catalogYAML []byte) error {
+	cfg, err := declcfg.LoadReader(bytes.NewReader(catalogYAML))
+	if err != nil {
+		return fmt.Errorf("parsing catalog: %w", err)
+	}
+	for _, b := range cfg.Bundles {
+		d, err := catalog.ResolveDigest(ctx, b.Image)
+		if err != nil {
+			return fmt.Errorf("resolving digest of %s: %w", b.Image, err)
+		}
+		res.Bundles = append(res.Bundles, provenance.Bundle{Image: b.Image, Digest: d.String()})
+	}
+	return nil
+}
+
 func printWorkflowGuide() {
 	fmt.Printf(`
 Workflows:
@@ -341,6 +1244,8 @@ func main() {
 		kong.Vars{
 			"default_artefacts_dir": DefaultArtefactsDir,
 			"default_manifests_dir": DefaultManifestsDir,
+			"default_install_dir":   DefaultInstallDir,
+			"default_ocp_version":   bundle.DefaultOCPVersion,
 		},
 		kong.Exit(func(code int) {
 			// Print workflow guide before exiting on help
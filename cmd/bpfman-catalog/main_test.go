@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/openshift/bpfman-catalog/pkg/manifests"
+	"github.com/openshift/bpfman-catalog/pkg/provenance"
+	"github.com/openshift/bpfman-catalog/pkg/writer"
 )
 
 // TestOutputDirValidation tests that we never allow the current working directory
@@ -116,3 +124,263 @@ func TestFilePathCleaningBehaviour(t *testing.T) {
 		})
 	}
 }
+
+// TestPrepareCatalogBuildFromYAMLDeterministic tests that artefacts
+// generated with SOURCE_DATE_EPOCH are identical between runs and
+// record their inputs.
+func TestPrepareCatalogBuildFromYAMLDeterministic(t *testing.T) {
+	t.Setenv(provenance.SourceDateEpochEnv, "1700000000")
+	dir := t.TempDir()
+	catalogYAML := filepath.Join(dir, "catalog.yaml")
+	if err := os.WriteFile(catalogYAML, []byte("---\nschema: olm.package\nname: bpfman-operator\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := PrepareCatalogBuildFromYAMLCmd{CatalogYAML: catalogYAML, OutputDir: filepath.Join(dir, "artefacts")}
+
+	runs := make([]map[string]string, 2)
+	for i := range runs {
+		if err := cmd.Run(&GlobalContext{Context: context.Background()}); err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, err)
+		}
+		runs[i] = map[string]string{}
+		for _, name := range []string{"Makefile", "WORKFLOW.txt", "Dockerfile", provenance.File} {
+			data, err := os.ReadFile(filepath.Join(cmd.OutputDir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			runs[i][name] = string(data)
+		}
+	}
+
+	for name, first := range runs[0] {
+		if runs[1][name] != first {
+			t.Errorf("%s differs between runs", name)
+		}
+	}
+	if !strings.Contains(runs[0]["Makefile"], "BPFMAN_CATALOG := bpfman-catalog\n") {
+		t.Errorf("Makefile records the executable path")
+	}
+	for _, want := range []string{`"command": "prepare-catalog-build-from-yaml"`, `"source_date_epoch": 1700000000`, `"digest": "sha256:`} {
+		if !strings.Contains(runs[0][provenance.File], want) {
+			t.Errorf("%s missing %s:\n%s", provenance.File, want, runs[0][provenance.File])
+		}
+	}
+}
+
+// TestDescribeCatalog tests the package, channels and digest recorded
+// in JSON results for a rendered catalog.
+func TestDescribeCatalog(t *testing.T) {
+	catalogYAML := []byte(`---
+schema: olm.package
+name: bpfman-operator
+defaultChannel: stable
+---
+schema: olm.channel
+package: bpfman-operator
+name: candidate
+entries: []
+---
+schema: olm.channel
+package: bpfman-operator
+name: stable
+entries: []
+`)
+	var res writer.Result
+	if err := describeCatalog(&res, catalogYAML); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Package != "bpfman-operator" || res.DefaultChannel != "stable" || strings.Join(res.Channels, " ") != "candidate stable" {
+		t.Errorf("got package %q, default channel %q and channels %v", res.Package, res.DefaultChannel, res.Channels)
+	}
+	if !strings.HasPrefix(res.CatalogFileDigest, "sha256:") {
+		t.Errorf("got catalog digest %q", res.CatalogFileDigest)
+	}
+}
+
+// TestGitOpsConfig tests that the Applications track the output
+// directory at HEAD unless --gitops-path and --gitops-revision are
+// given.
+func TestGitOpsConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		flags        DeploymentFlags
+		wantPath     string
+		wantRevision string
+	}{
+		{
+			name:         "gitops path unset",
+			flags:        DeploymentFlags{OutputDir: "./auto-generated/manifests/", GitOpsRepoURL: "https://github.com/example/fleet.git"},
+			wantPath:     "auto-generated/manifests/catalog",
+			wantRevision: "HEAD",
+		},
+		{
+			name:         "gitops path set",
+			flags:        DeploymentFlags{OutputDir: "out", GitOpsPath: "clusters/dev", GitOpsRevision: "main", GitOpsRepoURL: "https://github.com/example/fleet.git"},
+			wantPath:     "clusters/dev/catalog",
+			wantRevision: "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argoCDSet, err := manifests.NewGenerator(manifests.GeneratorConfig{}).GenerateArgoCD(&manifests.ManifestSet{}, tt.flags.gitOpsConfig())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			src := argoCDSet.CatalogApplication.Spec.Source
+			if src.Path != tt.wantPath || src.TargetRevision != tt.wantRevision {
+				t.Errorf("got path %s at %s, want %s at %s", src.Path, src.TargetRevision, tt.wantPath, tt.wantRevision)
+			}
+		})
+	}
+}
+
+// TestDeploymentFlagsValidate tests the combinations of deployment
+// flags that are rejected.
+func TestDeploymentFlagsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    DeploymentFlags
+		unmarked bool
+		wantErr  string
+	}{
+		{
+			name:  "gitops",
+			flags: DeploymentFlags{GitOps: "argocd", GitOpsRepoURL: "https://github.com/example/fleet.git"},
+		},
+		{
+			name:  "pull secret",
+			flags: DeploymentFlags{PullSecretFrom: "auth.json"},
+		},
+		{
+			name:    "gitops without repository",
+			flags:   DeploymentFlags{GitOps: "argocd"},
+			wantErr: "--gitops-repo-url",
+		},
+		{
+			name:    "gitops with pull secret",
+			flags:   DeploymentFlags{GitOps: "argocd", GitOpsRepoURL: "https://github.com/example/fleet.git", PullSecretFrom: "auth.json"},
+			wantErr: "would commit registry credentials",
+		},
+		{
+			name:     "unmarked output directory",
+			unmarked: true,
+			wantErr:  "not empty",
+		},
+		{
+			name:     "diff unmarked output directory",
+			flags:    DeploymentFlags{OutputFlags: OutputFlags{Diff: true}},
+			unmarked: true,
+		},
+		{
+			name:    "global pull secret without pull secret",
+			flags:   DeploymentFlags{GlobalPullSecret: "pull-secret.json"},
+			wantErr: "--global-pull-secret requires",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "manifests")
+			if tt.unmarked {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.flags.OutputDir = dir
+			err := tt.flags.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestOutputFlagsFinish tests that --diff, and a JSON result that
+// cannot be built, leave the output directory as it was. --diff may
+// preview changes to a directory that it could not replace.
+func TestOutputFlagsFinish(t *testing.T) {
+	tests := []struct {
+		name      string
+		flags     OutputFlags
+		unmarked  bool
+		resultErr error
+		wantErr   string
+	}{
+		{
+			name:  "diff",
+			flags: OutputFlags{Diff: true},
+		},
+		{
+			name:     "diff unmarked directory",
+			flags:    OutputFlags{Diff: true},
+			unmarked: true,
+		},
+		{
+			name:      "json result fails",
+			flags:     OutputFlags{Format: "json"},
+			resultErr: errors.New("resolving digest of quay.io/example/bundle:latest: unauthorized"),
+			wantErr:   "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "artefacts")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{writer.MarkerFile: "", "Makefile": "all:\n"}
+			if tt.unmarked {
+				delete(files, writer.MarkerFile)
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out, err := tt.flags.open(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer out.Cleanup()
+			if err := os.WriteFile(filepath.Join(out.Staging(), "Makefile"), []byte("deploy:\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.flags.finish(out, func() (*writer.Result, error) {
+				if tt.flags.Diff {
+					t.Error("result requested for a dry run")
+				}
+				return &writer.Result{}, tt.resultErr
+			}, func() {
+				t.Error("text output printed")
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "Makefile"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "all:\n" {
+				t.Errorf("output directory was replaced: Makefile is %q", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	crdschema "github.com/openshift/bpfman-catalog/pkg/schema"
+)
+
+// almExamplesAnnotation holds the example CRs the OperatorHub console
+// offers as starting templates.
+const almExamplesAnnotation = "alm-examples"
+
+// ALMExampleIssue is a problem with one of the CSV's alm-examples.
+type ALMExampleIssue struct {
+	Index   int    `json:"index"` // Position in the alm-examples array, or -1 if it does not parse
+	Kind    string `json:"kind,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Path    string `json:"path,omitempty"` // JSON path within the example
+	Message string `json:"message"`
+}
+
+// Field returns the location of the issue within the annotation,
+// e.g. alm-examples[0].spec.nodeSelector.
+func (i ALMExampleIssue) Field() string {
+	if i.Index < 0 {
+		return almExamplesAnnotation
+	}
+	return fmt.Sprintf("%s[%d]%s", almExamplesAnnotation, i.Index, i.Path)
+}
+
+// String returns a one-line description of the issue.
+func (i ALMExampleIssue) String() string {
+	subject := i.Field()
+	if i.Kind != "" {
+		subject = fmt.Sprintf("%s (%s %s)", subject, i.Kind, i.Name)
+	}
+	return fmt.Sprintf("%s: %s", subject, i.Message)
+}
+
+// CheckALMExamples validates each example in the CSV's alm-examples
+// annotation against the structural schema of the matching CRD in the
+// bundle, matched by group, version and kind. A CSV without examples
+// has no issues; the OperatorHub validator already warns about that.
+func CheckALMExamples(manifests []Manifest) ([]ALMExampleIssue, error) {
+	csv, _, err := FindCSV(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := strings.TrimSpace(csv.Annotations[almExamplesAnnotation])
+	if raw == "" {
+		return nil, nil
+	}
+
+	var examples []map[string]any
+	if err := json.Unmarshal([]byte(raw), &examples); err != nil {
+		return []ALMExampleIssue{{Index: -1, Message: fmt.Sprintf("not a JSON array of objects: %v", err)}}, nil
+	}
+
+	crds, err := bundleCRDs(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ALMExampleIssue
+	for i, example := range examples {
+		m := Manifest{File: almExamplesAnnotation, Object: example}
+		apiVersion, kind := m.APIVersion(), m.Kind()
+		issue := ALMExampleIssue{Index: i, Kind: kind, Name: m.Name()}
+
+		if apiVersion == "" || kind == "" {
+			issue.Message = "example must set apiVersion and kind"
+			issues = append(issues, issue)
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			issue.Message = fmt.Sprintf("invalid apiVersion %q: %v", apiVersion, err)
+			issues = append(issues, issue)
+			continue
+		}
+
+		s, msg := exampleSchema(crds, gv.WithKind(kind))
+		if s == nil {
+			issue.Message = msg
+			issues = append(issues, issue)
+			continue
+		}
+
+		fieldErrs, err := crdschema.Validate(s, example)
+		if err != nil {
+			issue.Message = fmt.Sprintf("cannot validate against the CRD schema: %v", err)
+			issues = append(issues, issue)
+			continue
+		}
+		for _, fe := range fieldErrs {
+			issue.Path = fe.Path
+			issue.Message = fe.Message
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// bundleCRDs returns the apiextensions.k8s.io/v1 CRDs in a bundle.
+func bundleCRDs(manifests []Manifest) ([]*apiextv1.CustomResourceDefinition, error) {
+	var crds []*apiextv1.CustomResourceDefinition
+	for _, m := range manifests {
+		if m.Kind() != "CustomResourceDefinition" || m.APIVersion() != apiextv1.SchemeGroupVersion.String() {
+			continue
+		}
+		var crd apiextv1.CustomResourceDefinition
+		if err := m.Decode(&crd); err != nil {
+			return nil, err
+		}
+		crds = append(crds, &crd)
+	}
+	return crds, nil
+}
+
+// exampleSchema finds the schema for a GVK among the bundle's CRDs.
+// If there is none it returns a message explaining why.
+func exampleSchema(crds []*apiextv1.CustomResourceDefinition, gvk schema.GroupVersionKind) (*apiextv1.JSONSchemaProps, string) {
+	for _, crd := range crds {
+		if crd.Spec.Group != gvk.Group || crd.Spec.Names.Kind != gvk.Kind {
+			continue
+		}
+		if s, ok := crdschema.CRDSchema(crd, gvk.Version); ok {
+			return s, ""
+		}
+		return nil, fmt.Sprintf("CRD %s has no schema for version %s", crd.Name, gvk.Version)
+	}
+	return nil, fmt.Sprintf("no CRD in the bundle defines %s", gvk.GroupKind())
+}
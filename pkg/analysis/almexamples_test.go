@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"testing"
+)
+
+const testALMCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: bpfapplications.bpfman.io
+spec:
+  group: bpfman.io
+  names:
+    kind: BpfApplication
+    plural: bpfapplications
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion: {type: string}
+          kind: {type: string}
+          metadata: {type: object}
+          spec:
+            type: object
+            required: [programs]
+            properties:
+              programs:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    name: {type: string}
+                    type: {type: string, enum: [XDP, TC]}
+`
+
+// TestCheckALMExamples tests that alm-examples are matched to bundle
+// CRDs and validated against their schemas.
+func TestCheckALMExamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		examples string
+		want     []string
+	}{
+		{
+			name:     "valid example",
+			examples: `[{"apiVersion":"bpfman.io/v1alpha1","kind":"BpfApplication","metadata":{"name":"app"},"spec":{"programs":[{"name":"xdp_stats","type":"XDP"}]}}]`,
+		},
+		{
+			name:     "no examples",
+			examples: "",
+		},
+		{
+			name:     "schema violations",
+			examples: `[{"apiVersion":"bpfman.io/v1alpha1","kind":"BpfApplication","metadata":{"name":"app"},"spec":{"programs":[{"name":"xdp_stats","type":"Xdp","priority":1}]}}]`,
+			want: []string{
+				"alm-examples[0].spec.programs[0].priority (BpfApplication app): unknown field",
+				`alm-examples[0].spec.programs[0].type (BpfApplication app): Unsupported value: "Xdp": supported values: "XDP", "TC"`,
+			},
+		},
+		{
+			name:     "version not in CRD",
+			examples: `[{"apiVersion":"bpfman.io/v1","kind":"BpfApplication","metadata":{"name":"app"},"spec":{"programs":[]}}]`,
+			want:     []string{"alm-examples[0] (BpfApplication app): CRD bpfapplications.bpfman.io has no schema for version v1"},
+		},
+		{
+			name:     "kind not in bundle",
+			examples: `[{"apiVersion":"bpfman.io/v1alpha1","kind":"BpfProgram","metadata":{"name":"prog"}}]`,
+			want:     []string{"alm-examples[0] (BpfProgram prog): no CRD in the bundle defines BpfProgram.bpfman.io"},
+		},
+		{
+			name:     "invalid JSON",
+			examples: `[{"apiVersion":`,
+			want:     []string{"alm-examples: not a JSON array of objects: unexpected end of JSON input"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv := decodeTestYAML(t, `
+apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: bpfman-operator.v0.6.0
+spec: {}
+`)
+			if tt.examples != "" {
+				csv["metadata"].(map[string]any)["annotations"] = map[string]any{"alm-examples": tt.examples}
+			}
+			manifests := []Manifest{
+				{File: "manifests/bpfman-operator.clusterserviceversion.yaml", Object: csv},
+				{File: "manifests/bpfman.io_bpfapplications.yaml", Object: decodeTestYAML(t, testALMCRD)},
+			}
+
+			issues, err := CheckALMExamples(manifests)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
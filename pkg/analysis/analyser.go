@@ -3,15 +3,19 @@ package analysis
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
 	"github.com/sirupsen/logrus"
 )
 
 // AnalyseBundle performs analysis of a bundle image.
-func AnalyseBundle(ctx context.Context, bundleRefStr string) (*BundleAnalysis, error) {
+func AnalyseBundle(ctx context.Context, bundleRefStr string, config AnalyseConfig) (*BundleAnalysis, error) {
 	resolvedRefStr := bundleRefStr
 	if !strings.Contains(bundleRefStr, "@sha256:") {
 		logrus.Infof("Resolving tag reference to digest: %s", bundleRefStr)
@@ -41,14 +45,45 @@ func AnalyseBundle(ctx context.Context, bundleRefStr string) (*BundleAnalysis, e
 	}
 
 	logrus.Infof("Inspecting bundle metadata from %s", bundleRef.String())
-	bundleInfo, err := extractBundleMetadata(ctx, bundleRef, stream)
+	bundleInfo, activeRef, err := extractBundleMetadata(ctx, bundleRef, stream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract bundle metadata: %w", err)
 	}
 	analysis.BundleInfo = bundleInfo
 
+	// Unpack the bundle once; every manifest-level step below reads
+	// from the same directory.
+	registry, err := newBundleRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer registry.Destroy()
+
+	bundleDir, err := UnpackBundle(ctx, activeRef, registry)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	manifests, err := LoadBundleManifests(bundleDir)
+	if err != nil {
+		return nil, err
+	}
+
+	csvMetadata, err := ExtractCSVMetadata(manifests)
+	if err != nil {
+		logrus.WithError(err).Debugf("failed to extract CSV metadata from bundle")
+	} else if csvMetadata != nil {
+		if csvMetadata.Version != "" {
+			bundleInfo.CSVVersion = csvMetadata.Version
+		}
+		if csvMetadata.CreatedAt != "" {
+			bundleInfo.CSVCreatedAt = csvMetadata.CreatedAt
+		}
+	}
+
 	logrus.Infof("Extracting image references from bundle")
-	imageRefs, err := ExtractImageReferences(ctx, bundleRef)
+	imageRefs, err := ExtractImageReferences(ctx, bundleRef, bundleDir, manifests)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract image references: %w", err)
 	}
@@ -56,11 +91,11 @@ func AnalyseBundle(ctx context.Context, bundleRefStr string) (*BundleAnalysis, e
 	logrus.Infof("Found %d image references, inspecting each", len(imageRefs))
 	imageResults := make([]ImageResult, len(imageRefs))
 	for i, ref := range imageRefs {
-		logrus.Infof("Inspecting image %d/%d: %s", i+1, len(imageRefs), ref)
-		result, err := InspectImage(ctx, ref, stream)
+		logrus.Infof("Inspecting image %d/%d: %s", i+1, len(imageRefs), ref.Image)
+		result, err := InspectImage(ctx, ref.Image, stream)
 		if err != nil {
 			imageResults[i] = ImageResult{
-				Reference:  ref,
+				Reference:  ref.Image,
 				Accessible: false,
 				Registry:   NotAccessible,
 				Error:      fmt.Sprintf("inspection failed: %v", err),
@@ -68,51 +103,112 @@ func AnalyseBundle(ctx context.Context, bundleRefStr string) (*BundleAnalysis, e
 		} else {
 			imageResults[i] = *result
 		}
+		imageResults[i].Sources = ref.Sources
 	}
 	analysis.Images = imageResults
 	analysis.Summary = CalculateSummary(imageResults)
 
+	logrus.Infof("Checking bundle manifests")
+	if err := inspectBundleManifests(bundleDir, manifests, config, analysis); err != nil {
+		return nil, fmt.Errorf("failed to check bundle manifests: %w", err)
+	}
+
+	if config.Catalog != nil {
+		analysis.Deprecation = CatalogDeprecationStatus(config.Catalog, bundleInfo.CSVVersion, bundleRefStr, resolvedRefStr, bundleRef.String())
+	}
+
 	return analysis, nil
 }
 
+// CatalogDeprecationStatus finds a bundle in a catalog and reports
+// the deprecations that apply to it. The bundle is matched by any of
+// its image references or, failing that, by its CSV version, since
+// catalogs usually list bundles by their release registry rather than
+// the one they were built in.
+func CatalogDeprecationStatus(cfg *declcfg.DeclarativeConfig, csvVersion string, imageRefs ...string) *DeprecationStatus {
+	status := &DeprecationStatus{}
+	for _, b := range cfg.Bundles {
+		if slices.Contains(imageRefs, b.Image) {
+			status.Package, status.Bundle, status.MatchedBy = b.Package, b.Name, "image"
+			break
+		}
+		if csvVersion != "" && status.Bundle == "" && catalog.BundleVersion(b) == strings.TrimPrefix(csvVersion, "v") {
+			status.Package, status.Bundle, status.MatchedBy = b.Package, b.Name, "version"
+		}
+	}
+	if status.Bundle != "" {
+		status.Deprecations = catalog.BundleDeprecations(cfg, status.Package, status.Bundle)
+	}
+	return status
+}
+
 // extractBundleMetadata extracts metadata from the bundle image
-// itself.
-func extractBundleMetadata(ctx context.Context, bundleRef ImageRef, stream string) (*ImageInfo, error) {
-	var activeRef ImageRef = bundleRef
+// itself. If the bundle is not accessible it falls back to the tenant
+// workspace, and returns the reference that was found.
+func extractBundleMetadata(ctx context.Context, bundleRef ImageRef, stream string) (*ImageInfo, ImageRef, error) {
 	info, err := ExtractImageMetadata(ctx, bundleRef)
+	if err == nil {
+		return info, bundleRef, nil
+	}
+
+	tenantRef, err := bundleRef.ConvertToTenantWorkspace(stream)
+	if err != nil {
+		return nil, ImageRef{}, fmt.Errorf("bundle not accessible and cannot convert to tenant workspace: %w", err)
+	}
+
+	info, err = ExtractImageMetadata(ctx, tenantRef)
 	if err != nil {
-		tenantRef, err := bundleRef.ConvertToTenantWorkspace(stream)
+		return nil, ImageRef{}, fmt.Errorf("bundle not accessible in any registry: %w", err)
+	}
+	return info, tenantRef, nil
+}
+
+// inspectBundleManifests runs the manifest-level checks against an
+// unpacked bundle.
+func inspectBundleManifests(bundleDir string, manifests []Manifest, config AnalyseConfig, analysis *BundleAnalysis) error {
+	relatedImages, err := CheckRelatedImages(manifests)
+	if err != nil {
+		return fmt.Errorf("checking relatedImages: %w", err)
+	}
+	analysis.RelatedImages = relatedImages
+	analysis.Summary.MissingRelatedImages = len(relatedImages.Missing)
+	analysis.Summary.TagReferences = len(relatedImages.TagReferences)
+
+	if config.TargetOCP != "" {
+		logrus.Infof("Checking compatibility with OpenShift %s", config.TargetOCP)
+		bundleAnnotations, err := LoadBundleAnnotations(bundleDir)
 		if err != nil {
-			return nil, fmt.Errorf("bundle not accessible and cannot convert to tenant workspace: %w", err)
+			return err
 		}
-
-		info, err = ExtractImageMetadata(ctx, tenantRef)
+		compatibility, err := CheckCompatibility(manifests, bundleAnnotations, config.TargetOCP)
 		if err != nil {
-			return nil, fmt.Errorf("bundle not accessible in any registry: %w", err)
+			return fmt.Errorf("checking compatibility: %w", err)
+		}
+		analysis.Compatibility = compatibility
+		for _, c := range compatibility.Checks {
+			if c.Status == StatusFail {
+				analysis.Summary.CompatibilityFailures++
+			}
 		}
-		activeRef = tenantRef
 	}
 
-	// Extract CSV metadata from bundle
-	csvMetadata, err := extractCSVMetadataFromBundle(ctx, activeRef)
-	if err != nil {
-		logrus.WithError(err).Debugf("failed to extract CSV metadata from bundle")
-	} else if csvMetadata != nil {
-		if csvMetadata.Version != "" {
-			info.CSVVersion = csvMetadata.Version
-		}
-		if csvMetadata.CreatedAt != "" {
-			info.CSVCreatedAt = csvMetadata.CreatedAt
+	if config.Validate {
+		logrus.Infof("Validating bundle manifests")
+		validation, err := ValidateBundleDir(bundleDir, config.K8sVersion)
+		if err != nil {
+			return fmt.Errorf("validating bundle: %w", err)
 		}
+		analysis.Validation = validation
+		analysis.Summary.ValidationErrors = validation.Errors
+		analysis.Summary.ValidationWarnings = validation.Warnings
 	}
 
-	return info, nil
+	return nil
 }
 
-// extractCSVMetadataFromBundle extracts the CSV metadata using a temporary registry.
-func extractCSVMetadataFromBundle(ctx context.Context, bundleRef ImageRef) (*CSVMetadata, error) {
-	logrus.Debugf("Extracting CSV metadata from bundle: %s", bundleRef.String())
-
+// newBundleRegistry creates a podman-backed registry for pulling and
+// unpacking bundle images.
+func newBundleRegistry() (*execregistry.Registry, error) {
 	logrus.SetLevel(logrus.WarnLevel)
 	logger := logrus.NewEntry(logrus.New())
 	logger.Logger.SetLevel(logrus.WarnLevel)
@@ -121,12 +217,17 @@ func extractCSVMetadataFromBundle(ctx context.Context, bundleRef ImageRef) (*CSV
 	if err != nil {
 		return nil, fmt.Errorf("creating image registry: %w", err)
 	}
-	defer registry.Destroy()
-
-	return ExtractCSVMetadata(ctx, bundleRef, registry)
+	return registry, nil
 }
 
 // AnalyseConfig holds configuration options for bundle analysis.
 type AnalyseConfig struct {
-	ShowAll bool // Include inaccessible images in results.
+	ShowAll    bool   // Include inaccessible images in results.
+	Validate   bool   // Run the operator-framework validators against the bundle manifests.
+	K8sVersion string // Kubernetes version to check for removed APIs (default: CSV minKubeVersion).
+	TargetOCP  string // OpenShift version to check compatibility with, e.g. 4.20.
+
+	// Catalog, if set, is searched for the bundle to report its
+	// deprecation status.
+	Catalog *declcfg.DeclarativeConfig
 }
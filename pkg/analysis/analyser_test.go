@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// TestCatalogDeprecationStatus tests that a bundle is found in a
+// catalog by image or CSV version and its deprecations reported.
+func TestCatalogDeprecationStatus(t *testing.T) {
+	cfg := &declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{
+			{Name: "bpfman-operator.v0.5.8", Package: "bpfman-operator", Image: "registry.redhat.io/bpfman/bundle@sha256:aaaa",
+				Properties: []property.Property{property.MustBuildPackage("bpfman-operator", "0.5.8")}},
+			{Name: "bpfman-operator.v0.5.9", Package: "bpfman-operator", Image: "registry.redhat.io/bpfman/bundle@sha256:bbbb",
+				Properties: []property.Property{property.MustBuildPackage("bpfman-operator", "0.5.9")}},
+		},
+		Channels: []declcfg.Channel{{Package: "bpfman-operator", Name: "stable", Entries: []declcfg.ChannelEntry{
+			{Name: "bpfman-operator.v0.5.8"},
+			{Name: "bpfman-operator.v0.5.9", Replaces: "bpfman-operator.v0.5.8"},
+		}}},
+		Deprecations: []declcfg.Deprecation{{Package: "bpfman-operator", Entries: []declcfg.DeprecationEntry{
+			{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "bpfman-operator.v0.5.8"}, Message: "upgrade to 0.5.9"},
+		}}},
+	}
+
+	tests := []struct {
+		name           string
+		csvVersion     string
+		refs           []string
+		wantBundle     string
+		wantMatchedBy  string
+		wantDeprecated bool
+	}{
+		{
+			name:           "matched by image",
+			refs:           []string{"quay.io/bpfman/bundle:v0.5.8", "registry.redhat.io/bpfman/bundle@sha256:aaaa"},
+			wantBundle:     "bpfman-operator.v0.5.8",
+			wantMatchedBy:  "image",
+			wantDeprecated: true,
+		},
+		{
+			name:          "matched by version",
+			csvVersion:    "0.5.9",
+			refs:          []string{"quay.io/bpfman/bundle@sha256:cccc"},
+			wantBundle:    "bpfman-operator.v0.5.9",
+			wantMatchedBy: "version",
+		},
+		{
+			name:       "not in catalog",
+			csvVersion: "0.6.0",
+			refs:       []string{"quay.io/bpfman/bundle@sha256:cccc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := CatalogDeprecationStatus(cfg, tt.csvVersion, tt.refs...)
+			if status.Bundle != tt.wantBundle || status.MatchedBy != tt.wantMatchedBy {
+				t.Errorf("got bundle %q matched by %q, want %q matched by %q", status.Bundle, status.MatchedBy, tt.wantBundle, tt.wantMatchedBy)
+			}
+			if deprecated := len(status.Deprecations) > 0; deprecated != tt.wantDeprecated {
+				t.Errorf("got deprecations %v, want deprecated %v", status.Deprecations, tt.wantDeprecated)
+			}
+		})
+	}
+}
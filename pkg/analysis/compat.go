@@ -0,0 +1,345 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"sigs.k8s.io/yaml"
+)
+
+// OpenShiftVersionsAnnotation restricts the OpenShift versions a
+// bundle is included in when building Red Hat catalogs.
+const OpenShiftVersionsAnnotation = "com.redhat.openshift.versions"
+
+// featureAnnotations are the infrastructure feature annotations
+// checked for every target.
+var featureAnnotations = []string{
+	"features.operators.openshift.io/disconnected",
+	"features.operators.openshift.io/fips-compliant",
+	"features.operators.openshift.io/proxy-aware",
+}
+
+// removedAPI describes a group/version, or specific kinds within it,
+// that Kubernetes no longer serves.
+type removedAPI struct {
+	apiVersion string
+	kinds      []string // Empty means every kind in the group/version
+	removedIn  string   // Kubernetes minor version, e.g. 1.25
+}
+
+// removedAPIs lists APIs removed from Kubernetes releases shipped in
+// supported OpenShift versions. See
+// https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var removedAPIs = []removedAPI{
+	{apiVersion: "admissionregistration.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "apiextensions.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "apiregistration.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "authentication.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "authorization.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "certificates.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "coordination.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "extensions/v1beta1", kinds: []string{"Ingress"}, removedIn: "1.22"},
+	{apiVersion: "networking.k8s.io/v1beta1", kinds: []string{"Ingress", "IngressClass"}, removedIn: "1.22"},
+	{apiVersion: "rbac.authorization.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "scheduling.k8s.io/v1beta1", removedIn: "1.22"},
+	{apiVersion: "storage.k8s.io/v1beta1", kinds: []string{"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment"}, removedIn: "1.22"},
+	{apiVersion: "batch/v1beta1", kinds: []string{"CronJob"}, removedIn: "1.25"},
+	{apiVersion: "discovery.k8s.io/v1beta1", removedIn: "1.25"},
+	{apiVersion: "events.k8s.io/v1beta1", removedIn: "1.25"},
+	{apiVersion: "autoscaling/v2beta1", removedIn: "1.25"},
+	{apiVersion: "policy/v1beta1", removedIn: "1.25"},
+	{apiVersion: "node.k8s.io/v1beta1", removedIn: "1.25"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta1", removedIn: "1.26"},
+	{apiVersion: "autoscaling/v2beta2", removedIn: "1.26"},
+	{apiVersion: "storage.k8s.io/v1beta1", kinds: []string{"CSIStorageCapacity"}, removedIn: "1.27"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta2", removedIn: "1.29"},
+	{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta3", removedIn: "1.32"},
+}
+
+// CompatibilityReport holds the results of checking a bundle against
+// a target OpenShift version.
+type CompatibilityReport struct {
+	TargetOCP   string      `json:"target_ocp"`
+	KubeVersion string      `json:"kube_version"` // Kubernetes version shipped with the target
+	Checks      []LintCheck `json:"checks"`
+}
+
+// Failed reports whether any check failed.
+func (r *CompatibilityReport) Failed() bool {
+	if r == nil {
+		return false
+	}
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// ocpVersion is an OpenShift major.minor version.
+type ocpVersion struct {
+	major, minor int
+}
+
+func (v ocpVersion) less(o ocpVersion) bool {
+	return v.major < o.major || (v.major == o.major && v.minor < o.minor)
+}
+
+func (v ocpVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// kubeVersion returns the Kubernetes release shipped with an
+// OpenShift 4 release, e.g. 4.20 ships Kubernetes 1.33.
+func (v ocpVersion) kubeVersion() semver.Version {
+	return semver.Version{Major: 1, Minor: uint64(v.minor + 13)}
+}
+
+// parseOCPVersion parses versions such as 4.20 or v4.20.
+func parseOCPVersion(s string) (ocpVersion, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	parts := strings.Split(trimmed, ".")
+	if len(parts) != 2 {
+		return ocpVersion{}, fmt.Errorf("invalid OpenShift version %q, expected 4.x", s)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ocpVersion{}, fmt.Errorf("invalid OpenShift version %q: %w", s, err)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ocpVersion{}, fmt.Errorf("invalid OpenShift version %q: %w", s, err)
+	}
+	if major != 4 {
+		return ocpVersion{}, fmt.Errorf("unsupported OpenShift version %q, only 4.x is supported", s)
+	}
+	return ocpVersion{major: major, minor: minor}, nil
+}
+
+// ValidateOCPVersion checks that s is a supported OpenShift version.
+func ValidateOCPVersion(s string) error {
+	_, err := parseOCPVersion(s)
+	return err
+}
+
+// openShiftVersionsInclude reports whether a
+// com.redhat.openshift.versions value includes the target. Values
+// are either a single version meaning that version or later
+// ("v4.16"), an exact version ("=v4.16"), an inclusive range
+// ("v4.16-v4.20") or the deprecated comma-separated list ("v4.5,v4.6"),
+// which means the lowest listed version or later.
+func openShiftVersionsInclude(value string, target ocpVersion) (bool, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.Contains(value, ","):
+		var lowest *ocpVersion
+		for _, part := range strings.Split(value, ",") {
+			v, err := parseOCPVersion(part)
+			if err != nil {
+				return false, err
+			}
+			if lowest == nil || v.less(*lowest) {
+				lowest = &v
+			}
+		}
+		return !target.less(*lowest), nil
+	case strings.HasPrefix(value, "="):
+		v, err := parseOCPVersion(strings.TrimPrefix(value, "="))
+		if err != nil {
+			return false, err
+		}
+		return v == target, nil
+	case strings.Contains(value, "-"):
+		bounds := strings.SplitN(value, "-", 2)
+		lower, err := parseOCPVersion(bounds[0])
+		if err != nil {
+			return false, err
+		}
+		upper, err := parseOCPVersion(bounds[1])
+		if err != nil {
+			return false, err
+		}
+		return !target.less(lower) && !upper.less(target), nil
+	default:
+		v, err := parseOCPVersion(value)
+		if err != nil {
+			return false, err
+		}
+		return !target.less(v), nil
+	}
+}
+
+// LoadBundleAnnotations reads metadata/annotations.yaml from an
+// unpacked bundle. A missing file yields no annotations.
+func LoadBundleAnnotations(bundleDir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(bundleDir, "metadata", "annotations.yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle annotations: %w", err)
+	}
+
+	var annotations struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := yaml.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("parsing bundle annotations: %w", err)
+	}
+	return annotations.Annotations, nil
+}
+
+// CheckCompatibility checks whether a bundle will install on the
+// target OpenShift version, given its manifests and the annotations
+// from metadata/annotations.yaml.
+func CheckCompatibility(manifests []Manifest, bundleAnnotations map[string]string, targetOCP string) (*CompatibilityReport, error) {
+	target, err := parseOCPVersion(targetOCP)
+	if err != nil {
+		return nil, err
+	}
+
+	csv, _, err := FindCSV(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	kube := target.kubeVersion()
+	report := &CompatibilityReport{
+		TargetOCP:   target.String(),
+		KubeVersion: fmt.Sprintf("%d.%d", kube.Major, kube.Minor),
+	}
+
+	report.Checks = append(report.Checks,
+		checkOpenShiftVersions(csv.Annotations[OpenShiftVersionsAnnotation], bundleAnnotations[OpenShiftVersionsAnnotation], target),
+		checkMinKubeVersion(csv.Spec.MinKubeVersion, kube),
+		checkFeatureAnnotations(csv.Annotations),
+		checkRemovedAPIs(manifests, kube),
+	)
+
+	return report, nil
+}
+
+func checkOpenShiftVersions(csvValue, bundleValue string, target ocpVersion) LintCheck {
+	check := LintCheck{Name: "openshift-versions", Status: StatusPass}
+
+	if csvValue == "" && bundleValue == "" {
+		check.Status = StatusWarn
+		check.Messages = []string{fmt.Sprintf("%s is not set; the bundle is not restricted to any OpenShift version", OpenShiftVersionsAnnotation)}
+		return check
+	}
+
+	sources := []struct {
+		name, value string
+	}{
+		{"metadata/annotations.yaml", bundleValue},
+		{"CSV", csvValue},
+	}
+	for _, src := range sources {
+		if src.value == "" {
+			continue
+		}
+		ok, err := openShiftVersionsInclude(src.value, target)
+		switch {
+		case err != nil:
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: %v", src.name, err))
+		case !ok:
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: %q excludes OpenShift %s", src.name, src.value, target))
+		default:
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: %q includes OpenShift %s", src.name, src.value, target))
+		}
+	}
+
+	if csvValue != "" && bundleValue != "" && csvValue != bundleValue && check.Status == StatusPass {
+		check.Status = StatusWarn
+		check.Messages = append(check.Messages, "CSV and metadata/annotations.yaml values differ; the bundle annotation is used when building catalogs")
+	}
+
+	return check
+}
+
+func checkMinKubeVersion(minKubeVersion string, kube semver.Version) LintCheck {
+	check := LintCheck{Name: "min-kube-version", Status: StatusPass}
+
+	if minKubeVersion == "" {
+		check.Status = StatusWarn
+		check.Messages = []string{"spec.minKubeVersion is not set"}
+		return check
+	}
+
+	v, err := semver.ParseTolerant(minKubeVersion)
+	if err != nil {
+		check.Status = StatusFail
+		check.Messages = []string{fmt.Sprintf("invalid spec.minKubeVersion %q: %v", minKubeVersion, err)}
+		return check
+	}
+
+	if kube.LT(semver.Version{Major: v.Major, Minor: v.Minor}) {
+		check.Status = StatusFail
+		check.Messages = []string{fmt.Sprintf("spec.minKubeVersion %s is newer than Kubernetes %d.%d", minKubeVersion, kube.Major, kube.Minor)}
+		return check
+	}
+
+	check.Messages = []string{fmt.Sprintf("spec.minKubeVersion %s is satisfied by Kubernetes %d.%d", minKubeVersion, kube.Major, kube.Minor)}
+	return check
+}
+
+func checkFeatureAnnotations(annotations map[string]string) LintCheck {
+	check := LintCheck{Name: "feature-annotations", Status: StatusPass}
+
+	for _, key := range featureAnnotations {
+		value, ok := annotations[key]
+		switch {
+		case !ok:
+			if check.Status == StatusPass {
+				check.Status = StatusWarn
+			}
+			check.Messages = append(check.Messages, fmt.Sprintf("%s is not set", key))
+		case value != "true" && value != "false":
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s must be \"true\" or \"false\", got %q", key, value))
+		default:
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+
+	return check
+}
+
+func checkRemovedAPIs(manifests []Manifest, kube semver.Version) LintCheck {
+	check := LintCheck{Name: "removed-apis", Status: StatusPass}
+
+	for _, m := range manifests {
+		for _, api := range removedAPIs {
+			if m.APIVersion() != api.apiVersion || !kindMatches(api.kinds, m.Kind()) {
+				continue
+			}
+			removedIn := semver.MustParse(api.removedIn + ".0")
+			if kube.GE(removedIn) {
+				check.Status = StatusFail
+				check.Messages = append(check.Messages, fmt.Sprintf("%s: %s %s was removed in Kubernetes %s", m.File, m.APIVersion(), m.Kind(), api.removedIn))
+			}
+		}
+	}
+
+	return check
+}
+
+func kindMatches(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
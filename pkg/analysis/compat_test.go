@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"testing"
+)
+
+// TestOpenShiftVersionsInclude tests the com.redhat.openshift.versions
+// formats.
+func TestOpenShiftVersionsInclude(t *testing.T) {
+	tests := []struct {
+		value  string
+		target string
+		want   bool
+	}{
+		{value: "v4.16", target: "4.20", want: true},
+		{value: "v4.21", target: "4.20", want: false},
+		{value: "=v4.20", target: "4.20", want: true},
+		{value: "=v4.19", target: "4.20", want: false},
+		{value: "v4.16-v4.20", target: "4.20", want: true},
+		{value: "v4.16-v4.19", target: "4.20", want: false},
+		// The comma form means the lowest version or later.
+		{value: "v4.16,v4.20", target: "4.20", want: true},
+		{value: "v4.5,v4.6", target: "4.20", want: true},
+		{value: "v4.6,v4.5", target: "4.5", want: true},
+		{value: "v4.21,v4.22", target: "4.20", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			target, err := parseOCPVersion(tt.target)
+			if err != nil {
+				t.Fatalf("parsing target: %v", err)
+			}
+			got, err := openShiftVersionsInclude(tt.value, target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("openShiftVersionsInclude(%q, %s) = %v, want %v", tt.value, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckCompatibility tests each compatibility check against a
+// target OpenShift version.
+func TestCheckCompatibility(t *testing.T) {
+	csv := `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: bpfman-operator.v0.6.0
+  annotations:
+    com.redhat.openshift.versions: v4.16-v4.20
+    features.operators.openshift.io/disconnected: "true"
+    features.operators.openshift.io/fips-compliant: "false"
+spec:
+  minKubeVersion: 1.29.0
+`
+	cronJob := `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+`
+
+	tests := []struct {
+		name       string
+		target     string
+		withCron   bool
+		wantStatus map[string]string
+	}{
+		{
+			name:   "supported target",
+			target: "4.20",
+			wantStatus: map[string]string{
+				"openshift-versions":  StatusPass,
+				"min-kube-version":    StatusPass,
+				"feature-annotations": StatusWarn,
+				"removed-apis":        StatusPass,
+			},
+		},
+		{
+			name:     "target outside range with removed API",
+			target:   "4.21",
+			withCron: true,
+			wantStatus: map[string]string{
+				"openshift-versions": StatusFail,
+				"removed-apis":       StatusFail,
+			},
+		},
+		{
+			name:   "target older than minKubeVersion",
+			target: "4.15",
+			wantStatus: map[string]string{
+				"openshift-versions": StatusFail,
+				"min-kube-version":   StatusFail,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests := []Manifest{{File: "manifests/csv.yaml", Object: decodeTestYAML(t, csv)}}
+			if tt.withCron {
+				manifests = append(manifests, Manifest{File: "manifests/cronjob.yaml", Object: decodeTestYAML(t, cronJob)})
+			}
+
+			report, err := CheckCompatibility(manifests, nil, tt.target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, c := range report.Checks {
+				got[c.Name] = c.Status
+			}
+			for name, want := range tt.wantStatus {
+				if got[name] != want {
+					t.Errorf("%s: got %s, want %s", name, got[name], want)
+				}
+			}
+		})
+	}
+}
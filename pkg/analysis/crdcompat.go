@@ -0,0 +1,322 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/sirupsen/logrus"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
+	"github.com/openshift/bpfman-catalog/pkg/schema"
+)
+
+// CRDCompatIssue is an incompatible CRD change between two bundles.
+type CRDCompatIssue struct {
+	CRD     string `json:"crd"`
+	Version string `json:"version,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// String returns a one-line description of the issue.
+func (i CRDCompatIssue) String() string {
+	parts := []string{i.CRD}
+	if i.Version != "" {
+		parts = append(parts, i.Version)
+	}
+	if i.Path != "" {
+		parts = append(parts, i.Path)
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(parts, " "), i.Message)
+}
+
+// CRDCompatHop holds the result of comparing one bundle with the
+// bundle it replaces.
+type CRDCompatHop struct {
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	FromImage string           `json:"from_image"`
+	ToImage   string           `json:"to_image"`
+	Issues    []CRDCompatIssue `json:"issues"`
+	Error     string           `json:"error,omitempty"` // Set if either bundle could not be loaded
+}
+
+// Failed reports whether the hop has incompatible changes or could not
+// be checked.
+func (h CRDCompatHop) Failed() bool {
+	return len(h.Issues) > 0 || h.Error != ""
+}
+
+// CRDCompatReport holds the CRD compatibility results for every hop
+// of a channel's replaces chain.
+type CRDCompatReport struct {
+	Package  string         `json:"package"`
+	Channel  string         `json:"channel"`
+	Hops     []CRDCompatHop `json:"hops"`
+	Failures int            `json:"failures"` // Number of failed hops
+}
+
+// CheckCRDCompat walks the replaces chain of a channel from its oldest
+// entry to its head and checks that each bundle's CRDs can replace
+// those of the bundle before it without breaking existing CRs.
+func CheckCRDCompat(ctx context.Context, cfg *declcfg.DeclarativeConfig, packageName, channelName string) (*CRDCompatReport, error) {
+	if channelName == "" {
+		var err error
+		channelName, err = catalog.DefaultChannel(cfg, packageName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chain, err := catalog.ReplacesChain(cfg, packageName, channelName)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]string)
+	for _, b := range cfg.Bundles {
+		if b.Package == packageName {
+			images[b.Name] = b.Image
+		}
+	}
+
+	report := &CRDCompatReport{
+		Package: packageName,
+		Channel: channelName,
+		Hops:    []CRDCompatHop{},
+	}
+	if len(chain) < 2 {
+		return report, nil
+	}
+
+	registry, err := newBundleRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer registry.Destroy()
+
+	loaded := make(map[string]*BundleContents)
+	load := func(name string) (*BundleContents, error) {
+		if contents, ok := loaded[name]; ok {
+			return contents, nil
+		}
+		img, ok := images[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle %s not found in catalog", name)
+		}
+		logrus.Infof("Loading bundle %s", name)
+		contents, err := LoadBundle(ctx, img, registry)
+		if err != nil {
+			return nil, err
+		}
+		loaded[name] = contents
+		return contents, nil
+	}
+
+	for i := 1; i < len(chain); i++ {
+		hop := checkCRDCompatHop(chain[i-1], chain[i], images, load)
+		if hop.Failed() {
+			report.Failures++
+		}
+		report.Hops = append(report.Hops, hop)
+	}
+
+	return report, nil
+}
+
+func checkCRDCompatHop(from, to string, images map[string]string, load func(string) (*BundleContents, error)) CRDCompatHop {
+	hop := CRDCompatHop{
+		From:      from,
+		To:        to,
+		FromImage: images[from],
+		ToImage:   images[to],
+		Issues:    []CRDCompatIssue{},
+	}
+
+	fromContents, err := load(from)
+	if err != nil {
+		hop.Error = fmt.Sprintf("loading %s: %v", from, err)
+		return hop
+	}
+	toContents, err := load(to)
+	if err != nil {
+		hop.Error = fmt.Sprintf("loading %s: %v", to, err)
+		return hop
+	}
+
+	hop.Issues = CRDCompatIssues(fromContents, toContents)
+	return hop
+}
+
+// CRDCompatIssues reports changes to the CRDs of a bundle that would
+// break CRs created against the bundle it replaces: removed CRDs,
+// served versions removed without a conversion webhook, removed
+// properties, narrowed types and new required fields.
+func CRDCompatIssues(from, to *BundleContents) []CRDCompatIssue {
+	issues := []CRDCompatIssue{}
+
+	names := make(map[string]bool)
+	for name := range from.CRDs {
+		names[name] = true
+	}
+	for _, name := range sortedSet(names) {
+		fromCRD, toCRD := from.CRDs[name], to.CRDs[name]
+		if toCRD == nil {
+			issues = append(issues, CRDCompatIssue{CRD: name, Message: "CRD removed"})
+			continue
+		}
+		issues = append(issues, crdCompatIssues(name, fromCRD, toCRD)...)
+	}
+
+	return issues
+}
+
+func crdCompatIssues(name string, from, to *apiextv1.CustomResourceDefinition) []CRDCompatIssue {
+	var issues []CRDCompatIssue
+
+	webhook := to.Spec.Conversion != nil && to.Spec.Conversion.Strategy == apiextv1.WebhookConverter
+	toVersions := crdVersions(to)
+	for _, fv := range from.Spec.Versions {
+		if !fv.Served {
+			continue
+		}
+		if tv, ok := toVersions[fv.Name]; (!ok || !tv.Served) && !webhook {
+			issues = append(issues, CRDCompatIssue{
+				CRD:     name,
+				Version: fv.Name,
+				Message: "served version removed without a conversion webhook",
+			})
+		}
+	}
+
+	d, _ := DiffCRDs(name, from, to)
+	removed := make(map[string]bool)
+	for _, f := range d.Fields {
+		switch f.Change {
+		case ChangeRemoved:
+			removed[f.Version+f.Path] = true
+			if removed[f.Version+parentPath(f.Path)] {
+				continue
+			}
+			issues = append(issues, CRDCompatIssue{CRD: name, Version: f.Version, Path: f.Path, Message: "property removed"})
+		case ChangeTypeChanged:
+			if !typeWidens(f.FromType, f.ToType) {
+				issues = append(issues, CRDCompatIssue{
+					CRD:     name,
+					Version: f.Version,
+					Path:    f.Path,
+					Message: fmt.Sprintf("type narrowed from %s to %s", f.FromType, f.ToType),
+				})
+			}
+		}
+	}
+
+	fromVersions := crdVersions(from)
+	for _, tv := range to.Spec.Versions {
+		fv, ok := fromVersions[tv.Name]
+		if !ok {
+			continue
+		}
+		fromFields := schema.Flatten(versionSchema(fv))
+		toFields := schema.Flatten(versionSchema(tv))
+		for _, path := range schema.SortedPaths(toFields) {
+			if !toFields[path].Required || fromFields[path].Required {
+				continue
+			}
+			// A required field under a new optional parent does not
+			// affect existing CRs.
+			if parent := parentPath(path); parent != "" {
+				if _, ok := fromFields[parent]; !ok {
+					continue
+				}
+			}
+			issues = append(issues, CRDCompatIssue{CRD: name, Version: tv.Name, Path: path, Message: "new required field"})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Version != issues[j].Version {
+			return issues[i].Version < issues[j].Version
+		}
+		return issues[i].Path < issues[j].Path
+	})
+	return issues
+}
+
+// typeWidens reports whether a schema type change accepts every value
+// the old type accepted.
+func typeWidens(from, to string) bool {
+	switch {
+	case to == "any":
+		return true
+	case from == "integer" && to == "number":
+		return true
+	case to == "int-or-string" && (from == "integer" || from == "string"):
+		return true
+	}
+	return false
+}
+
+// parentPath returns the path of the object containing a flattened
+// schema field.
+func parentPath(path string) string {
+	for _, suffix := range []string{"[]", "[*]"} {
+		if strings.HasSuffix(path, suffix) {
+			return strings.TrimSuffix(path, suffix)
+		}
+	}
+	if i := strings.LastIndex(path, "."); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// FormatCRDCompatReport formats a CRD compatibility report according
+// to the specified format.
+func FormatCRDCompatReport(report *CRDCompatReport, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "text", "":
+		return formatCRDCompatText(report), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: text, json)", format)
+	}
+}
+
+func formatCRDCompatText(report *CRDCompatReport) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Package %s, channel %s\n\n", report.Package, report.Channel))
+	if len(report.Hops) == 0 {
+		b.WriteString("Channel has a single entry; nothing to compare.\n")
+		return b.String()
+	}
+
+	for _, hop := range report.Hops {
+		status := StatusPass
+		if hop.Failed() {
+			status = StatusFail
+		}
+		b.WriteString(fmt.Sprintf("%s %s → %s\n", statusSymbol(status), hop.From, hop.To))
+		if hop.Error != "" {
+			b.WriteString(fmt.Sprintf("      %s\n", hop.Error))
+		}
+		for _, issue := range hop.Issues {
+			b.WriteString(fmt.Sprintf("      %s\n", issue))
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("\nSummary: %d upgrade(s) checked, %d failed\n", len(report.Hops), report.Failures))
+
+	return b.String()
+}
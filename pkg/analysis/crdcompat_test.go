@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"testing"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// TestCRDCompatIssues tests which CRD changes are reported as
+// incompatible.
+func TestCRDCompatIssues(t *testing.T) {
+	base := map[string]apiextv1.JSONSchemaProps{
+		"bytecode": {Type: "string"},
+		"priority": {Type: "integer"},
+	}
+
+	tests := []struct {
+		name string
+		to   *apiextv1.CustomResourceDefinition
+		want []string
+	}{
+		{
+			name: "unchanged",
+			to:   testCRD(testCRDVersion("v1alpha1", true, true, base)),
+		},
+		{
+			name: "new optional field and widened type",
+			to: testCRD(testCRDVersion("v1alpha1", true, true, map[string]apiextv1.JSONSchemaProps{
+				"bytecode":     {Type: "string"},
+				"priority":     {Type: "number"},
+				"nodeSelector": {Type: "object", Required: []string{"key"}, Properties: map[string]apiextv1.JSONSchemaProps{"key": {Type: "string"}}},
+			})),
+		},
+		{
+			name: "removed property",
+			to: testCRD(testCRDVersion("v1alpha1", true, true, map[string]apiextv1.JSONSchemaProps{
+				"bytecode": {Type: "string"},
+			})),
+			want: []string{"programs.bpfman.io v1alpha1 .spec.priority: property removed"},
+		},
+		{
+			name: "narrowed type",
+			to: testCRD(testCRDVersion("v1alpha1", true, true, map[string]apiextv1.JSONSchemaProps{
+				"bytecode": {Type: "object"},
+				"priority": {Type: "integer"},
+			})),
+			want: []string{"programs.bpfman.io v1alpha1 .spec.bytecode: type narrowed from string to object"},
+		},
+		{
+			name: "new required field",
+			to: func() *apiextv1.CustomResourceDefinition {
+				crd := testCRD(testCRDVersion("v1alpha1", true, true, base))
+				crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"] = apiextv1.JSONSchemaProps{
+					Type: "object", Properties: base, Required: []string{"priority"},
+				}
+				return crd
+			}(),
+			want: []string{"programs.bpfman.io v1alpha1 .spec.priority: new required field"},
+		},
+		{
+			name: "served version removed",
+			to:   testCRD(testCRDVersion("v1", true, true, base)),
+			want: []string{"programs.bpfman.io v1alpha1: served version removed without a conversion webhook"},
+		},
+		{
+			name: "served version removed with conversion webhook",
+			to: func() *apiextv1.CustomResourceDefinition {
+				crd := testCRD(testCRDVersion("v1", true, true, base))
+				crd.Spec.Conversion = &apiextv1.CustomResourceConversion{Strategy: apiextv1.WebhookConverter}
+				return crd
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := &BundleContents{CRDs: map[string]*apiextv1.CustomResourceDefinition{
+				"programs.bpfman.io": testCRD(testCRDVersion("v1alpha1", true, true, base)),
+			}}
+			to := &BundleContents{CRDs: map[string]*apiextv1.CustomResourceDefinition{
+				"programs.bpfman.io": tt.to,
+			}}
+
+			var got []string
+			for _, issue := range CRDCompatIssues(from, to) {
+				got = append(got, issue.String())
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
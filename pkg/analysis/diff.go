@@ -0,0 +1,521 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/openshift/bpfman-catalog/pkg/schema"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Change kinds.
+const (
+	ChangeAdded       = "added"
+	ChangeRemoved     = "removed"
+	ChangeChanged     = "changed"
+	ChangeTypeChanged = "type-changed"
+)
+
+// BundleDiff describes the differences between two bundles.
+type BundleDiff struct {
+	From               string             `json:"from"`
+	To                 string             `json:"to"`
+	FromVersion        string             `json:"from_version,omitempty"`
+	ToVersion          string             `json:"to_version,omitempty"`
+	CRDs               []CRDDiff          `json:"crds"`
+	Permissions        []PermissionChange `json:"permissions"`
+	ClusterPermissions []PermissionChange `json:"cluster_permissions"`
+	OwnedAPIs          ListChange         `json:"owned_apis"`
+	RequiredAPIs       ListChange         `json:"required_apis"`
+	Images             []ImageChange      `json:"images"`
+}
+
+// IsEmpty reports whether the bundles have no semantic differences.
+func (d *BundleDiff) IsEmpty() bool {
+	return len(d.CRDs) == 0 && len(d.Permissions) == 0 && len(d.ClusterPermissions) == 0 &&
+		d.OwnedAPIs.IsEmpty() && d.RequiredAPIs.IsEmpty() && len(d.Images) == 0
+}
+
+// CRDDiff describes changes to a single CRD.
+type CRDDiff struct {
+	Name            string        `json:"name"`
+	Change          string        `json:"change"` // added, removed or changed
+	VersionsAdded   []string      `json:"versions_added,omitempty"`
+	VersionsRemoved []string      `json:"versions_removed,omitempty"`
+	Served          []FlagChange  `json:"served,omitempty"`
+	FromStorage     string        `json:"from_storage,omitempty"`
+	ToStorage       string        `json:"to_storage,omitempty"`
+	Fields          []FieldChange `json:"fields,omitempty"`
+}
+
+// FlagChange records a CRD version's served flag changing.
+type FlagChange struct {
+	Version string `json:"version"`
+	From    bool   `json:"from"`
+	To      bool   `json:"to"`
+}
+
+// FieldChange records a schema field being added, removed or
+// changing type within a CRD version.
+type FieldChange struct {
+	Version  string `json:"version"`
+	Path     string `json:"path"`
+	Change   string `json:"change"` // added, removed or type-changed
+	FromType string `json:"from_type,omitempty"`
+	ToType   string `json:"to_type,omitempty"`
+}
+
+// PermissionChange records RBAC changes for one service account and
+// resource.
+type PermissionChange struct {
+	ServiceAccount string   `json:"service_account"`
+	Resource       string   `json:"resource"` // resource.group, or a non-resource URL
+	Change         string   `json:"change"`   // added, removed or changed
+	AddedVerbs     []string `json:"added_verbs,omitempty"`
+	RemovedVerbs   []string `json:"removed_verbs,omitempty"`
+}
+
+// ListChange records entries added to and removed from a list.
+type ListChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// IsEmpty reports whether the list is unchanged.
+func (c ListChange) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// ImageChange records a deployment container's image changing.
+type ImageChange struct {
+	Container string `json:"container"` // deployment/container
+	Change    string `json:"change"`    // added, removed or changed
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+}
+
+// BundleContents holds the parts of a bundle compared by DiffBundles.
+type BundleContents struct {
+	Ref  string
+	CSV  *v1alpha1.ClusterServiceVersion
+	CRDs map[string]*apiextv1.CustomResourceDefinition
+}
+
+// LoadBundleContents reads the CSV and CRDs from bundle manifests.
+func LoadBundleContents(ref string, manifests []Manifest) (*BundleContents, error) {
+	csv, _, err := FindCSV(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	crds, err := bundleCRDs(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &BundleContents{
+		Ref:  ref,
+		CSV:  csv,
+		CRDs: make(map[string]*apiextv1.CustomResourceDefinition),
+	}
+	for _, crd := range crds {
+		contents.CRDs[crd.Name] = crd
+	}
+
+	return contents, nil
+}
+
+// LoadBundle reads a bundle from an unpacked bundle directory or a
+// bundle image reference.
+func LoadBundle(ctx context.Context, ref string, registry image.Registry) (*BundleContents, error) {
+	manifests, err := loadBundleManifestsFrom(ctx, ref, registry)
+	if err != nil {
+		return nil, err
+	}
+	return LoadBundleContents(ref, manifests)
+}
+
+// loadBundleManifestsFrom reads the manifests of an unpacked bundle
+// directory, or unpacks a bundle image.
+func loadBundleManifestsFrom(ctx context.Context, ref string, registry image.Registry) ([]Manifest, error) {
+	if info, err := os.Stat(ref); err == nil && info.IsDir() {
+		return LoadBundleManifests(ref)
+	}
+
+	resolved, err := ResolveToDigest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	bundleRef, err := ParseImageRef(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle reference: %w", err)
+	}
+
+	return loadBundleImageManifests(ctx, bundleRef, registry)
+}
+
+// DiffBundleRefs loads two bundles, from directories or images, and
+// compares them.
+func DiffBundleRefs(ctx context.Context, fromRef, toRef string) (*BundleDiff, error) {
+	registry, err := newBundleRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer registry.Destroy()
+
+	from, err := LoadBundle(ctx, fromRef, registry)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", fromRef, err)
+	}
+	to, err := LoadBundle(ctx, toRef, registry)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", toRef, err)
+	}
+
+	return DiffBundles(from, to), nil
+}
+
+// DiffBundles compares the CRDs, RBAC, owned and required APIs and
+// deployment images of two bundles.
+func DiffBundles(from, to *BundleContents) *BundleDiff {
+	diff := &BundleDiff{
+		From:               from.Ref,
+		To:                 to.Ref,
+		FromVersion:        from.CSV.Spec.Version.String(),
+		ToVersion:          to.CSV.Spec.Version.String(),
+		CRDs:               []CRDDiff{},
+		Permissions:        []PermissionChange{},
+		ClusterPermissions: []PermissionChange{},
+		Images:             []ImageChange{},
+	}
+
+	names := make(map[string]bool)
+	for name := range from.CRDs {
+		names[name] = true
+	}
+	for name := range to.CRDs {
+		names[name] = true
+	}
+	for _, name := range sortedSet(names) {
+		if d, ok := DiffCRDs(name, from.CRDs[name], to.CRDs[name]); ok {
+			diff.CRDs = append(diff.CRDs, d)
+		}
+	}
+
+	fromSpec, toSpec := from.CSV.Spec.InstallStrategy.StrategySpec, to.CSV.Spec.InstallStrategy.StrategySpec
+	diff.Permissions = diffPermissions(fromSpec.Permissions, toSpec.Permissions)
+	diff.ClusterPermissions = diffPermissions(fromSpec.ClusterPermissions, toSpec.ClusterPermissions)
+
+	diff.OwnedAPIs = diffLists(apiNames(from.CSV.Spec.CustomResourceDefinitions.Owned, from.CSV.Spec.APIServiceDefinitions.Owned),
+		apiNames(to.CSV.Spec.CustomResourceDefinitions.Owned, to.CSV.Spec.APIServiceDefinitions.Owned))
+	diff.RequiredAPIs = diffLists(apiNames(from.CSV.Spec.CustomResourceDefinitions.Required, from.CSV.Spec.APIServiceDefinitions.Required),
+		apiNames(to.CSV.Spec.CustomResourceDefinitions.Required, to.CSV.Spec.APIServiceDefinitions.Required))
+
+	diff.Images = diffImages(deploymentImages(fromSpec.DeploymentSpecs), deploymentImages(toSpec.DeploymentSpecs))
+
+	return diff
+}
+
+// DiffCRDs compares two versions of a CRD. Either may be nil if the
+// CRD was added or removed. It returns false if nothing changed.
+func DiffCRDs(name string, from, to *apiextv1.CustomResourceDefinition) (CRDDiff, bool) {
+	d := CRDDiff{Name: name}
+
+	switch {
+	case from == nil && to == nil:
+		return d, false
+	case from == nil:
+		d.Change = ChangeAdded
+		d.VersionsAdded = crdVersionNames(to)
+		d.ToStorage = storageVersion(to)
+		return d, true
+	case to == nil:
+		d.Change = ChangeRemoved
+		d.VersionsRemoved = crdVersionNames(from)
+		d.FromStorage = storageVersion(from)
+		return d, true
+	}
+
+	d.Change = ChangeChanged
+
+	fromVersions := crdVersions(from)
+	toVersions := crdVersions(to)
+	for _, v := range crdVersionNames(to) {
+		if _, ok := fromVersions[v]; !ok {
+			d.VersionsAdded = append(d.VersionsAdded, v)
+		}
+	}
+	for _, v := range crdVersionNames(from) {
+		if _, ok := toVersions[v]; !ok {
+			d.VersionsRemoved = append(d.VersionsRemoved, v)
+		}
+	}
+
+	if fs, ts := storageVersion(from), storageVersion(to); fs != ts {
+		d.FromStorage, d.ToStorage = fs, ts
+	}
+
+	for _, v := range crdVersionNames(to) {
+		fv, ok := fromVersions[v]
+		if !ok {
+			continue
+		}
+		tv := toVersions[v]
+		if fv.Served != tv.Served {
+			d.Served = append(d.Served, FlagChange{Version: v, From: fv.Served, To: tv.Served})
+		}
+		d.Fields = append(d.Fields, DiffSchemaFields(v, versionSchema(fv), versionSchema(tv))...)
+	}
+
+	changed := len(d.VersionsAdded) > 0 || len(d.VersionsRemoved) > 0 || d.FromStorage != d.ToStorage ||
+		len(d.Served) > 0 || len(d.Fields) > 0
+	return d, changed
+}
+
+// DiffSchemaFields compares the fields of two schemas for the same
+// CRD version.
+func DiffSchemaFields(version string, from, to *apiextv1.JSONSchemaProps) []FieldChange {
+	fromFields := schema.Flatten(from)
+	toFields := schema.Flatten(to)
+
+	var changes []FieldChange
+	for _, path := range schema.SortedPaths(toFields) {
+		tf := toFields[path]
+		ff, ok := fromFields[path]
+		switch {
+		case !ok:
+			changes = append(changes, FieldChange{Version: version, Path: path, Change: ChangeAdded, ToType: tf.Type})
+		case ff.Type != tf.Type:
+			changes = append(changes, FieldChange{Version: version, Path: path, Change: ChangeTypeChanged, FromType: ff.Type, ToType: tf.Type})
+		}
+	}
+	for _, path := range schema.SortedPaths(fromFields) {
+		if _, ok := toFields[path]; !ok {
+			changes = append(changes, FieldChange{Version: version, Path: path, Change: ChangeRemoved, FromType: fromFields[path].Type})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func crdVersions(crd *apiextv1.CustomResourceDefinition) map[string]apiextv1.CustomResourceDefinitionVersion {
+	versions := make(map[string]apiextv1.CustomResourceDefinitionVersion)
+	for _, v := range crd.Spec.Versions {
+		versions[v.Name] = v
+	}
+	return versions
+}
+
+func crdVersionNames(crd *apiextv1.CustomResourceDefinition) []string {
+	var names []string
+	for _, v := range crd.Spec.Versions {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func storageVersion(crd *apiextv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+func versionSchema(v apiextv1.CustomResourceDefinitionVersion) *apiextv1.JSONSchemaProps {
+	if v.Schema == nil {
+		return nil
+	}
+	return v.Schema.OpenAPIV3Schema
+}
+
+// flattenPermissions maps service account → resource → verbs.
+func flattenPermissions(perms []v1alpha1.StrategyDeploymentPermissions) map[string]map[string]map[string]bool {
+	result := make(map[string]map[string]map[string]bool)
+	add := func(sa, resource string, verbs []string) {
+		if result[sa] == nil {
+			result[sa] = make(map[string]map[string]bool)
+		}
+		if result[sa][resource] == nil {
+			result[sa][resource] = make(map[string]bool)
+		}
+		for _, verb := range verbs {
+			result[sa][resource][verb] = true
+		}
+	}
+
+	for _, p := range perms {
+		for _, rule := range p.Rules {
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					name := resource
+					if group != "" {
+						name = resource + "." + group
+					}
+					add(p.ServiceAccountName, name, rule.Verbs)
+				}
+			}
+			for _, url := range rule.NonResourceURLs {
+				add(p.ServiceAccountName, url, rule.Verbs)
+			}
+		}
+	}
+
+	return result
+}
+
+func diffPermissions(from, to []v1alpha1.StrategyDeploymentPermissions) []PermissionChange {
+	fromPerms := flattenPermissions(from)
+	toPerms := flattenPermissions(to)
+
+	accounts := make(map[string]bool)
+	for sa := range fromPerms {
+		accounts[sa] = true
+	}
+	for sa := range toPerms {
+		accounts[sa] = true
+	}
+
+	changes := []PermissionChange{}
+	for _, sa := range sortedSet(accounts) {
+		resources := make(map[string]bool)
+		for r := range fromPerms[sa] {
+			resources[r] = true
+		}
+		for r := range toPerms[sa] {
+			resources[r] = true
+		}
+
+		for _, r := range sortedSet(resources) {
+			fromVerbs, inFrom := fromPerms[sa][r]
+			toVerbs, inTo := toPerms[sa][r]
+
+			c := PermissionChange{ServiceAccount: sa, Resource: r}
+			for _, verb := range sortedSet(toVerbs) {
+				if !fromVerbs[verb] {
+					c.AddedVerbs = append(c.AddedVerbs, verb)
+				}
+			}
+			for _, verb := range sortedSet(fromVerbs) {
+				if !toVerbs[verb] {
+					c.RemovedVerbs = append(c.RemovedVerbs, verb)
+				}
+			}
+
+			switch {
+			case !inFrom:
+				c.Change = ChangeAdded
+			case !inTo:
+				c.Change = ChangeRemoved
+			case len(c.AddedVerbs) > 0 || len(c.RemovedVerbs) > 0:
+				c.Change = ChangeChanged
+			default:
+				continue
+			}
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+func apiNames(crds []v1alpha1.CRDDescription, apiServices []v1alpha1.APIServiceDescription) []string {
+	var names []string
+	for _, crd := range crds {
+		names = append(names, fmt.Sprintf("%s/%s (%s)", crd.Name, crd.Version, crd.Kind))
+	}
+	for _, svc := range apiServices {
+		names = append(names, fmt.Sprintf("%s.%s/%s (%s)", svc.Name, svc.Group, svc.Version, svc.Kind))
+	}
+	return names
+}
+
+func diffLists(from, to []string) ListChange {
+	fromSet := make(map[string]bool)
+	for _, s := range from {
+		fromSet[s] = true
+	}
+	toSet := make(map[string]bool)
+	for _, s := range to {
+		toSet[s] = true
+	}
+
+	var change ListChange
+	for _, s := range sortedSet(toSet) {
+		if !fromSet[s] {
+			change.Added = append(change.Added, s)
+		}
+	}
+	for _, s := range sortedSet(fromSet) {
+		if !toSet[s] {
+			change.Removed = append(change.Removed, s)
+		}
+	}
+	return change
+}
+
+// deploymentImages maps deployment/container to image for every
+// container and initContainer in the install strategy.
+func deploymentImages(deployments []v1alpha1.StrategyDeploymentSpec) map[string]string {
+	images := make(map[string]string)
+	for _, d := range deployments {
+		for _, c := range d.Spec.Template.Spec.InitContainers {
+			images[d.Name+"/"+c.Name] = c.Image
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			images[d.Name+"/"+c.Name] = c.Image
+		}
+	}
+	return images
+}
+
+func diffImages(from, to map[string]string) []ImageChange {
+	keys := make(map[string]bool)
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+
+	changes := []ImageChange{}
+	for _, k := range sortedSet(keys) {
+		fromImage, inFrom := from[k]
+		toImage, inTo := to[k]
+		switch {
+		case !inFrom:
+			changes = append(changes, ImageChange{Container: k, Change: ChangeAdded, To: toImage})
+		case !inTo:
+			changes = append(changes, ImageChange{Container: k, Change: ChangeRemoved, From: fromImage})
+		case fromImage != toImage:
+			changes = append(changes, ImageChange{Container: k, Change: ChangeChanged, From: fromImage, To: toImage})
+		}
+	}
+	return changes
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinOrNone joins a list for display, or returns "none".
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
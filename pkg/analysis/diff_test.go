@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testCRD(versions ...apiextv1.CustomResourceDefinitionVersion) *apiextv1.CustomResourceDefinition {
+	return &apiextv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "programs.bpfman.io"},
+		Spec:       apiextv1.CustomResourceDefinitionSpec{Versions: versions},
+	}
+}
+
+func testCRDVersion(name string, served, storage bool, spec map[string]apiextv1.JSONSchemaProps) apiextv1.CustomResourceDefinitionVersion {
+	return apiextv1.CustomResourceDefinitionVersion{
+		Name:    name,
+		Served:  served,
+		Storage: storage,
+		Schema: &apiextv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]apiextv1.JSONSchemaProps{
+					"spec": {Type: "object", Properties: spec},
+				},
+			},
+		},
+	}
+}
+
+func testBundle(crd *apiextv1.CustomResourceDefinition, rules []rbacv1.PolicyRule, image string) *BundleContents {
+	csv := &v1alpha1.ClusterServiceVersion{}
+	csv.Spec.InstallStrategy.StrategySpec.ClusterPermissions = []v1alpha1.StrategyDeploymentPermissions{
+		{ServiceAccountName: "bpfman-operator", Rules: rules},
+	}
+	csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs = []v1alpha1.StrategyDeploymentSpec{{Name: "bpfman-operator"}}
+	csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers = []corev1.Container{{Name: "manager", Image: image}}
+	return &BundleContents{
+		CSV:  csv,
+		CRDs: map[string]*apiextv1.CustomResourceDefinition{crd.Name: crd},
+	}
+}
+
+// TestDiffBundles tests that schema, version, RBAC and image changes
+// between two bundles are reported.
+func TestDiffBundles(t *testing.T) {
+	from := testBundle(
+		testCRD(
+			testCRDVersion("v1alpha1", true, true, map[string]apiextv1.JSONSchemaProps{
+				"bytecode": {Type: "string"},
+				"priority": {Type: "string"},
+			}),
+		),
+		[]rbacv1.PolicyRule{
+			{APIGroups: []string{"bpfman.io"}, Resources: []string{"programs"}, Verbs: []string{"get", "list"}},
+		},
+		"quay.io/bpfman/bpfman-operator:v0.5.6",
+	)
+	to := testBundle(
+		testCRD(
+			testCRDVersion("v1alpha1", false, false, map[string]apiextv1.JSONSchemaProps{
+				"bytecode": {Type: "string"},
+				"priority": {Type: "integer"},
+			}),
+			testCRDVersion("v1", true, true, map[string]apiextv1.JSONSchemaProps{
+				"bytecode":     {Type: "string"},
+				"nodeSelector": {Type: "object"},
+			}),
+		),
+		[]rbacv1.PolicyRule{
+			{APIGroups: []string{"bpfman.io"}, Resources: []string{"programs"}, Verbs: []string{"get", "list", "delete"}},
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+		},
+		"quay.io/bpfman/bpfman-operator:v0.6.0",
+	)
+
+	diff := DiffBundles(from, to)
+
+	if len(diff.CRDs) != 1 {
+		t.Fatalf("expected 1 CRD change, got %d", len(diff.CRDs))
+	}
+	crd := diff.CRDs[0]
+	if !equalStrings(crd.VersionsAdded, []string{"v1"}) {
+		t.Errorf("versions added = %v, want [v1]", crd.VersionsAdded)
+	}
+	if crd.FromStorage != "v1alpha1" || crd.ToStorage != "v1" {
+		t.Errorf("storage = %s → %s, want v1alpha1 → v1", crd.FromStorage, crd.ToStorage)
+	}
+	if len(crd.Served) != 1 || crd.Served[0].Version != "v1alpha1" || crd.Served[0].To {
+		t.Errorf("served changes = %+v, want v1alpha1 no longer served", crd.Served)
+	}
+
+	fields := make(map[string]FieldChange)
+	for _, f := range crd.Fields {
+		fields[f.Version+f.Path] = f
+	}
+	if f, ok := fields["v1alpha1.spec.priority"]; !ok || f.Change != ChangeTypeChanged || f.ToType != "integer" {
+		t.Errorf("expected .spec.priority type change to integer, got %+v", f)
+	}
+
+	changes := make(map[string]PermissionChange)
+	for _, c := range diff.ClusterPermissions {
+		changes[c.Resource] = c
+	}
+	if c := changes["programs.bpfman.io"]; c.Change != ChangeChanged || !equalStrings(c.AddedVerbs, []string{"delete"}) {
+		t.Errorf("expected delete verb added on programs.bpfman.io, got %+v", c)
+	}
+	if c := changes["secrets"]; c.Change != ChangeAdded {
+		t.Errorf("expected new secrets resource, got %+v", c)
+	}
+
+	if len(diff.Images) != 1 || diff.Images[0].Change != ChangeChanged {
+		t.Errorf("expected one changed image, got %+v", diff.Images)
+	}
+
+	if d := DiffBundles(from, from); !d.IsEmpty() {
+		t.Errorf("expected no differences comparing a bundle with itself, got %+v", d)
+	}
+}
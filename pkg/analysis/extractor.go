@@ -4,47 +4,39 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/containers/image/v5/docker"
 	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/types"
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/alpha/action/migrations"
-	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
-	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
 	"github.com/sirupsen/logrus"
-	"sigs.k8s.io/yaml"
 )
 
-// ExtractImageReferences extracts all image references from a bundle
-// image by directly inspecting it.
-func ExtractImageReferences(ctx context.Context, bundleRef ImageRef) ([]string, error) {
-	logrus.Debugf("ExtractImageReferences from bundle: %s", bundleRef.String())
-
-	logrus.SetLevel(logrus.WarnLevel)
-
-	logger := logrus.NewEntry(logrus.New())
-	logger.Logger.SetLevel(logrus.WarnLevel)
+// ImageReference is an image referenced by a bundle together with
+// every place it is referenced from.
+type ImageReference struct {
+	Image   string
+	Sources []ImageSource
+}
 
-	registry, err := execregistry.NewRegistry(containertools.PodmanTool, logger)
-	if err != nil {
-		return nil, fmt.Errorf("creating image registry: %w", err)
-	}
-	defer registry.Destroy()
+// ExtractImageReferences extracts all image references from an
+// unpacked bundle: the bundle image itself, the images rendered from
+// its CSV and the images found in its manifests.
+func ExtractImageReferences(ctx context.Context, bundleRef ImageRef, bundleDir string, manifests []Manifest) ([]ImageReference, error) {
+	logrus.Debugf("ExtractImageReferences from bundle: %s", bundleRef.String())
 
-	migs, err := migrations.NewMigrations("bundle-object-to-csv-metadata")
+	migs, err := migrations.NewMigrations(catalog.MigrationLevel)
 	if err != nil {
 		return nil, fmt.Errorf("creating migrations: %w", err)
 	}
 
 	r := action.Render{
-		Refs:           []string{bundleRef.String()},
-		Registry:       registry,
-		AllowedRefMask: action.RefBundleImage,
+		Refs:           []string{bundleDir},
+		AllowedRefMask: action.RefBundleDir,
 		Migrations:     migs,
 	}
 
@@ -54,29 +46,57 @@ func ExtractImageReferences(ctx context.Context, bundleRef ImageRef) ([]string,
 		return nil, fmt.Errorf("rendering bundle: %w", err)
 	}
 
-	var images []string
+	// A rendered bundle directory has no image, so record the
+	// bundle's own reference first.
+	refs := newImageReferences()
+	refs.add(bundleRef.String(), ImageSource{Source: SourceBundle})
 	for _, bundle := range cfg.Bundles {
-		if bundle.Image != "" {
-			logrus.Debugf("Found bundle image: %s", bundle.Image)
-			images = append(images, bundle.Image)
-		}
-
 		for _, relatedImage := range bundle.RelatedImages {
-			if relatedImage.Image != "" {
+			if relatedImage.Image != "" && relatedImage.Image != bundleRef.String() {
 				logrus.Debugf("Found relatedImage: %s", relatedImage.Image)
-				images = append(images, relatedImage.Image)
+				refs.add(relatedImage.Image)
 			}
 		}
 	}
 
-	configmapImages, err := extractConfigMapImages(ctx, bundleRef, registry)
-	if err != nil {
-		logrus.WithError(err).Warn("failed to extract configmap images")
-	} else {
-		images = append(images, configmapImages...)
+	for _, u := range findRelatedImageUsages(manifests) {
+		refs.add(u.Image, u.ImageSource)
 	}
+	for _, u := range findConfigMapImages(manifests) {
+		logrus.Debugf("Found ConfigMap image: %s at %s", u.Image, u.Path)
+		refs.add(u.Image, u.ImageSource)
+	}
+
+	return refs.list(), nil
+}
 
-	return deduplicateStrings(images), nil
+// imageReferences accumulates image references and their sources in
+// first-seen order.
+type imageReferences struct {
+	order []string
+	refs  map[string]*ImageReference
+}
+
+func newImageReferences() *imageReferences {
+	return &imageReferences{refs: make(map[string]*ImageReference)}
+}
+
+func (r *imageReferences) add(image string, sources ...ImageSource) {
+	ref, ok := r.refs[image]
+	if !ok {
+		ref = &ImageReference{Image: image}
+		r.refs[image] = ref
+		r.order = append(r.order, image)
+	}
+	ref.Sources = append(ref.Sources, sources...)
+}
+
+func (r *imageReferences) list() []ImageReference {
+	result := make([]ImageReference, len(r.order))
+	for i, image := range r.order {
+		result[i] = *r.refs[image]
+	}
+	return result
 }
 
 // deduplicateStrings removes duplicate strings from a slice.
@@ -94,40 +114,55 @@ func deduplicateStrings(slice []string) []string {
 	return result
 }
 
-// extractConfigMapImages extracts image references from the bpfman-config ConfigMap
-// in the bundle manifests. These images (daemon and agent) are not tracked in
-// relatedImages but are configured via ConfigMap at runtime.
-func extractConfigMapImages(ctx context.Context, bundleRef ImageRef, registry image.Registry) ([]string, error) {
-	tmpDir, err := os.MkdirTemp("", "bundle-manifests-*")
+// loadBundleImageManifests unpacks a bundle image and reads its
+// manifests.
+func loadBundleImageManifests(ctx context.Context, bundleRef ImageRef, registry image.Registry) ([]Manifest, error) {
+	tmpDir, err := UnpackBundle(ctx, bundleRef, registry)
 	if err != nil {
-		return nil, fmt.Errorf("creating temp directory: %w", err)
+		return nil, err
 	}
 	defer os.RemoveAll(tmpDir)
 
-	ref := image.SimpleReference(bundleRef.String())
-	if err := registry.Unpack(ctx, ref, tmpDir); err != nil {
-		return nil, fmt.Errorf("unpacking bundle image: %w", err)
-	}
+	return LoadBundleManifests(tmpDir)
+}
 
-	configmapPath := filepath.Join(tmpDir, "manifests", "bpfman-config_v1_configmap.yaml")
-	data, err := os.ReadFile(configmapPath)
+// findRelatedImageUsages returns the relatedImages declared in the
+// CSV with their JSON paths.
+func findRelatedImageUsages(manifests []Manifest) []ImageUsage {
+	csv, m, err := FindCSV(manifests)
 	if err != nil {
-		return nil, fmt.Errorf("reading configmap file: %w", err)
+		return nil
 	}
 
-	var images []string
-
-	daemonPattern := regexp.MustCompile(`bpfman\.image:\s*["']?([^\s"']+)["']?`)
-	if match := daemonPattern.FindSubmatch(data); len(match) > 1 {
-		images = append(images, string(match[1]))
-	}
+	var usages []ImageUsage
+	for i, ri := range csv.Spec.RelatedImages {
+		if ri.Image == "" {
+			continue
+		}
+		usages = append(usages, ImageUsage{
+			Image: ri.Image,
+			ImageSource: ImageSource{
+				File:   m.File,
+				Path:   fmt.Sprintf(".spec.relatedImages[%d].image", i),
+				Source: SourceRelatedImages,
+			},
+		})
+	}
+	return usages
+}
 
-	agentPattern := regexp.MustCompile(`bpfman\.agent\.image:\s*["']?([^\s"']+)["']?`)
-	if match := agentPattern.FindSubmatch(data); len(match) > 1 {
-		images = append(images, string(match[1]))
+// findConfigMapImages returns image references from the bpfman-config
+// ConfigMap, wherever it is in the bundle. These images (daemon and
+// agent) are not tracked in relatedImages but are configured via the
+// ConfigMap at runtime.
+func findConfigMapImages(manifests []Manifest) []ImageUsage {
+	var configMaps []Manifest
+	for _, m := range manifests {
+		if m.Kind() == "ConfigMap" && m.Name() == BpfmanConfigMapName {
+			configMaps = append(configMaps, m)
+		}
 	}
-
-	return images, nil
+	return FindImageUsages(configMaps)
 }
 
 // CSVMetadata holds extracted metadata from the ClusterServiceVersion.
@@ -136,64 +171,27 @@ type CSVMetadata struct {
 	CreatedAt string
 }
 
-// ExtractCSVMetadata extracts version and createdAt from the ClusterServiceVersion in a bundle image.
-func ExtractCSVMetadata(ctx context.Context, bundleRef ImageRef, registry image.Registry) (*CSVMetadata, error) {
-	tmpDir, err := os.MkdirTemp("", "bundle-csv-*")
-	if err != nil {
-		return nil, fmt.Errorf("creating temp directory: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	ref := image.SimpleReference(bundleRef.String())
-	if err := registry.Unpack(ctx, ref, tmpDir); err != nil {
-		return nil, fmt.Errorf("unpacking bundle image: %w", err)
-	}
-
-	manifestDir := filepath.Join(tmpDir, "manifests")
-	entries, err := os.ReadDir(manifestDir)
+// ExtractCSVMetadata extracts version and createdAt from the
+// ClusterServiceVersion in a bundle's manifests. It returns nil if the
+// CSV has neither.
+func ExtractCSVMetadata(manifests []Manifest) (*CSVMetadata, error) {
+	_, m, err := FindCSV(manifests)
 	if err != nil {
-		return nil, fmt.Errorf("reading manifests directory: %w", err)
+		return nil, err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		if strings.Contains(strings.ToLower(entry.Name()), "clusterserviceversion") {
-			csvPath := filepath.Join(manifestDir, entry.Name())
-			data, err := os.ReadFile(csvPath)
-			if err != nil {
-				logrus.WithError(err).Debugf("failed to read CSV file: %s", entry.Name())
-				continue
-			}
-
-			var csv struct {
-				Spec struct {
-					Version string `yaml:"version"`
-				} `yaml:"spec"`
-				Metadata struct {
-					Annotations map[string]string `yaml:"annotations"`
-				} `yaml:"metadata"`
-			}
-
-			if err := yaml.Unmarshal(data, &csv); err != nil {
-				logrus.WithError(err).Debugf("failed to parse CSV YAML: %s", entry.Name())
-				continue
-			}
-
-			metadata := &CSVMetadata{
-				Version:   csv.Spec.Version,
-				CreatedAt: csv.Metadata.Annotations["createdAt"],
-			}
+	spec, _ := m.Object["spec"].(map[string]any)
+	meta, _ := m.Object["metadata"].(map[string]any)
+	annotations, _ := meta["annotations"].(map[string]any)
+	metadata := &CSVMetadata{}
+	metadata.Version, _ = spec["version"].(string)
+	metadata.CreatedAt, _ = annotations["createdAt"].(string)
 
-			if metadata.Version != "" || metadata.CreatedAt != "" {
-				logrus.Debugf("Found CSV metadata: version=%s, createdAt=%s", metadata.Version, metadata.CreatedAt)
-				return metadata, nil
-			}
-		}
+	if metadata.Version == "" && metadata.CreatedAt == "" {
+		return nil, nil
 	}
-
-	return nil, nil
+	logrus.Debugf("Found CSV metadata: version=%s, createdAt=%s", metadata.Version, metadata.CreatedAt)
+	return metadata, nil
 }
 
 // ResolveToDigest resolves an image reference to a digest-based reference.
@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestFindConfigMapImages tests that ConfigMap images are found by
+// kind and name regardless of file name, and that commented-out
+// values are ignored.
+func TestFindConfigMapImages(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		configMap string
+		wantPaths []string
+	}{
+		{
+			name: "renamed file",
+			file: "manifests/operator-config.yaml",
+			configMap: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bpfman-config
+data:
+  bpfman.agent.image: ` + testAgentImage + `
+  bpfman.image: ` + testDaemonImage + `
+`,
+			wantPaths: []string{".data[bpfman.agent.image]", ".data[bpfman.image]"},
+		},
+		{
+			name: "commented-out image",
+			file: "manifests/bpfman-config_v1_configmap.yaml",
+			configMap: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bpfman-config
+data:
+  # bpfman.image: quay.io/bpfman/bpfman:old
+  bpfman.image: ` + testDaemonImage + `
+`,
+			wantPaths: []string{".data[bpfman.image]"},
+		},
+		{
+			name: "other configmap",
+			file: "manifests/other.yaml",
+			configMap: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other-config
+data:
+  bpfman.image: ` + testDaemonImage + `
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests := []Manifest{{File: tt.file, Object: decodeTestYAML(t, tt.configMap)}}
+
+			var paths []string
+			for _, u := range findConfigMapImages(manifests) {
+				if u.File != tt.file || u.Source != SourceConfigMap {
+					t.Errorf("unexpected source %+v", u.ImageSource)
+				}
+				paths = append(paths, u.Path)
+			}
+			if !equalStrings(paths, tt.wantPaths) {
+				t.Errorf("got paths %v, want %v", paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+// TestExtractCSVMetadata tests that the version and creation time are
+// read from the loaded CSV.
+func TestExtractCSVMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want *CSVMetadata
+	}{
+		{
+			name: "version and createdAt",
+			csv: `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: bpfman-operator.v0.5.9
+  annotations:
+    createdAt: "2025-10-01T12:00:00Z"
+spec:
+  version: 0.5.9
+`,
+			want: &CSVMetadata{Version: "0.5.9", CreatedAt: "2025-10-01T12:00:00Z"},
+		},
+		{
+			name: "neither",
+			csv: `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: bpfman-operator
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests := []Manifest{{File: "manifests/bpfman-operator.clusterserviceversion.yaml", Object: decodeTestYAML(t, tt.csv)}}
+			got, err := ExtractCSVMetadata(manifests)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractImageReferences tests that image references are read
+// from an unpacked bundle directory without pulling the image again.
+func TestExtractImageReferences(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "metadata", "annotations.yaml"), testAnnotations)
+	writeTestFile(t, filepath.Join(dir, "manifests", "bpfman-operator.clusterserviceversion.yaml"), testCSV+`  relatedImages:
+  - name: operator
+    image: `+testOperatorImage+`
+`)
+	writeTestFile(t, filepath.Join(dir, "manifests", "bpfman-config_v1_configmap.yaml"), `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bpfman-config
+data:
+  bpfman.agent.image: `+testAgentImage+`
+`)
+
+	manifests, err := LoadBundleManifests(dir)
+	if err != nil {
+		t.Fatalf("loading manifests: %v", err)
+	}
+	bundleRef, err := ParseImageRef("quay.io/bpfman/bundle@sha256:4444444444444444444444444444444444444444444444444444444444444444")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := ExtractImageReferences(context.Background(), bundleRef, dir, manifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sources := make(map[string][]string)
+	var images []string
+	for _, ref := range refs {
+		images = append(images, ref.Image)
+		for _, s := range ref.Sources {
+			sources[ref.Image] = append(sources[ref.Image], s.Source)
+		}
+	}
+	want := []string{bundleRef.String(), "quay.io/bpfman/bpfman-operator:latest", testOperatorImage, testAgentImage}
+	if !equalStrings(images, want) {
+		t.Fatalf("got images %v, want %v", images, want)
+	}
+	for image, source := range map[string]string{
+		bundleRef.String(): SourceBundle,
+		testOperatorImage:  SourceRelatedImages,
+		testAgentImage:     SourceConfigMap,
+	} {
+		if !slices.Contains(sources[image], source) {
+			t.Errorf("%s has sources %v, want %s", image, sources[image], source)
+		}
+	}
+}
@@ -79,6 +79,22 @@ func formatText(analysis *BundleAnalysis) string {
 		}
 	}
 
+	if analysis.RelatedImages != nil {
+		b.WriteString(formatRelatedImages(analysis.RelatedImages))
+	}
+
+	if analysis.Compatibility != nil {
+		b.WriteString(formatCompatibility(analysis.Compatibility))
+	}
+
+	if analysis.Validation != nil {
+		b.WriteString(formatValidation(analysis.Validation))
+	}
+
+	if analysis.Deprecation != nil {
+		b.WriteString(formatDeprecation(analysis.Deprecation))
+	}
+
 	b.WriteString(formatSummary(analysis.Summary))
 
 	return b.String()
@@ -89,6 +105,9 @@ func formatImageResult(img ImageResult) string {
 	var b strings.Builder
 
 	b.WriteString(fmt.Sprintf("  %s\n", img.Reference))
+	for _, src := range img.Sources {
+		b.WriteString(fmt.Sprintf("    Referenced by: %s\n", src))
+	}
 
 	if !img.Accessible {
 		if img.Error != "" {
@@ -140,6 +159,117 @@ func formatImageResult(img ImageResult) string {
 	return b.String()
 }
 
+// formatRelatedImages formats the relatedImages completeness check.
+func formatRelatedImages(check *RelatedImagesCheck) string {
+	var b strings.Builder
+
+	b.WriteString("Related images:\n")
+	if check.Passed() {
+		b.WriteString(fmt.Sprintf("  ✓ All %d image references are declared in relatedImages and pinned by digest\n", len(check.Used)))
+	}
+	for _, u := range check.Missing {
+		b.WriteString(fmt.Sprintf("  ✗ Missing from relatedImages: %s\n", u.Image))
+		b.WriteString(fmt.Sprintf("    Used by %s %s (%s)\n", u.File, u.Path, u.Source))
+	}
+	for _, img := range check.TagReferences {
+		b.WriteString(fmt.Sprintf("  ✗ Not pinned by digest: %s\n", img))
+	}
+	for _, img := range check.Unreferenced {
+		b.WriteString(fmt.Sprintf("  ⚠ Declared but not referenced by any manifest: %s\n", img))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatCompatibility formats the target-platform compatibility
+// checks.
+func formatCompatibility(report *CompatibilityReport) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Compatibility with OpenShift %s (Kubernetes %s):\n", report.TargetOCP, report.KubeVersion))
+	for _, c := range report.Checks {
+		b.WriteString(fmt.Sprintf("  %s %s\n", statusSymbol(c.Status), c.Name))
+		for _, msg := range c.Messages {
+			b.WriteString(fmt.Sprintf("      %s\n", msg))
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatDeprecation formats the bundle's deprecation status in a
+// catalog.
+func formatDeprecation(status *DeprecationStatus) string {
+	var b strings.Builder
+
+	b.WriteString("Catalog deprecation status:\n")
+	switch {
+	case status.Bundle == "":
+		b.WriteString(fmt.Sprintf("  %s bundle not found in catalog\n", statusSymbol(StatusWarn)))
+	case len(status.Deprecations) == 0:
+		b.WriteString(fmt.Sprintf("  %s %s is not deprecated (matched by %s)\n", statusSymbol(StatusPass), status.Bundle, status.MatchedBy))
+	default:
+		b.WriteString(fmt.Sprintf("  %s (matched by %s):\n", status.Bundle, status.MatchedBy))
+		for _, d := range status.Deprecations {
+			b.WriteString(fmt.Sprintf("  %s deprecated by %s\n", statusSymbol(StatusWarn), d))
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// statusSymbol returns the symbol used for a check status.
+func statusSymbol(status string) string {
+	switch status {
+	case StatusWarn:
+		return "⚠"
+	case StatusFail:
+		return "✗"
+	}
+	return "✓"
+}
+
+// formatValidation formats bundle validation findings grouped by
+// manifest file.
+func formatValidation(validation *BundleValidation) string {
+	var b strings.Builder
+
+	header := "Validation"
+	if validation.K8sVersion != "" {
+		header = fmt.Sprintf("Validation (Kubernetes %s)", validation.K8sVersion)
+	}
+
+	if len(validation.Findings) == 0 {
+		b.WriteString(fmt.Sprintf("%s:\n  ✓ No errors or warnings\n\n", header))
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("%s (%d errors, %d warnings):\n", header, validation.Errors, validation.Warnings))
+
+	lastFile := "\x00"
+	for _, f := range validation.Findings {
+		if f.File != lastFile {
+			file := f.File
+			if file == "" {
+				file = "bundle"
+			}
+			b.WriteString(fmt.Sprintf("\n  %s\n", file))
+			lastFile = f.File
+		}
+		symbol := "⚠"
+		if f.Level == LevelError {
+			symbol = "✗"
+		}
+		b.WriteString(fmt.Sprintf("    %s [%s] %s\n", symbol, f.Suite, f.Message))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 // formatSummary formats the analysis summary.
 func formatSummary(summary Summary) string {
 	if summary.TotalImages == 0 {
@@ -166,6 +296,26 @@ func formatSummary(summary Summary) string {
 		parts = append(parts, fmt.Sprintf("%d inaccessible", summary.InaccessibleImages))
 	}
 
+	if summary.MissingRelatedImages > 0 {
+		parts = append(parts, fmt.Sprintf("%d missing from relatedImages", summary.MissingRelatedImages))
+	}
+
+	if summary.TagReferences > 0 {
+		parts = append(parts, fmt.Sprintf("%d not pinned by digest", summary.TagReferences))
+	}
+
+	if summary.CompatibilityFailures > 0 {
+		parts = append(parts, fmt.Sprintf("%d compatibility failures", summary.CompatibilityFailures))
+	}
+
+	if summary.ValidationErrors > 0 {
+		parts = append(parts, fmt.Sprintf("%d validation errors", summary.ValidationErrors))
+	}
+
+	if summary.ValidationWarnings > 0 {
+		parts = append(parts, fmt.Sprintf("%d validation warnings", summary.ValidationWarnings))
+	}
+
 	return fmt.Sprintf("Summary: %s\n", strings.Join(parts, ", "))
 }
 
@@ -219,3 +369,176 @@ func identifyComponent(imageRef string) string {
 
 	return ""
 }
+
+// FormatBundleDiff formats a bundle diff according to the specified
+// format.
+func FormatBundleDiff(diff *BundleDiff, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "markdown", "md":
+		return formatDiff(diff, &markdownStyle{}), nil
+	case "text", "":
+		return formatDiff(diff, &textStyle{}), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: text, markdown, json)", format)
+	}
+}
+
+// diffStyle renders the structural elements of a diff report.
+type diffStyle interface {
+	title(b *strings.Builder, s string)
+	section(b *strings.Builder, s string)
+	item(b *strings.Builder, level int, s string)
+	code(s string) string
+}
+
+type textStyle struct{}
+
+func (textStyle) title(b *strings.Builder, s string)   { b.WriteString(s + "\n") }
+func (textStyle) section(b *strings.Builder, s string) { b.WriteString("\n" + s + ":\n") }
+func (textStyle) item(b *strings.Builder, level int, s string) {
+	b.WriteString(strings.Repeat("  ", level+1) + s + "\n")
+}
+func (textStyle) code(s string) string { return s }
+
+type markdownStyle struct{}
+
+func (markdownStyle) title(b *strings.Builder, s string)   { b.WriteString("## " + s + "\n") }
+func (markdownStyle) section(b *strings.Builder, s string) { b.WriteString("\n### " + s + "\n\n") }
+func (markdownStyle) item(b *strings.Builder, level int, s string) {
+	b.WriteString(strings.Repeat("  ", level) + "- " + s + "\n")
+}
+func (markdownStyle) code(s string) string { return "`" + s + "`" }
+
+// changeSymbol returns the prefix used for a change kind.
+func changeSymbol(change string) string {
+	switch change {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	}
+	return "~"
+}
+
+func formatDiff(diff *BundleDiff, style diffStyle) string {
+	var b strings.Builder
+
+	title := fmt.Sprintf("Bundle diff: %s → %s", diff.From, diff.To)
+	if diff.FromVersion != "" || diff.ToVersion != "" {
+		title = fmt.Sprintf("Bundle diff: %s → %s", diff.FromVersion, diff.ToVersion)
+	}
+	style.title(&b, title)
+	if diff.FromVersion != "" || diff.ToVersion != "" {
+		style.item(&b, 0, fmt.Sprintf("From: %s", style.code(diff.From)))
+		style.item(&b, 0, fmt.Sprintf("To: %s", style.code(diff.To)))
+	}
+
+	if diff.IsEmpty() {
+		b.WriteString("\nNo differences in CRDs, RBAC, APIs or deployment images.\n")
+		return b.String()
+	}
+
+	if len(diff.CRDs) > 0 {
+		style.section(&b, "CRDs")
+		for _, crd := range diff.CRDs {
+			style.item(&b, 0, fmt.Sprintf("%s %s (%s)", changeSymbol(crd.Change), style.code(crd.Name), crd.Change))
+			if len(crd.VersionsAdded) > 0 {
+				style.item(&b, 1, fmt.Sprintf("versions added: %s", strings.Join(crd.VersionsAdded, ", ")))
+			}
+			if len(crd.VersionsRemoved) > 0 {
+				style.item(&b, 1, fmt.Sprintf("versions removed: %s", strings.Join(crd.VersionsRemoved, ", ")))
+			}
+			if crd.Change == ChangeChanged && crd.FromStorage != crd.ToStorage {
+				style.item(&b, 1, fmt.Sprintf("storage version: %s → %s", crd.FromStorage, crd.ToStorage))
+			}
+			for _, s := range crd.Served {
+				style.item(&b, 1, fmt.Sprintf("%s served: %t → %t", s.Version, s.From, s.To))
+			}
+			for _, f := range crd.Fields {
+				switch f.Change {
+				case ChangeTypeChanged:
+					style.item(&b, 1, fmt.Sprintf("~ %s %s: type %s → %s", f.Version, style.code(f.Path), f.FromType, f.ToType))
+				case ChangeAdded:
+					style.item(&b, 1, fmt.Sprintf("+ %s %s (%s)", f.Version, style.code(f.Path), f.ToType))
+				default:
+					style.item(&b, 1, fmt.Sprintf("- %s %s (%s)", f.Version, style.code(f.Path), f.FromType))
+				}
+			}
+		}
+	}
+
+	formatPermissionChanges(&b, style, "Cluster permissions", diff.ClusterPermissions)
+	formatPermissionChanges(&b, style, "Permissions", diff.Permissions)
+
+	if !diff.OwnedAPIs.IsEmpty() || !diff.RequiredAPIs.IsEmpty() {
+		style.section(&b, "APIs")
+		for _, api := range diff.OwnedAPIs.Added {
+			style.item(&b, 0, fmt.Sprintf("+ owned %s", style.code(api)))
+		}
+		for _, api := range diff.OwnedAPIs.Removed {
+			style.item(&b, 0, fmt.Sprintf("- owned %s", style.code(api)))
+		}
+		for _, api := range diff.RequiredAPIs.Added {
+			style.item(&b, 0, fmt.Sprintf("+ required %s", style.code(api)))
+		}
+		for _, api := range diff.RequiredAPIs.Removed {
+			style.item(&b, 0, fmt.Sprintf("- required %s", style.code(api)))
+		}
+	}
+
+	if len(diff.Images) > 0 {
+		style.section(&b, "Deployment images")
+		for _, img := range diff.Images {
+			switch img.Change {
+			case ChangeAdded:
+				style.item(&b, 0, fmt.Sprintf("+ %s: %s", img.Container, style.code(img.To)))
+			case ChangeRemoved:
+				style.item(&b, 0, fmt.Sprintf("- %s: %s", img.Container, style.code(img.From)))
+			default:
+				style.item(&b, 0, fmt.Sprintf("~ %s:", img.Container))
+				style.item(&b, 1, fmt.Sprintf("from %s", style.code(img.From)))
+				style.item(&b, 1, fmt.Sprintf("to   %s", style.code(img.To)))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// formatPermissionChanges lists RBAC changes, highlighting new
+// resources and verbs.
+func formatPermissionChanges(b *strings.Builder, style diffStyle, title string, changes []PermissionChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	style.section(b, title)
+	lastAccount := ""
+	for _, c := range changes {
+		if c.ServiceAccount != lastAccount {
+			style.item(b, 0, fmt.Sprintf("service account %s", style.code(c.ServiceAccount)))
+			lastAccount = c.ServiceAccount
+		}
+		switch c.Change {
+		case ChangeAdded:
+			style.item(b, 1, fmt.Sprintf("+ NEW RESOURCE %s: %s", style.code(c.Resource), joinOrNone(c.AddedVerbs)))
+		case ChangeRemoved:
+			style.item(b, 1, fmt.Sprintf("- %s: %s", style.code(c.Resource), joinOrNone(c.RemovedVerbs)))
+		default:
+			msg := fmt.Sprintf("~ %s:", style.code(c.Resource))
+			if len(c.AddedVerbs) > 0 {
+				msg += fmt.Sprintf(" NEW VERBS %s", strings.Join(c.AddedVerbs, ", "))
+			}
+			if len(c.RemovedVerbs) > 0 {
+				msg += fmt.Sprintf(" removed %s", strings.Join(c.RemovedVerbs, ", "))
+			}
+			style.item(b, 1, msg)
+		}
+	}
+}
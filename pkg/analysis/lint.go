@@ -0,0 +1,223 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/sirupsen/logrus"
+)
+
+// Lint check statuses.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// LintCheck is the outcome of a single lint against a bundle.
+type LintCheck struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"` // pass, warn or fail
+	Messages []string `json:"messages,omitempty"`
+}
+
+// BundleLint holds the lint results for one bundle in a catalog.
+type BundleLint struct {
+	Name         string                `json:"name"`
+	Image        string                `json:"image"`
+	Checks       []LintCheck           `json:"checks"`
+	Deprecations []catalog.Deprecation `json:"deprecations,omitempty"`
+}
+
+// Failed reports whether any check failed.
+func (b BundleLint) Failed() bool {
+	for _, c := range b.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// LintReport holds the lint results for every bundle of a package in
+// a catalog.
+type LintReport struct {
+	Package    string       `json:"package"`
+	Bundles    []BundleLint `json:"bundles"`
+	Failures   int          `json:"failures"`   // Number of bundles with a failed check
+	Deprecated int          `json:"deprecated"` // Number of deprecated bundles
+}
+
+// LintCatalog unpacks every bundle of a package in a catalog and runs
+// the bundle lints against its manifests.
+func LintCatalog(ctx context.Context, cfg *declcfg.DeclarativeConfig, packageName string) (*LintReport, error) {
+	report := &LintReport{
+		Package: packageName,
+		Bundles: []BundleLint{},
+	}
+
+	var bundles []declcfg.Bundle
+	for _, b := range cfg.Bundles {
+		if b.Package == packageName {
+			bundles = append(bundles, b)
+		}
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no bundles found for package %s", packageName)
+	}
+
+	registry, err := newBundleRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer registry.Destroy()
+
+	for i, b := range bundles {
+		logrus.Infof("Linting bundle %d/%d: %s", i+1, len(bundles), b.Name)
+		result := BundleLint{
+			Name:         b.Name,
+			Image:        b.Image,
+			Deprecations: catalog.BundleDeprecations(cfg, packageName, b.Name),
+		}
+		checks, err := lintBundleImage(ctx, b.Image, registry)
+		if err != nil {
+			checks = []LintCheck{{Name: "unpack", Status: StatusFail, Messages: []string{err.Error()}}}
+		}
+		result.Checks = checks
+		if result.Failed() {
+			report.Failures++
+		}
+		if len(result.Deprecations) > 0 {
+			report.Deprecated++
+		}
+		report.Bundles = append(report.Bundles, result)
+	}
+
+	return report, nil
+}
+
+// lintBundleImage unpacks a bundle image and lints its manifests.
+func lintBundleImage(ctx context.Context, bundleImage string, registry image.Registry) ([]LintCheck, error) {
+	bundleRef, err := ParseImageRef(bundleImage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle reference: %w", err)
+	}
+
+	bundleDir, err := UnpackBundle(ctx, bundleRef, registry)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	manifests, err := LoadBundleManifests(bundleDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return LintBundleManifests(manifests), nil
+}
+
+// LintBundleManifests runs the bundle lints against a set of bundle
+// manifests.
+func LintBundleManifests(manifests []Manifest) []LintCheck {
+	return []LintCheck{
+		lintRelatedImages(manifests),
+		lintALMExamples(manifests),
+	}
+}
+
+// lintRelatedImages fails if an image used by the bundle is missing
+// from relatedImages or any reference is not pinned by digest.
+func lintRelatedImages(manifests []Manifest) LintCheck {
+	check := LintCheck{Name: "related-images", Status: StatusPass}
+
+	result, err := CheckRelatedImages(manifests)
+	if err != nil {
+		check.Status = StatusFail
+		check.Messages = []string{err.Error()}
+		return check
+	}
+
+	for _, u := range result.Missing {
+		check.Messages = append(check.Messages, fmt.Sprintf("missing from relatedImages: %s (%s %s)", u.Image, u.File, u.Path))
+	}
+	for _, img := range result.TagReferences {
+		check.Messages = append(check.Messages, fmt.Sprintf("not pinned by digest: %s", img))
+	}
+	if !result.Passed() {
+		check.Status = StatusFail
+		return check
+	}
+
+	for _, img := range result.Unreferenced {
+		check.Status = StatusWarn
+		check.Messages = append(check.Messages, fmt.Sprintf("declared but not referenced by any manifest: %s", img))
+	}
+
+	return check
+}
+
+// lintALMExamples fails if an alm-examples entry does not validate
+// against its CRD schema.
+func lintALMExamples(manifests []Manifest) LintCheck {
+	check := LintCheck{Name: almExamplesAnnotation, Status: StatusPass}
+
+	issues, err := CheckALMExamples(manifests)
+	if err != nil {
+		check.Status = StatusFail
+		check.Messages = []string{err.Error()}
+		return check
+	}
+
+	for _, issue := range issues {
+		check.Status = StatusFail
+		check.Messages = append(check.Messages, issue.String())
+	}
+
+	return check
+}
+
+// FormatLintReport formats a lint report according to the specified
+// format.
+func FormatLintReport(report *LintReport, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "text", "":
+		return formatLintText(report), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: text, json)", format)
+	}
+}
+
+func formatLintText(report *LintReport) string {
+	var b strings.Builder
+
+	for _, bundle := range report.Bundles {
+		b.WriteString(fmt.Sprintf("%s (%s)\n", bundle.Name, bundle.Image))
+		for _, d := range bundle.Deprecations {
+			b.WriteString(fmt.Sprintf("  %s deprecated by %s\n", statusSymbol(StatusWarn), d))
+		}
+		for _, c := range bundle.Checks {
+			b.WriteString(fmt.Sprintf("  %s %s\n", statusSymbol(c.Status), c.Name))
+			for _, msg := range c.Messages {
+				b.WriteString(fmt.Sprintf("      %s\n", msg))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fmt.Sprintf("Summary: %d bundles linted, %d failed, %d deprecated\n", len(report.Bundles), report.Failures, report.Deprecated))
+
+	return b.String()
+}
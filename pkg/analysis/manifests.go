@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is a single Kubernetes object read from an unpacked
+// bundle.
+type Manifest struct {
+	File   string         // Path relative to the bundle root, e.g. manifests/foo.yaml
+	Object map[string]any // Decoded object
+}
+
+// APIVersion returns the apiVersion of the manifest.
+func (m Manifest) APIVersion() string {
+	s, _ := m.Object["apiVersion"].(string)
+	return s
+}
+
+// Kind returns the kind of the manifest.
+func (m Manifest) Kind() string {
+	s, _ := m.Object["kind"].(string)
+	return s
+}
+
+// Name returns metadata.name of the manifest.
+func (m Manifest) Name() string {
+	meta, _ := m.Object["metadata"].(map[string]any)
+	s, _ := meta["name"].(string)
+	return s
+}
+
+// Decode converts the manifest into a typed object.
+func (m Manifest) Decode(into any) error {
+	data, err := yaml.Marshal(m.Object)
+	if err != nil {
+		return fmt.Errorf("marshaling %s: %w", m.File, err)
+	}
+	if err := yaml.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("decoding %s: %w", m.File, err)
+	}
+	return nil
+}
+
+// UnpackBundle unpacks a bundle image into a new temporary directory
+// and returns its path. The caller is responsible for removing the
+// directory.
+func UnpackBundle(ctx context.Context, bundleRef ImageRef, registry image.Registry) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "bundle-unpack-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp directory: %w", err)
+	}
+
+	ref := image.SimpleReference(bundleRef.String())
+	if err := registry.Unpack(ctx, ref, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("unpacking bundle image: %w", err)
+	}
+
+	return tmpDir, nil
+}
+
+// LoadBundleManifests reads every YAML document in the manifests/
+// directory of an unpacked bundle. Files are returned in name order
+// and empty documents are skipped.
+func LoadBundleManifests(bundleDir string) ([]Manifest, error) {
+	manifestDir := filepath.Join(bundleDir, "manifests")
+	if _, err := os.Stat(manifestDir); err != nil {
+		return nil, fmt.Errorf("reading manifests directory: %w", err)
+	}
+	return loadManifestsFromDir(bundleDir, manifestDir)
+}
+
+// LoadManifestDir reads every YAML document below dir, recording file
+// paths relative to dir.
+func LoadManifestDir(dir string) ([]Manifest, error) {
+	return loadManifestsFromDir(dir, dir)
+}
+
+// loadManifestsFromDir reads every YAML document below dir, recording
+// file paths relative to root.
+func loadManifestsFromDir(root, dir string) ([]Manifest, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	var manifests []Manifest
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+
+		docs, err := decodeYAMLDocuments(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", rel, err)
+		}
+
+		for _, doc := range docs {
+			manifests = append(manifests, Manifest{
+				File:   filepath.ToSlash(rel),
+				Object: doc,
+			})
+		}
+	}
+
+	return manifests, nil
+}
+
+// decodeYAMLDocuments splits a multi-document YAML stream and decodes
+// each non-empty document.
+func decodeYAMLDocuments(data []byte) ([]map[string]any, error) {
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+
+	var docs []map[string]any
+	for {
+		raw, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var obj map[string]any
+		if err := yaml.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		docs = append(docs, obj)
+	}
+
+	return docs, nil
+}
+
+// FindCSV returns the ClusterServiceVersion from a set of bundle
+// manifests.
+func FindCSV(manifests []Manifest) (*v1alpha1.ClusterServiceVersion, *Manifest, error) {
+	for i := range manifests {
+		if manifests[i].Kind() != v1alpha1.ClusterServiceVersionKind {
+			continue
+		}
+		var csv v1alpha1.ClusterServiceVersion
+		if err := manifests[i].Decode(&csv); err != nil {
+			return nil, nil, err
+		}
+		return &csv, &manifests[i], nil
+	}
+	return nil, nil, fmt.Errorf("no ClusterServiceVersion found in bundle manifests")
+}
@@ -0,0 +1,268 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+)
+
+// Image usage sources.
+const (
+	SourceBundle        = "bundle"
+	SourceRelatedImages = "related-images"
+	SourceContainer     = "container"
+	SourceInitContainer = "init-container"
+	SourceEnv           = "env"
+	SourceConfigMap     = "configmap"
+	SourceAnnotation    = "annotation"
+	SourceManifest      = "manifest"
+)
+
+// relatedImageEnvPrefix marks environment variables that OLM and
+// disconnected mirroring tools treat as image references.
+const relatedImageEnvPrefix = "RELATED_IMAGE_"
+
+// BpfmanConfigMapName is the ConfigMap the operator reads its daemon
+// and agent configuration, including their images, from.
+const BpfmanConfigMapName = "bpfman-config"
+
+// ImageSource records where an image reference was found.
+type ImageSource struct {
+	File   string `json:"file,omitempty"` // Manifest file, relative to the bundle root
+	Path   string `json:"path,omitempty"` // JSON path within the manifest
+	Source string `json:"source"`         // e.g. container, env, configmap or related-images
+}
+
+// String returns a short description of the source.
+func (s ImageSource) String() string {
+	if s.File == "" {
+		return s.Source
+	}
+	return fmt.Sprintf("%s %s (%s)", s.File, s.Path, s.Source)
+}
+
+// ImageUsage records where an image is referenced in a bundle.
+type ImageUsage struct {
+	Image string `json:"image"`
+	ImageSource
+}
+
+// RelatedImagesCheck compares the images a bundle uses with the
+// relatedImages declared in its CSV.
+type RelatedImagesCheck struct {
+	RelatedImages []string     `json:"related_images"`
+	Used          []ImageUsage `json:"used"`
+	Missing       []ImageUsage `json:"missing,omitempty"`        // Used but not declared in relatedImages
+	Unreferenced  []string     `json:"unreferenced,omitempty"`   // Declared but not used by any manifest
+	TagReferences []string     `json:"tag_references,omitempty"` // References not pinned by digest
+}
+
+// Passed reports whether every used image is declared in
+// relatedImages and every reference is pinned by digest.
+func (c *RelatedImagesCheck) Passed() bool {
+	return c != nil && len(c.Missing) == 0 && len(c.TagReferences) == 0
+}
+
+// CheckRelatedImages finds every image referenced by the bundle
+// manifests and reports those missing from the CSV's relatedImages.
+// Images used by the operator but not declared there are not
+// mirrored for disconnected installs.
+func CheckRelatedImages(manifests []Manifest) (*RelatedImagesCheck, error) {
+	csv, _, err := FindCSV(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &RelatedImagesCheck{
+		RelatedImages: []string{},
+		Used:          FindImageUsages(manifests),
+	}
+
+	declared := make(map[string]bool)
+	for _, ri := range csv.Spec.RelatedImages {
+		if ri.Image == "" {
+			continue
+		}
+		check.RelatedImages = append(check.RelatedImages, ri.Image)
+		declared[normaliseImage(ri.Image)] = true
+	}
+
+	used := make(map[string]bool)
+	for _, u := range check.Used {
+		key := normaliseImage(u.Image)
+		used[key] = true
+		if !declared[key] {
+			check.Missing = append(check.Missing, u)
+		}
+	}
+
+	for _, img := range check.RelatedImages {
+		if !used[normaliseImage(img)] {
+			check.Unreferenced = append(check.Unreferenced, img)
+		}
+	}
+
+	var all []string
+	all = append(all, check.RelatedImages...)
+	for _, u := range check.Used {
+		all = append(all, u.Image)
+	}
+	for _, img := range deduplicateStrings(all) {
+		if !isDigestReference(img) {
+			check.TagReferences = append(check.TagReferences, img)
+		}
+	}
+	sort.Strings(check.TagReferences)
+
+	return check, nil
+}
+
+// FindImageUsages walks every bundle manifest and returns each image
+// reference with the file and JSON path it was found at. Container
+// and initContainer images, RELATED_IMAGE_* environment variables and
+// any string value under an image-like key are included. The CSV's
+// own relatedImages list is skipped.
+func FindImageUsages(manifests []Manifest) []ImageUsage {
+	var usages []ImageUsage
+	for _, m := range manifests {
+		w := imageWalker{manifest: m}
+		w.walk(m.Object, "")
+		usages = append(usages, w.usages...)
+	}
+	return usages
+}
+
+type imageWalker struct {
+	manifest Manifest
+	usages   []ImageUsage
+}
+
+func (w *imageWalker) walk(node any, path string) {
+	switch v := node.(type) {
+	case map[string]any:
+		if w.manifest.Kind() == "ClusterServiceVersion" && path == ".spec" {
+			// Walk everything except the declared relatedImages.
+			for _, k := range sortedKeys(v) {
+				if k != "relatedImages" {
+					w.walkField(v, k, path)
+				}
+			}
+			return
+		}
+		if name, ok := v["name"].(string); ok && strings.HasPrefix(name, relatedImageEnvPrefix) {
+			if value, ok := v["value"].(string); ok && looksLikeImage(value) {
+				w.add(value, path+".value", SourceEnv)
+			}
+		}
+		for _, k := range sortedKeys(v) {
+			w.walkField(v, k, path)
+		}
+	case []any:
+		for i, item := range v {
+			w.walk(item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+func (w *imageWalker) walkField(obj map[string]any, key, path string) {
+	fieldPath := jsonPathField(path, key)
+	if s, ok := obj[key].(string); ok {
+		if isImageKey(key) && looksLikeImage(s) {
+			w.add(s, fieldPath, w.sourceFor(fieldPath))
+		}
+		return
+	}
+	w.walk(obj[key], fieldPath)
+}
+
+func (w *imageWalker) add(image, path, source string) {
+	w.usages = append(w.usages, ImageUsage{
+		Image: image,
+		ImageSource: ImageSource{
+			File:   w.manifest.File,
+			Path:   path,
+			Source: source,
+		},
+	})
+}
+
+// sourceFor classifies an image-valued field by where it appears.
+func (w *imageWalker) sourceFor(path string) string {
+	switch {
+	case w.manifest.Kind() == "ConfigMap":
+		return SourceConfigMap
+	case strings.HasPrefix(path, ".metadata.annotations"):
+		return SourceAnnotation
+	case strings.Contains(path, ".initContainers["):
+		return SourceInitContainer
+	case strings.Contains(path, ".containers["):
+		return SourceContainer
+	}
+	return SourceManifest
+}
+
+// isImageKey reports whether a map key names an image, e.g. image,
+// containerImage, bpfman.image or agent_image.
+func isImageKey(key string) bool {
+	if key == "image" || strings.HasSuffix(key, "Image") {
+		return true
+	}
+	lower := strings.ToLower(key)
+	for _, sep := range []string{".", "_", "-"} {
+		if strings.HasSuffix(lower, sep+"image") {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeImage reports whether s parses as a fully qualified image
+// reference.
+func looksLikeImage(s string) bool {
+	if !strings.Contains(s, "/") || strings.ContainsAny(s, " \t\n") {
+		return false
+	}
+	_, err := reference.ParseNormalizedNamed(s)
+	return err == nil
+}
+
+// isDigestReference reports whether an image reference is pinned by
+// digest.
+func isDigestReference(s string) bool {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return strings.Contains(s, "@sha256:")
+	}
+	_, ok := named.(reference.Digested)
+	return ok
+}
+
+// normaliseImage returns the canonical form of an image reference so
+// that e.g. docker.io/library/foo and foo compare equal.
+func normaliseImage(s string) string {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return s
+	}
+	return named.String()
+}
+
+// jsonPathField appends a field to a JSON path, bracketing keys that
+// contain dots or slashes.
+func jsonPathField(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
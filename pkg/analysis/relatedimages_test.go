@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	testOperatorImage = "registry.redhat.io/bpfman/bpfman-rhel9-operator@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testAgentImage    = "registry.redhat.io/bpfman/bpfman-agent@sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	testDaemonImage   = "registry.redhat.io/bpfman/bpfman@sha256:3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+// TestCheckRelatedImages tests that images used by containers,
+// RELATED_IMAGE_* variables and the ConfigMap are compared against
+// relatedImages.
+func TestCheckRelatedImages(t *testing.T) {
+	configMap := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bpfman-config
+data:
+  bpfman.agent.image: ` + testAgentImage + `
+  bpfman.image: ` + testDaemonImage + `
+  bpfman.log.level: info
+`
+
+	tests := []struct {
+		name          string
+		relatedImages []string
+		initImage     string
+		wantMissing   []string
+		wantTags      []string
+		wantPassed    bool
+	}{
+		{
+			name:          "all images declared",
+			relatedImages: []string{testOperatorImage, testAgentImage, testDaemonImage},
+			wantPassed:    true,
+		},
+		{
+			name:          "configmap images missing",
+			relatedImages: []string{testOperatorImage, testAgentImage},
+			wantMissing:   []string{".data[bpfman.image]"},
+		},
+		{
+			name:          "init container by tag",
+			relatedImages: []string{testOperatorImage, testAgentImage, testDaemonImage},
+			initImage:     "quay.io/bpfman/init:v1",
+			wantMissing:   []string{".spec.install.spec.deployments[0].spec.template.spec.initContainers[0].image"},
+			wantTags:      []string{"quay.io/bpfman/init:v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var related []map[string]string
+			for _, img := range tt.relatedImages {
+				related = append(related, map[string]string{"image": img})
+			}
+
+			podSpec := map[string]any{
+				"containers": []any{map[string]any{
+					"name":  "manager",
+					"image": testOperatorImage,
+					"env": []any{
+						map[string]any{"name": "RELATED_IMAGE_AGENT", "value": testAgentImage},
+						map[string]any{"name": "GOMEMLIMIT", "value": "100MiB"},
+					},
+				}},
+			}
+			if tt.initImage != "" {
+				podSpec["initContainers"] = []any{map[string]any{"name": "init", "image": tt.initImage}}
+			}
+
+			csv := map[string]any{
+				"apiVersion": "operators.coreos.com/v1alpha1",
+				"kind":       "ClusterServiceVersion",
+				"metadata":   map[string]any{"name": "bpfman-operator.v0.6.0"},
+				"spec": map[string]any{
+					"relatedImages": related,
+					"install": map[string]any{
+						"strategy": "deployment",
+						"spec": map[string]any{
+							"deployments": []any{map[string]any{
+								"name": "bpfman-operator",
+								"spec": map[string]any{
+									"template": map[string]any{"spec": podSpec},
+								},
+							}},
+						},
+					},
+				},
+			}
+
+			manifests := []Manifest{
+				{File: "manifests/bpfman-config_v1_configmap.yaml", Object: decodeTestYAML(t, configMap)},
+				{File: "manifests/bpfman-operator.clusterserviceversion.yaml", Object: roundTrip(t, csv)},
+			}
+
+			check, err := CheckRelatedImages(manifests)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if check.Passed() != tt.wantPassed {
+				t.Errorf("got passed=%v, want %v", check.Passed(), tt.wantPassed)
+			}
+
+			var missing []string
+			for _, u := range check.Missing {
+				missing = append(missing, u.Path)
+			}
+			if !equalStrings(missing, tt.wantMissing) {
+				t.Errorf("got missing %v, want %v", missing, tt.wantMissing)
+			}
+			if !equalStrings(check.TagReferences, tt.wantTags) {
+				t.Errorf("got tag references %v, want %v", check.TagReferences, tt.wantTags)
+			}
+		})
+	}
+}
+
+func decodeTestYAML(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var obj map[string]any
+	if err := yaml.Unmarshal([]byte(s), &obj); err != nil {
+		t.Fatalf("parsing YAML: %v", err)
+	}
+	return obj
+}
+
+// roundTrip converts an object to the generic form produced by
+// decoding YAML.
+func roundTrip(t *testing.T, obj any) map[string]any {
+	t.Helper()
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		t.Fatalf("marshaling: %v", err)
+	}
+	return decodeTestYAML(t, string(data))
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
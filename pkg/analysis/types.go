@@ -4,26 +4,42 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
 )
 
 // BundleAnalysis represents complete analysis results for a bundle
 // image.
 type BundleAnalysis struct {
-	BundleRef  ImageRef      `json:"bundle_ref"`
-	BundleInfo *ImageInfo    `json:"bundle_info,omitempty"`
-	Stream     string        `json:"stream"` // Stream detected from bundle (ystream/zstream)
-	Images     []ImageResult `json:"images"`
-	Summary    Summary       `json:"summary"`
+	BundleRef     ImageRef             `json:"bundle_ref"`
+	BundleInfo    *ImageInfo           `json:"bundle_info,omitempty"`
+	Stream        string               `json:"stream"` // Stream detected from bundle (ystream/zstream)
+	Images        []ImageResult        `json:"images"`
+	RelatedImages *RelatedImagesCheck  `json:"related_images,omitempty"`
+	Compatibility *CompatibilityReport `json:"compatibility,omitempty"`
+	Validation    *BundleValidation    `json:"validation,omitempty"`
+	Deprecation   *DeprecationStatus   `json:"deprecation,omitempty"`
+	Summary       Summary              `json:"summary"`
+}
+
+// DeprecationStatus reports the deprecations that apply to a bundle
+// in a catalog.
+type DeprecationStatus struct {
+	Package      string                `json:"package,omitempty"`
+	Bundle       string                `json:"bundle,omitempty"`     // Empty if the bundle is not in the catalog
+	MatchedBy    string                `json:"matched_by,omitempty"` // image or version
+	Deprecations []catalog.Deprecation `json:"deprecations,omitempty"`
 }
 
 // ImageResult contains analysis results for a single image.
 type ImageResult struct {
-	Reference  string       `json:"reference"`
-	TenantRef  string       `json:"tenant_ref,omitempty"` // Tenant workspace reference if found there
-	Accessible bool         `json:"accessible"`
-	Registry   RegistryType `json:"registry"`
-	Info       *ImageInfo   `json:"info,omitempty"`
-	Error      string       `json:"error,omitempty"`
+	Reference  string        `json:"reference"`
+	TenantRef  string        `json:"tenant_ref,omitempty"` // Tenant workspace reference if found there
+	Accessible bool          `json:"accessible"`
+	Registry   RegistryType  `json:"registry"`
+	Info       *ImageInfo    `json:"info,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Sources    []ImageSource `json:"sources,omitempty"` // Where the bundle references the image
 }
 
 // ImageInfo holds extracted metadata from image labels and manifest.
@@ -41,11 +57,16 @@ type ImageInfo struct {
 
 // Summary provides aggregate statistics from the analysis.
 type Summary struct {
-	TotalImages        int `json:"total_images"`
-	AccessibleImages   int `json:"accessible_images"`
-	DownstreamImages   int `json:"downstream_images"`
-	TenantImages       int `json:"tenant_images"`
-	InaccessibleImages int `json:"inaccessible_images"`
+	TotalImages           int `json:"total_images"`
+	AccessibleImages      int `json:"accessible_images"`
+	DownstreamImages      int `json:"downstream_images"`
+	TenantImages          int `json:"tenant_images"`
+	InaccessibleImages    int `json:"inaccessible_images"`
+	MissingRelatedImages  int `json:"missing_related_images,omitempty"`
+	TagReferences         int `json:"tag_references,omitempty"`
+	CompatibilityFailures int `json:"compatibility_failures,omitempty"`
+	ValidationErrors      int `json:"validation_errors,omitempty"`
+	ValidationWarnings    int `json:"validation_warnings,omitempty"`
 }
 
 // RegistryType indicates where an image was found.
@@ -0,0 +1,164 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/operator-framework/api/pkg/validation"
+	validationerrors "github.com/operator-framework/api/pkg/validation/errors"
+	validator "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// Validation severity levels.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// bundleValidatorSuites lists the operator-framework validator suites
+// run against a bundle, in report order.
+var bundleValidatorSuites = []struct {
+	name       string
+	validators validator.Validators
+}{
+	{"bundle", validation.DefaultBundleValidators},
+	{"operatorhub", validator.Validators{validation.OperatorHubV2Validator}},
+	{"good-practices", validator.Validators{validation.GoodPracticesValidator}},
+	{"deprecated-apis", validator.Validators{validation.AlphaDeprecatedAPIsValidator}},
+}
+
+// BundleValidation holds the results of validating a bundle's
+// manifests.
+type BundleValidation struct {
+	K8sVersion string              `json:"k8s_version,omitempty"` // Kubernetes version checked for removed APIs
+	Findings   []ValidationFinding `json:"findings"`
+	Errors     int                 `json:"errors"`
+	Warnings   int                 `json:"warnings"`
+}
+
+// ValidationFinding is a single error or warning reported by a
+// validator.
+type ValidationFinding struct {
+	Suite    string `json:"suite"`              // Validator suite, e.g. operatorhub
+	Level    string `json:"level"`              // error or warning
+	File     string `json:"file,omitempty"`     // Manifest file, relative to the bundle root
+	Manifest string `json:"manifest,omitempty"` // Name reported by the validator
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// HasErrors reports whether any finding is an error.
+func (v *BundleValidation) HasErrors() bool {
+	return v != nil && v.Errors > 0
+}
+
+// ValidateBundleDir runs the operator-framework validator suites
+// against an unpacked bundle. If k8sVersion is set, removed APIs are
+// checked against that Kubernetes version rather than the CSV's
+// minKubeVersion.
+func ValidateBundleDir(bundleDir, k8sVersion string) (*BundleValidation, error) {
+	bundle, err := manifests.GetBundleFromDir(bundleDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading bundle: %w", err)
+	}
+
+	loaded, err := LoadBundleManifests(bundleDir)
+	if err != nil {
+		return nil, err
+	}
+	files := manifestFilesByName(loaded)
+
+	objs := bundle.ObjectsToValidate()
+	if k8sVersion != "" {
+		objs = append(objs, map[string]string{"k8s-version": k8sVersion})
+	}
+
+	result := &BundleValidation{
+		K8sVersion: k8sVersion,
+		Findings:   []ValidationFinding{},
+	}
+	for _, suite := range bundleValidatorSuites {
+		for _, manifestResult := range suite.validators.Validate(objs...) {
+			file := files[manifestResult.Name]
+			for _, e := range manifestResult.Errors {
+				result.add(suite.name, LevelError, file, manifestResult.Name, e)
+			}
+			for _, e := range manifestResult.Warnings {
+				result.add(suite.name, LevelWarning, file, manifestResult.Name, e)
+			}
+		}
+	}
+
+	if err := result.addALMExamples(loaded); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Level == LevelError && b.Level != LevelError
+	})
+
+	return result, nil
+}
+
+func (v *BundleValidation) add(suite, level, file, manifest string, e validationerrors.Error) {
+	v.Findings = append(v.Findings, ValidationFinding{
+		Suite:    suite,
+		Level:    level,
+		File:     file,
+		Manifest: manifest,
+		Field:    e.Field,
+		Message:  e.Error(),
+	})
+	if level == LevelError {
+		v.Errors++
+	} else {
+		v.Warnings++
+	}
+}
+
+// addALMExamples validates the CSV's alm-examples against the
+// bundle's CRD schemas.
+func (v *BundleValidation) addALMExamples(loaded []Manifest) error {
+	issues, err := CheckALMExamples(loaded)
+	if err != nil {
+		return err
+	}
+
+	_, csv, err := FindCSV(loaded)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		v.Findings = append(v.Findings, ValidationFinding{
+			Suite:    almExamplesAnnotation,
+			Level:    LevelError,
+			File:     csv.File,
+			Manifest: csv.Name(),
+			Field:    issue.Field(),
+			Message:  issue.String(),
+		})
+		v.Errors++
+	}
+	return nil
+}
+
+// manifestFilesByName maps object names to the bundle file that
+// declares them. Validators report results by object name, or by the
+// bundle name which is the CSV's name.
+func manifestFilesByName(loaded []Manifest) map[string]string {
+	files := make(map[string]string)
+	for _, m := range loaded {
+		if name := m.Name(); name != "" {
+			if _, ok := files[name]; !ok {
+				files[name] = m.File
+			}
+		}
+	}
+
+	return files
+}
@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAnnotations = `annotations:
+  operators.operatorframework.io.bundle.package.v1: bpfman-operator
+  operators.operatorframework.io.bundle.channels.v1: stable
+  operators.operatorframework.io.bundle.manifests.v1: manifests/
+  operators.operatorframework.io.bundle.mediatype.v1: registry+v1
+  operators.operatorframework.io.bundle.metadata.v1: metadata/
+`
+
+const testCSV = `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: bpfman-operator.v0.6.0
+spec:
+  version: 0.6.0
+  displayName: eBPF Manager Operator
+  provider:
+    name: Red Hat
+  install:
+    strategy: deployment
+    spec:
+      deployments:
+      - name: bpfman-operator
+        spec:
+          selector:
+            matchLabels:
+              app: bpfman-operator
+          template:
+            metadata:
+              labels:
+                app: bpfman-operator
+            spec:
+              containers:
+              - name: manager
+                image: quay.io/bpfman/bpfman-operator:latest
+`
+
+// TestValidateBundleDir tests that validator results are attributed
+// to the manifest file that caused them.
+func TestValidateBundleDir(t *testing.T) {
+	tests := []struct {
+		name       string
+		csv        string
+		wantErrors bool
+		wantText   string
+	}{
+		{
+			name:       "missing install modes",
+			csv:        testCSV,
+			wantErrors: true,
+			wantText:   "install modes not found",
+		},
+		{
+			name: "install modes present",
+			csv: testCSV + `  installModes:
+  - type: AllNamespaces
+    supported: true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, filepath.Join(dir, "metadata", "annotations.yaml"), testAnnotations)
+			writeTestFile(t, filepath.Join(dir, "manifests", "bpfman-operator.clusterserviceversion.yaml"), tt.csv)
+
+			result, err := ValidateBundleDir(dir, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.HasErrors() != tt.wantErrors {
+				t.Fatalf("got errors=%v, want %v: %+v", result.HasErrors(), tt.wantErrors, result.Findings)
+			}
+
+			if tt.wantText == "" {
+				return
+			}
+			for _, f := range result.Findings {
+				if strings.Contains(f.Message, tt.wantText) {
+					if f.File != "manifests/bpfman-operator.clusterserviceversion.yaml" {
+						t.Errorf("finding attributed to %q, want the CSV file", f.File)
+					}
+					return
+				}
+			}
+			t.Errorf("no finding contains %q: %+v", tt.wantText, result.Findings)
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
@@ -0,0 +1,381 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
+)
+
+// buildVersionArg is the Dockerfile build argument that sets the
+// catalog image's version label.
+const buildVersionArg = "BUILDVERSION"
+
+// VersionCheckConfig controls where check-versions reads versions
+// from.
+type VersionCheckConfig struct {
+	RenderedDir string // Read rendered catalogs from here instead of rendering the templates
+	SkipLabels  bool   // Do not inspect bundle image labels
+}
+
+// VersionReport holds the version checks for every template.
+type VersionReport struct {
+	Templates []TemplateVersions `json:"templates"`
+	Failures  int                `json:"failures"` // Number of templates with a failed check
+}
+
+// TemplateVersions holds the versions recorded for one catalog
+// template and the checks run against them.
+type TemplateVersions struct {
+	Template     string           `json:"template"`
+	BuildVersion string           `json:"build_version,omitempty"` // BUILDVERSION from the Dockerfile-args file
+	Bundles      []BundleVersions `json:"bundles"`
+	Checks       []LintCheck      `json:"checks"`
+}
+
+// Failed reports whether any check failed.
+func (t TemplateVersions) Failed() bool {
+	for _, c := range t.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// BundleVersions holds the versions recorded for one bundle.
+type BundleVersions struct {
+	Name         string `json:"name,omitempty"`
+	Image        string `json:"image"`
+	Comment      string `json:"comment,omitempty"`       // Version from the template comment
+	CSVVersion   string `json:"csv_version,omitempty"`   // CSV spec.version
+	LabelVersion string `json:"label_version,omitempty"` // Bundle image version label
+}
+
+// FindTemplates returns the catalog templates in a directory.
+func FindTemplates(dir string) ([]string, error) {
+	templates, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no templates found in %s", dir)
+	}
+	sort.Strings(templates)
+	return templates, nil
+}
+
+// CheckVersions runs the version checks against each template.
+func CheckVersions(ctx context.Context, templates []string, config VersionCheckConfig) (*VersionReport, error) {
+	report := &VersionReport{Templates: []TemplateVersions{}}
+	for _, path := range templates {
+		result, err := CheckTemplateVersions(ctx, path, config)
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", path, err)
+		}
+		if result.Failed() {
+			report.Failures++
+		}
+		report.Templates = append(report.Templates, *result)
+	}
+	return report, nil
+}
+
+// CheckTemplateVersions compares the version of each bundle in a
+// template as recorded by the template comment, the CSV spec.version
+// and the bundle image's version label. It also checks that
+// BUILDVERSION in the template's Dockerfile-args file matches the
+// default channel head, that each channel head is its highest
+// version, and that channel entries are listed in increasing order.
+func CheckTemplateVersions(ctx context.Context, path string, config VersionCheckConfig) (*TemplateVersions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+	templateBundles, err := catalog.ReadTemplateBundles(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadRenderedTemplate(ctx, path, config.RenderedDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TemplateVersions{
+		Template: path,
+		Bundles:  []BundleVersions{},
+	}
+
+	buildVersion, err := readBuildVersion(dockerfileArgsPath(path))
+	buildVersionCheck := LintCheck{Name: "build-version", Status: StatusPass}
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		buildVersionCheck.Status = StatusWarn
+		buildVersionCheck.Messages = []string{fmt.Sprintf("%s not found", dockerfileArgsPath(path))}
+	case err != nil:
+		return nil, err
+	}
+	result.BuildVersion = buildVersion
+
+	versions := make(map[string]string) // bundle name → CSV version
+	byImage := make(map[string]declcfg.Bundle)
+	for _, b := range cfg.Bundles {
+		byImage[b.Image] = b
+		versions[b.Name] = catalog.BundleVersion(b)
+	}
+
+	for _, tb := range templateBundles {
+		bv := BundleVersions{Image: tb.Image}
+		if tb.Comment != "" {
+			bv.Comment = extractVersionFromString(tb.Comment)
+		}
+		if b, ok := byImage[tb.Image]; ok {
+			bv.Name = b.Name
+			bv.CSVVersion = versions[b.Name]
+		}
+		result.Bundles = append(result.Bundles, bv)
+	}
+
+	labelErrs := make(map[string]error)
+	if !config.SkipLabels {
+		for i := range result.Bundles {
+			bv := &result.Bundles[i]
+			v, err := bundleLabelVersion(ctx, bv.Image)
+			if err != nil {
+				labelErrs[bv.Image] = err
+				continue
+			}
+			bv.LabelVersion = v
+		}
+	}
+
+	result.Checks = append(result.Checks, checkBundleVersions(result.Bundles, labelErrs))
+	result.Checks = append(result.Checks, checkBuildVersion(buildVersionCheck, buildVersion, cfg, versions))
+	result.Checks = append(result.Checks, checkChannelVersions(cfg, versions)...)
+
+	return result, nil
+}
+
+// loadRenderedTemplate renders a template, or loads the catalog
+// previously rendered from it if renderedDir is set.
+func loadRenderedTemplate(ctx context.Context, path, renderedDir string) (*declcfg.DeclarativeConfig, error) {
+	if renderedDir == "" {
+		return catalog.RenderTemplate(ctx, path)
+	}
+	return catalog.Load(ctx, filepath.Join(renderedDir, filepath.Base(path)))
+}
+
+// dockerfileArgsPath returns the Dockerfile-args file that
+// accompanies a template, e.g. templates/z-stream.Dockerfile-args.
+func dockerfileArgsPath(templatePath string) string {
+	return strings.TrimSuffix(templatePath, filepath.Ext(templatePath)) + ".Dockerfile-args"
+}
+
+// readBuildVersion reads BUILDVERSION from a Dockerfile-args file.
+func readBuildVersion(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok && key == buildVersionArg {
+			return strings.Trim(value, `"'`), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// bundleLabelVersion reads the version label of a bundle image.
+func bundleLabelVersion(ctx context.Context, bundleImage string) (string, error) {
+	ref, err := ParseImageRef(bundleImage)
+	if err != nil {
+		return "", err
+	}
+	info, err := inspectImageRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return extractVersion(info.Labels), nil
+}
+
+// sameVersion reports whether two version strings are equal semver
+// versions, ignoring a leading v.
+func sameVersion(a, b string) bool {
+	va, errA := semver.ParseTolerant(a)
+	vb, errB := semver.ParseTolerant(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Equals(vb)
+}
+
+// checkBundleVersions fails if the template comment, CSV version and
+// version label of a bundle disagree.
+func checkBundleVersions(bundles []BundleVersions, labelErrs map[string]error) LintCheck {
+	check := LintCheck{Name: "bundle-versions", Status: StatusPass}
+	warn := func(msg string) {
+		if check.Status == StatusPass {
+			check.Status = StatusWarn
+		}
+		check.Messages = append(check.Messages, msg)
+	}
+
+	for _, bv := range bundles {
+		name := bv.Name
+		if name == "" {
+			name = bv.Image
+		}
+		if bv.CSVVersion == "" {
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: not found in the rendered catalog", name))
+			continue
+		}
+		if bv.Comment == "" {
+			warn(fmt.Sprintf("%s: no version comment in the template", name))
+		} else if !sameVersion(bv.Comment, bv.CSVVersion) {
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: template comment %s does not match CSV version %s", name, bv.Comment, bv.CSVVersion))
+		}
+		if err, ok := labelErrs[bv.Image]; ok {
+			warn(fmt.Sprintf("%s: could not read version label: %v", name, err))
+		} else if bv.LabelVersion != "" && !sameVersion(bv.LabelVersion, bv.CSVVersion) {
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s: version label %s does not match CSV version %s", name, bv.LabelVersion, bv.CSVVersion))
+		}
+	}
+
+	return check
+}
+
+// checkBuildVersion fails if BUILDVERSION is not the version of the
+// default channel head.
+func checkBuildVersion(check LintCheck, buildVersion string, cfg *declcfg.DeclarativeConfig, versions map[string]string) LintCheck {
+	if check.Status != StatusPass {
+		return check
+	}
+
+	for _, p := range cfg.Packages {
+		chain, err := catalog.ReplacesChain(cfg, p.Name, p.DefaultChannel)
+		if err != nil {
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, err.Error())
+			continue
+		}
+		head := chain[len(chain)-1]
+		if !sameVersion(buildVersion, versions[head]) {
+			check.Status = StatusFail
+			check.Messages = append(check.Messages, fmt.Sprintf("%s %s does not match %s channel head %s (%s)",
+				buildVersionArg, buildVersion, p.DefaultChannel, head, versions[head]))
+		}
+	}
+
+	return check
+}
+
+// checkChannelVersions checks that each channel's head is its
+// highest version and that entries are listed in increasing order.
+func checkChannelVersions(cfg *declcfg.DeclarativeConfig, versions map[string]string) []LintCheck {
+	head := LintCheck{Name: "channel-head", Status: StatusPass}
+	order := LintCheck{Name: "channel-order", Status: StatusPass}
+
+	for _, ch := range cfg.Channels {
+		var highest string
+		var highestVersion, previous semver.Version
+		for i, e := range ch.Entries {
+			v, err := semver.ParseTolerant(versions[e.Name])
+			if err != nil {
+				order.Status = StatusFail
+				order.Messages = append(order.Messages, fmt.Sprintf("%s: %s has no valid version", ch.Name, e.Name))
+				continue
+			}
+			if i > 0 && !v.GT(previous) {
+				order.Status = StatusFail
+				order.Messages = append(order.Messages, fmt.Sprintf("%s: %s (%s) is listed after %s", ch.Name, e.Name, v, previous))
+			}
+			previous = v
+			if highest == "" || v.GT(highestVersion) {
+				highest, highestVersion = e.Name, v
+			}
+		}
+
+		chain, err := catalog.ReplacesChain(cfg, ch.Package, ch.Name)
+		if err != nil {
+			head.Status = StatusFail
+			head.Messages = append(head.Messages, err.Error())
+			continue
+		}
+		if h := chain[len(chain)-1]; h != highest {
+			head.Status = StatusFail
+			head.Messages = append(head.Messages, fmt.Sprintf("%s: head %s (%s) is not the highest version %s (%s)",
+				ch.Name, h, versions[h], highest, highestVersion))
+		}
+	}
+
+	return []LintCheck{head, order}
+}
+
+// FormatVersionReport formats a version report according to the
+// specified format.
+func FormatVersionReport(report *VersionReport, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "text", "":
+		return formatVersionText(report), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: text, json)", format)
+	}
+}
+
+func formatVersionText(report *VersionReport) string {
+	var b strings.Builder
+
+	for _, t := range report.Templates {
+		b.WriteString(fmt.Sprintf("%s (%s=%s)\n", t.Template, buildVersionArg, orNone(t.BuildVersion)))
+		for _, bv := range t.Bundles {
+			name := bv.Name
+			if name == "" {
+				name = bv.Image
+			}
+			b.WriteString(fmt.Sprintf("  %s: comment %s, CSV %s, label %s\n", name,
+				orNone(bv.Comment), orNone(bv.CSVVersion), orNone(bv.LabelVersion)))
+		}
+		for _, c := range t.Checks {
+			b.WriteString(fmt.Sprintf("  %s %s\n", statusSymbol(c.Status), c.Name))
+			for _, msg := range c.Messages {
+				b.WriteString(fmt.Sprintf("      %s\n", msg))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fmt.Sprintf("Summary: %d templates checked, %d failed\n", len(report.Templates), report.Failures))
+
+	return b.String()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
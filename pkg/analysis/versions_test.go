@@ -0,0 +1,157 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+const testVersionsTemplate = `schema: olm.template.basic
+entries:
+  - schema: olm.package
+    name: bpfman-operator
+    defaultChannel: stable
+  - schema: olm.channel
+    package: bpfman-operator
+    name: stable
+    entries:
+      - name: bpfman-operator.v0.5.9
+      - name: bpfman-operator.v0.5.10
+        replaces: bpfman-operator.v0.5.9
+  - schema: olm.bundle          # 0.5.9
+    image: quay.io/bpfman/bpfman-operator-bundle@sha256:0000000000000000000000000000000000000000000000000000000000000009
+  - schema: olm.bundle          # %s
+    image: quay.io/bpfman/bpfman-operator-bundle@sha256:0000000000000000000000000000000000000000000000000000000000000010
+`
+
+const testVersionsRendered = `---
+defaultChannel: stable
+name: bpfman-operator
+schema: olm.package
+---
+entries:
+- name: bpfman-operator.v0.5.9
+- name: bpfman-operator.v0.5.10
+  replaces: bpfman-operator.v0.5.9
+name: stable
+package: bpfman-operator
+schema: olm.channel
+---
+image: quay.io/bpfman/bpfman-operator-bundle@sha256:0000000000000000000000000000000000000000000000000000000000000009
+name: bpfman-operator.v0.5.9
+package: bpfman-operator
+properties:
+- type: olm.package
+  value:
+    packageName: bpfman-operator
+    version: 0.5.9
+schema: olm.bundle
+---
+image: quay.io/bpfman/bpfman-operator-bundle@sha256:0000000000000000000000000000000000000000000000000000000000000010
+name: bpfman-operator.v0.5.10
+package: bpfman-operator
+properties:
+- type: olm.package
+  value:
+    packageName: bpfman-operator
+    version: 0.5.10
+schema: olm.bundle
+`
+
+// TestCheckTemplateVersions tests that disagreement between template
+// comments, CSV versions and BUILDVERSION is reported.
+func TestCheckTemplateVersions(t *testing.T) {
+	tests := []struct {
+		name         string
+		comment      string
+		buildVersion string
+		want         map[string]string // check name → status
+	}{
+		{
+			name:         "consistent",
+			comment:      "0.5.10",
+			buildVersion: "0.5.10",
+			want:         map[string]string{"bundle-versions": StatusPass, "build-version": StatusPass, "channel-head": StatusPass, "channel-order": StatusPass},
+		},
+		{
+			name:         "stale comment",
+			comment:      "0.5.8",
+			buildVersion: "0.5.10",
+			want:         map[string]string{"bundle-versions": StatusFail, "build-version": StatusPass},
+		},
+		{
+			name:         "stale build version",
+			comment:      "0.5.10",
+			buildVersion: "0.5.9",
+			want:         map[string]string{"bundle-versions": StatusPass, "build-version": StatusFail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			template := filepath.Join(dir, "templates", "z-stream.yaml")
+			writeTestFile(t, template, fmt.Sprintf(testVersionsTemplate, tt.comment))
+			writeTestFile(t, filepath.Join(dir, "templates", "z-stream.Dockerfile-args"), "BUILDVERSION="+tt.buildVersion+"\n")
+			writeTestFile(t, filepath.Join(dir, "rendered", "z-stream.yaml"), testVersionsRendered)
+
+			result, err := CheckTemplateVersions(context.Background(), template, VersionCheckConfig{
+				RenderedDir: filepath.Join(dir, "rendered"),
+				SkipLabels:  true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, c := range result.Checks {
+				got[c.Name] = c.Status
+			}
+			for name, status := range tt.want {
+				if got[name] != status {
+					t.Errorf("%s = %s, want %s (checks: %+v)", name, got[name], status, result.Checks)
+				}
+			}
+		})
+	}
+}
+
+// TestCheckChannelVersions tests the channel head and entry order
+// checks.
+func TestCheckChannelVersions(t *testing.T) {
+	cfg := decodeTestCatalog(t, testVersionsRendered)
+	cfg.Channels[0].Entries[0], cfg.Channels[0].Entries[1] = cfg.Channels[0].Entries[1], cfg.Channels[0].Entries[0]
+
+	versions := map[string]string{
+		"bpfman-operator.v0.5.9":  "0.5.9",
+		"bpfman-operator.v0.5.10": "0.5.10",
+	}
+	checks := checkChannelVersions(cfg, versions)
+	if checks[0].Status != StatusPass {
+		t.Errorf("channel-head = %s, want pass: %v", checks[0].Status, checks[0].Messages)
+	}
+	if checks[1].Status != StatusFail {
+		t.Errorf("channel-order = %s, want fail", checks[1].Status)
+	}
+
+	// Point the head at the older bundle.
+	cfg.Channels[0].Entries[0].Replaces = ""
+	cfg.Channels[0].Entries[1].Replaces = "bpfman-operator.v0.5.10"
+	checks = checkChannelVersions(cfg, versions)
+	if checks[0].Status != StatusFail {
+		t.Errorf("channel-head = %s, want fail", checks[0].Status)
+	}
+}
+
+func decodeTestCatalog(t *testing.T, s string) *declcfg.DeclarativeConfig {
+	t.Helper()
+	cfg, err := declcfg.LoadReader(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("loading catalog: %v", err)
+	}
+	return cfg
+}
@@ -0,0 +1,107 @@
+package bundle
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// DefaultOCPVersion is the OpenShift release whose operator registry
+// image the catalog Dockerfile is based on by default.
+const DefaultOCPVersion = "4.20"
+
+// defaultPackage is the catalog directory used when the package
+// cannot be read from the catalog.
+const defaultPackage = "bpfman-operator"
+
+// DockerfileOptions configures the generated catalog Dockerfile.
+type DockerfileOptions struct {
+	BaseImage  string // opm base image (default: the operator registry image for OCPVersion)
+	OCPVersion string // OpenShift release selecting the default base image (default: DefaultOCPVersion)
+	Commit     string // Source commit recorded in the upstream-vcs-ref label
+}
+
+// ResolvedBaseImage returns the base image the options select.
+func (o DockerfileOptions) ResolvedBaseImage() string {
+	if o.BaseImage != "" {
+		return o.BaseImage
+	}
+	version := o.OCPVersion
+	if version == "" {
+		version = DefaultOCPVersion
+	}
+	return fmt.Sprintf("registry.redhat.io/openshift4/ose-operator-registry-rhel9:v%s", strings.TrimPrefix(version, "v"))
+}
+
+// CatalogLabels returns the image labels of a catalog image, as set
+// by the repository Dockerfile for the given version and commit. Both
+// build-catalog-image and the generated Dockerfile take their labels
+// from here.
+func CatalogLabels(version, commit string) map[string]string {
+	return map[string]string{
+		"operators.operatorframework.io.index.configs.v1": "/configs",
+		"com.redhat.component":                            "bpfman-operator-catalog-container",
+		"name":                                            "bpfman-operator-catalog",
+		"io.k8s.display-name":                             "eBPF Manager Operator Catalog",
+		"io.k8s.description":                              "eBPF Manager Operator Catalog",
+		"summary":                                         "eBPF Manager Operator Catalog",
+		"maintainer":                                      "support@redhat.com",
+		"io.openshift.tags":                               "bpfman-operator-catalog",
+		"upstream-vcs-ref":                                commit,
+		"upstream-vcs-type":                               "git",
+		"description":                                     "eBPF Manager operator for OpenShift.",
+		"version":                                         version,
+	}
+}
+
+// Label is an image label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// SortedLabels returns labels sorted by name.
+func SortedLabels(labels map[string]string) []Label {
+	sorted := make([]Label, 0, len(labels))
+	for name, value := range labels {
+		sorted = append(sorted, Label{Name: name, Value: value})
+	}
+	slices.SortFunc(sorted, func(a, b Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return sorted
+}
+
+// CatalogBuildInfo returns the package of a rendered catalog and the
+// version of its default channel head, falling back to the default
+// package and no version when the catalog does not have exactly one
+// package.
+func CatalogBuildInfo(catalogYAML string) (string, string) {
+	if catalogYAML == "" {
+		return defaultPackage, ""
+	}
+	cfg, err := declcfg.LoadReader(strings.NewReader(catalogYAML))
+	if err != nil || len(cfg.Packages) != 1 {
+		return defaultPackage, ""
+	}
+
+	pkg := cfg.Packages[0]
+	channel, err := catalog.FindChannel(cfg, pkg.Name, pkg.DefaultChannel)
+	if err != nil {
+		return pkg.Name, ""
+	}
+	head, err := catalog.ChannelHead(channel)
+	if err != nil {
+		return pkg.Name, ""
+	}
+	for _, b := range cfg.Bundles {
+		if b.Package == pkg.Name && b.Name == head {
+			return pkg.Name, catalog.BundleVersion(b)
+		}
+	}
+	return pkg.Name, ""
+}
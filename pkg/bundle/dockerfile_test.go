@@ -0,0 +1,141 @@
+package bundle
+
+import (
+	"maps"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGenerateCatalogDockerfile tests the base image selection and
+// the build arguments read from the catalog.
+func TestGenerateCatalogDockerfile(t *testing.T) {
+	catalogYAML := `---
+schema: olm.package
+name: bpfman-operator
+defaultChannel: stable
+---
+schema: olm.channel
+package: bpfman-operator
+name: stable
+entries:
+  - name: bpfman-operator.v0.5.8
+  - name: bpfman-operator.v0.5.9
+    replaces: bpfman-operator.v0.5.8
+---
+schema: olm.bundle
+name: bpfman-operator.v0.5.9
+package: bpfman-operator
+image: quay.io/bpfman/bundle:v0.5.9
+properties:
+  - type: olm.package
+    value:
+      packageName: bpfman-operator
+      version: 0.5.9
+`
+
+	tests := []struct {
+		name        string
+		catalogYAML string
+		opts        DockerfileOptions
+		want        []string
+	}{
+		{
+			name:        "defaults",
+			catalogYAML: catalogYAML,
+			want: []string{
+				"ARG BASE_IMAGE=registry.redhat.io/openshift4/ose-operator-registry-rhel9:v4.20\n",
+				"ARG BUILDVERSION=0.5.9\n",
+				"COPY catalog.yaml /configs/bpfman-operator/index.yaml\n",
+			},
+		},
+		{
+			name:        "OpenShift version and commit",
+			catalogYAML: catalogYAML,
+			opts:        DockerfileOptions{OCPVersion: "v4.18", Commit: "0123abc"},
+			want:        []string{"ose-operator-registry-rhel9:v4.18\n", "ARG COMMIT=0123abc\n"},
+		},
+		{
+			name: "base image and unrendered catalog",
+			opts: DockerfileOptions{BaseImage: "quay.io/operator-framework/opm:v1.60.0"},
+			want: []string{"ARG BASE_IMAGE=quay.io/operator-framework/opm:v1.60.0\n", "ARG BUILDVERSION=\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultTemplates().GenerateCatalogDockerfile(NewCatalogData(nil, tt.catalogYAML), tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Dockerfile missing %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestCatalogDockerfileMatchesRepo tests that the generated
+// Dockerfile serves, caches and labels the catalog as the
+// repository's Dockerfile does.
+func TestCatalogDockerfileMatchesRepo(t *testing.T) {
+	repo, err := os.ReadFile("../../Dockerfile")
+	if err != nil {
+		t.Fatalf("reading repository Dockerfile: %v", err)
+	}
+	got, err := DefaultTemplates().GenerateCatalogDockerfile(NewCatalogData(nil, ""), DockerfileOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, line := range strings.Split(string(repo), "\n") {
+		for _, instruction := range []string{"FROM ", "ENTRYPOINT ", "CMD ", "RUN "} {
+			if strings.HasPrefix(line, instruction) && !strings.Contains(got, line+"\n") {
+				t.Errorf("generated Dockerfile missing %q", line)
+			}
+		}
+	}
+
+	repoLabels, gotLabels := dockerfileLabels(string(repo)), dockerfileLabels(got)
+	if !maps.Equal(gotLabels, repoLabels) {
+		t.Errorf("got labels %v, want the repository's %v", gotLabels, repoLabels)
+	}
+}
+
+// TestCatalogLabelsMatchRepo tests that the labels of in-process
+// builds are those the repository Dockerfile sets.
+func TestCatalogLabelsMatchRepo(t *testing.T) {
+	repo, err := os.ReadFile("../../Dockerfile")
+	if err != nil {
+		t.Fatalf("reading repository Dockerfile: %v", err)
+	}
+	got := CatalogLabels("0.5.9", "0123abc")
+
+	labels := dockerfileLabels(string(repo))
+	for key, value := range labels {
+		value = strings.NewReplacer("$COMMIT", "0123abc", "$BUILDVERSION", "0.5.9").Replace(value)
+		if got[key] != value {
+			t.Errorf("label %s: got %q, want %q", key, got[key], value)
+		}
+	}
+	if len(got) != len(labels) {
+		t.Errorf("got %d labels, want the repository's %d", len(got), len(labels))
+	}
+}
+
+// dockerfileLabels returns the labels set by a Dockerfile's LABEL
+// instructions, with their quotes removed.
+func dockerfileLabels(dockerfile string) map[string]string {
+	labels := make(map[string]string)
+	for _, line := range strings.Split(dockerfile, "\n") {
+		label, ok := strings.CutPrefix(line, "LABEL ")
+		if !ok {
+			continue
+		}
+		key, value, _ := strings.Cut(label, "=")
+		labels[key] = strings.Trim(value, `"`)
+	}
+	return labels
+}
@@ -4,217 +4,443 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
-	"text/template"
 
+	"github.com/blang/semver/v4"
 	"github.com/google/uuid"
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
 
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/alpha/action/migrations"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
-	"github.com/operator-framework/operator-registry/alpha/template/basic"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 )
 
-//go:embed templates/Makefile.tmpl
-var makefileTemplate string
-
-//go:embed templates/WORKFLOW.txt.tmpl
-var workflowTemplate string
-
 // GenerateImageUUIDAndTTL generates a double UUID and random TTL for
-// ttl.sh examples.
-func GenerateImageUUIDAndTTL() (string, string) {
-	imageUUID := fmt.Sprintf("%s-%s", uuid.New().String(), uuid.New().String())
-	randomTTL := generateRandomTTL()
+// ttl.sh examples, drawing from rng.
+func GenerateImageUUIDAndTTL(rng *rand.Rand) (string, string) {
+	imageUUID := fmt.Sprintf("%s-%s", newUUID(rng), newUUID(rng))
+	randomTTL := generateRandomTTL(rng)
 	return imageUUID, randomTTL
 }
 
+// newUUID returns a random UUID drawn from rng.
+func newUUID(rng *rand.Rand) string {
+	id, err := uuid.NewRandomFromReader(rng)
+	if err != nil {
+		return uuid.New().String()
+	}
+	return id.String()
+}
+
 // generateRandomTTL generates a random TTL between 15m and 30m for
 // ttl.sh.
-func generateRandomTTL() string {
+func generateRandomTTL(rng *rand.Rand) string {
 	minMinutes := 15
 	maxMinutes := 30
-	randomMinutes := rand.Intn(maxMinutes-minMinutes+1) + minMinutes
+	randomMinutes := rng.Intn(maxMinutes-minMinutes+1) + minMinutes
 	return fmt.Sprintf("%dm", randomMinutes)
 }
 
 // BundleInfo contains extracted bundle metadata.
 type BundleInfo struct {
-	Name    string
-	Package string
+	Image       string
+	Name        string
+	Package     string
+	Version     string
+	Icon        *declcfg.Icon // First spec.icon of the CSV
+	Description string        // CSV spec.description
+}
+
+// Template types GenerateFBCTemplate can produce.
+const (
+	TemplateTypeBasic  = "basic"
+	TemplateTypeSemver = "semver"
+)
+
+// FBCOptions controls the package and channel written into a
+// generated FBC template.
+type FBCOptions struct {
+	TemplateType   string   // basic (default) or semver
+	PackageFrom    string   // Template, catalog or image to copy the olm.package icon and description from; basic only
+	Channels       []string // Basic channels (default "preview") or semver archetypes (default "candidate")
+	DefaultChannel string   // Package default channel (default: the first channel); basic only
+	SkipRange      bool     // Add a skipRange from the oldest bundle to each later entry; basic only
+
+	// GenerateMajorChannels and GenerateMinorChannels select the
+	// channels opm generates from a semver template. opm generates
+	// minor channels when neither is set.
+	GenerateMajorChannels bool
+	GenerateMinorChannels bool
+}
+
+// CatalogTemplate is a generated catalog template, either an
+// *FBCTemplate or a *catalog.SemverTemplate.
+type CatalogTemplate interface {
+	// BundleImages returns the bundle images in the template,
+	// oldest first.
+	BundleImages() []string
+}
+
+// templateType returns the opm render-template subcommand for a
+// template.
+func templateType(t CatalogTemplate) string {
+	if _, ok := t.(*catalog.SemverTemplate); ok {
+		return TemplateTypeSemver
+	}
+	return TemplateTypeBasic
+}
+
+// validate checks the options before any bundle is pulled.
+func (o FBCOptions) validate() error {
+	switch o.TemplateType {
+	case "", TemplateTypeBasic:
+		if o.GenerateMajorChannels || o.GenerateMinorChannels {
+			return fmt.Errorf("major and minor channel generation requires a semver template")
+		}
+		_, _, err := o.resolveChannels()
+		return err
+	case TemplateTypeSemver:
+		if o.DefaultChannel != "" {
+			return fmt.Errorf("a semver template cannot set the default channel; opm selects the most stable channel")
+		}
+		if o.SkipRange {
+			return fmt.Errorf("a semver template cannot set skipRange; opm generates the upgrade edges")
+		}
+		if o.PackageFrom != "" {
+			return fmt.Errorf("a semver template has no olm.package entry to copy package metadata into")
+		}
+		for _, channel := range o.Channels {
+			if !slices.Contains(catalog.SemverArchetypes, channel) {
+				return fmt.Errorf("unknown semver channel %q (supported: %s)", channel, strings.Join(catalog.SemverArchetypes, ", "))
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported template type: %s (supported: %s, %s)", o.TemplateType, TemplateTypeBasic, TemplateTypeSemver)
+	}
+}
+
+// resolveChannels applies the channel defaults and checks that the
+// default channel is one of the generated channels.
+func (o FBCOptions) resolveChannels() ([]string, string, error) {
+	channels := o.Channels
+	if len(channels) == 0 {
+		channels = []string{"preview"}
+	}
+	for i, channel := range channels {
+		if channel == "" {
+			return nil, "", fmt.Errorf("channel name cannot be empty")
+		}
+		if slices.Contains(channels[:i], channel) {
+			return nil, "", fmt.Errorf("channel %q given more than once", channel)
+		}
+	}
+
+	defaultChannel := o.DefaultChannel
+	if defaultChannel == "" {
+		defaultChannel = channels[0]
+	}
+	if !slices.Contains(channels, defaultChannel) {
+		return nil, "", fmt.Errorf("default channel %q is not one of the generated channels (%s)", defaultChannel, strings.Join(channels, ", "))
+	}
+	return channels, defaultChannel, nil
 }
 
 // FBCTemplate represents a File-Based Catalog template.
 type FBCTemplate struct {
-	Schema  string `yaml:"schema"`
-	Entries []any  `yaml:"entries"`
+	Schema  string `json:"schema" yaml:"schema"`
+	Entries []any  `json:"entries" yaml:"entries"`
 }
 
 // PackageEntry defines an OLM package.
 type PackageEntry struct {
-	Schema         string `yaml:"schema"`
-	Name           string `yaml:"name"`
-	DefaultChannel string `yaml:"defaultChannel"`
+	Schema         string        `json:"schema" yaml:"schema"`
+	Name           string        `json:"name" yaml:"name"`
+	DefaultChannel string        `json:"defaultChannel" yaml:"defaultChannel"`
+	Icon           *declcfg.Icon `json:"icon,omitempty" yaml:"icon,omitempty"`
+	Description    string        `json:"description,omitempty" yaml:"description,omitempty"`
 }
 
 // ChannelEntry defines an OLM channel.
 type ChannelEntry struct {
-	Schema  string             `yaml:"schema"`
-	Package string             `yaml:"package"`
-	Name    string             `yaml:"name"`
-	Entries []ChannelEntryItem `yaml:"entries"`
+	Schema  string             `json:"schema" yaml:"schema"`
+	Package string             `json:"package" yaml:"package"`
+	Name    string             `json:"name" yaml:"name"`
+	Entries []ChannelEntryItem `json:"entries" yaml:"entries"`
 }
 
 // ChannelEntryItem represents an entry in a channel.
 type ChannelEntryItem struct {
-	Name     string `yaml:"name"`
-	Replaces string `yaml:"replaces,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Replaces  string `json:"replaces,omitempty" yaml:"replaces,omitempty"`
+	SkipRange string `json:"skipRange,omitempty" yaml:"skipRange,omitempty"`
 }
 
 // BundleEntry defines an OLM bundle.
 type BundleEntry struct {
-	Schema string `yaml:"schema"`
-	Image  string `yaml:"image"`
-	Name   string `yaml:"name"`
+	Schema string `json:"schema" yaml:"schema"`
+	Image  string `json:"image" yaml:"image"`
+	Name   string `json:"name" yaml:"name"`
 }
 
-// GenerateFBCTemplate generates an FBC template for a bundle image.
-func GenerateFBCTemplate(bundleImage string, channel string) (*FBCTemplate, error) {
-	if bundleImage == "" {
-		return nil, fmt.Errorf("bundle image cannot be empty")
+// GenerateFBCTemplate generates a basic or semver catalog template
+// for one or more bundle images of the same package. The bundles are
+// ordered by CSV version; in a basic template each one replaces its
+// predecessor in the channel.
+func GenerateFBCTemplate(bundleImages []string, opts FBCOptions) (CatalogTemplate, error) {
+	if len(bundleImages) == 0 {
+		return nil, fmt.Errorf("at least one bundle image is required")
 	}
-
-	if channel == "" {
-		channel = "preview"
+	if err := opts.validate(); err != nil {
+		return nil, err
 	}
 
-	bundleInfo, err := extractBundleInfo(bundleImage)
-	if err != nil {
-		return nil, fmt.Errorf("extracting bundle info: %w", err)
+	var packages []declcfg.Package
+	if opts.PackageFrom != "" {
+		var err error
+		packages, err = catalog.LoadPackages(context.Background(), opts.PackageFrom)
+		if err != nil {
+			return nil, fmt.Errorf("loading package metadata: %w", err)
+		}
 	}
 
-	bundleName := bundleInfo.Name
-	packageName := bundleInfo.Package
+	bundles := make([]BundleInfo, 0, len(bundleImages))
+	for _, image := range bundleImages {
+		if image == "" {
+			return nil, fmt.Errorf("bundle image cannot be empty")
+		}
+		info, err := extractBundleInfo(image)
+		if err != nil {
+			return nil, fmt.Errorf("extracting bundle info for %s: %w", image, err)
+		}
+		bundles = append(bundles, *info)
+	}
 
-	template := &FBCTemplate{
-		Schema: "olm.template.basic",
-		Entries: []any{
-			PackageEntry{
-				Schema:         "olm.package",
-				Name:           packageName,
-				DefaultChannel: channel,
-			},
-			ChannelEntry{
-				Schema:  "olm.channel",
-				Package: packageName,
-				Name:    channel,
-				Entries: []ChannelEntryItem{
-					{Name: bundleName},
-				},
-			},
-			BundleEntry{
-				Schema: "olm.bundle",
-				Image:  bundleImage,
-				Name:   bundleName,
-			},
-		},
+	if opts.TemplateType == TemplateTypeSemver {
+		return BuildSemverTemplate(bundles, opts)
 	}
 
+	template, err := BuildFBCTemplate(bundles, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PackageFrom != "" {
+		if err := template.CopyPackageMetadata(packages); err != nil {
+			return nil, fmt.Errorf("copying package metadata from %s: %w", opts.PackageFrom, err)
+		}
+	}
 	return template, nil
 }
 
-// RenderCatalog uses the OPM library to render the FBC template into
-// a full catalog.
-func RenderCatalog(ctx context.Context, fbcTemplate *FBCTemplate) (string, error) {
-	templateYAML, err := yaml.Marshal(fbcTemplate)
-	if err != nil {
-		return "", fmt.Errorf("marshaling FBC template: %w", err)
+// BuildFBCTemplate builds an FBC template from already extracted
+// bundle metadata. Bundles are sorted by version, oldest first, and
+// chained with replaces edges so the newest bundle is the head of
+// every channel.
+func BuildFBCTemplate(bundles []BundleInfo, opts FBCOptions) (*FBCTemplate, error) {
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("at least one bundle is required")
 	}
 
-	logrus.SetLevel(logrus.WarnLevel)
-
-	logger := logrus.NewEntry(logrus.New())
-	logger.Logger.SetLevel(logrus.WarnLevel)
+	channels, defaultChannel, err := opts.resolveChannels()
+	if err != nil {
+		return nil, err
+	}
 
-	registry, err := execregistry.NewRegistry(containertools.PodmanTool, logger)
+	sorted, err := SortBundles(bundles)
 	if err != nil {
-		return "", fmt.Errorf("creating image registry: %w", err)
+		return nil, err
 	}
 
-	template := basic.Template{
-		RenderBundle: func(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error) {
-			migs, err := migrations.NewMigrations("bundle-object-to-csv-metadata")
-			if err != nil {
-				return nil, fmt.Errorf("creating migrations: %w", err)
-			}
+	packageName := sorted[0].Package
+	entries := make([]ChannelEntryItem, 0, len(sorted))
+	bundleEntries := make([]any, 0, len(sorted))
+	for i, b := range sorted {
+		if b.Package != packageName {
+			return nil, fmt.Errorf("bundle %s belongs to package %q, expected %q", b.Name, b.Package, packageName)
+		}
 
-			r := action.Render{
-				Refs:           []string{image},
-				Registry:       registry,
-				AllowedRefMask: action.RefBundleImage,
-				Migrations:     migs,
+		entry := ChannelEntryItem{Name: b.Name}
+		if i > 0 {
+			entry.Replaces = sorted[i-1].Name
+			if opts.SkipRange {
+				entry.SkipRange = fmt.Sprintf(">=%s <%s", sorted[0].Version, b.Version)
 			}
-			return r.Run(ctx)
+		}
+		entries = append(entries, entry)
+
+		bundleEntries = append(bundleEntries, BundleEntry{
+			Schema: "olm.bundle",
+			Image:  b.Image,
+			Name:   b.Name,
+		})
+	}
+
+	// The package is described by its newest bundle, as the console
+	// shows the channel head.
+	head := sorted[len(sorted)-1]
+	templateEntries := []any{
+		PackageEntry{
+			Schema:         "olm.package",
+			Name:           packageName,
+			DefaultChannel: defaultChannel,
+			Icon:           head.Icon,
+			Description:    head.Description,
 		},
 	}
+	for _, channel := range channels {
+		templateEntries = append(templateEntries, ChannelEntry{
+			Schema:  "olm.channel",
+			Package: packageName,
+			Name:    channel,
+			Entries: entries,
+		})
+	}
+
+	template := &FBCTemplate{
+		Schema:  "olm.template.basic",
+		Entries: append(templateEntries, bundleEntries...),
+	}
+
+	return template, nil
+}
+
+// CopyPackageMetadata replaces the icon and description of the
+// template's package with those of the matching olm.package entry, so
+// a development catalog looks like the released one.
+func (t *FBCTemplate) CopyPackageMetadata(packages []declcfg.Package) error {
+	for i, entry := range t.Entries {
+		pkg, ok := entry.(PackageEntry)
+		if !ok {
+			continue
+		}
+		for _, ref := range packages {
+			if ref.Name != pkg.Name {
+				continue
+			}
+			pkg.Icon = ref.Icon
+			pkg.Description = ref.Description
+			t.Entries[i] = pkg
+			return nil
+		}
+		return fmt.Errorf("no olm.package named %q", pkg.Name)
+	}
+	return fmt.Errorf("template has no olm.package entry")
+}
 
-	reader := bytes.NewReader(templateYAML)
-	cfg, err := template.Render(ctx, reader)
+// BuildSemverTemplate builds a semver template from already
+// extracted bundle metadata, listing the bundles oldest first in each
+// requested channel archetype.
+func BuildSemverTemplate(bundles []BundleInfo, opts FBCOptions) (*catalog.SemverTemplate, error) {
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("at least one bundle is required")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	sorted, err := SortBundles(bundles)
 	if err != nil {
-		return "", fmt.Errorf("rendering template: %w", err)
+		return nil, err
 	}
 
-	var buf bytes.Buffer
-	if err := declcfg.WriteYAML(*cfg, &buf); err != nil {
-		return "", fmt.Errorf("writing catalog YAML: %w", err)
+	channels := opts.Channels
+	if len(channels) == 0 {
+		channels = []string{catalog.SemverCandidate}
 	}
 
-	return buf.String(), nil
+	template := &catalog.SemverTemplate{
+		Schema:                catalog.SchemaSemverTemplate,
+		GenerateMajorChannels: opts.GenerateMajorChannels,
+		GenerateMinorChannels: opts.GenerateMinorChannels,
+	}
+	for _, b := range sorted {
+		if b.Package != sorted[0].Package {
+			return nil, fmt.Errorf("bundle %s belongs to package %q, expected %q", b.Name, b.Package, sorted[0].Package)
+		}
+		for _, channel := range channels {
+			if err := template.AddBundle(channel, b.Image); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return template, nil
 }
 
-// GenerateCatalogDockerfile generates a Dockerfile for building a
-// catalog image.
-func GenerateCatalogDockerfile() string {
-	return `# Catalog Dockerfile for bpfman-operator
-# Generated by bpfman-catalog tool
-#
-# Build with:
-#   podman build -f Dockerfile -t bpfman-catalog:dev .
-#
-# Push to a registry:
-#   podman push bpfman-catalog:dev ttl.sh/bpfman-catalog:dev
+// SortBundles returns the bundles ordered by CSV version, oldest
+// first. Bundles without a parseable version, or sharing a version,
+// are rejected since they cannot be placed in a replaces chain.
+func SortBundles(bundles []BundleInfo) ([]BundleInfo, error) {
+	versions := make(map[string]semver.Version, len(bundles))
+	for _, b := range bundles {
+		v, err := semver.ParseTolerant(b.Version)
+		if err != nil {
+			return nil, fmt.Errorf("parsing version %q of bundle %s: %w", b.Version, b.Name, err)
+		}
+		for name, other := range versions {
+			if name != b.Name && other.EQ(v) {
+				return nil, fmt.Errorf("bundles %s and %s have the same version %s", name, b.Name, v)
+			}
+		}
+		if _, ok := versions[b.Name]; ok {
+			return nil, fmt.Errorf("bundle %s given more than once", b.Name)
+		}
+		versions[b.Name] = v
+	}
 
-FROM registry.redhat.io/openshift4/ose-operator-registry-rhel9:v4.20 AS builder
+	sorted := slices.Clone(bundles)
+	slices.SortFunc(sorted, func(a, b BundleInfo) int {
+		return versions[a.Name].Compare(versions[b.Name])
+	})
+	return sorted, nil
+}
 
-# Copy the rendered catalog
-COPY catalog.yaml /configs/catalog.yaml
+// BundleImages returns the bundle images in the template, in channel
+// order.
+func (t *FBCTemplate) BundleImages() []string {
+	var images []string
+	for _, entry := range t.Entries {
+		if b, ok := entry.(BundleEntry); ok {
+			images = append(images, b.Image)
+		}
+	}
+	return images
+}
 
-# Validate the catalog
-RUN opm validate /configs
+// RenderCatalog uses the OPM library to render the catalog template
+// into a full catalog.
+func RenderCatalog(ctx context.Context, tmpl CatalogTemplate) (string, error) {
+	templateYAML, err := yaml.Marshal(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("marshaling FBC template: %w", err)
+	}
 
-# Final stage - minimal image with just the catalog
-FROM registry.redhat.io/openshift4/ose-operator-registry-rhel9:v4.20
+	logrus.SetLevel(logrus.WarnLevel)
 
-# Copy validated catalog from builder
-COPY --from=builder /configs /configs
+	cfg, err := catalog.RenderTemplateData(ctx, templateYAML)
+	if err != nil {
+		return "", fmt.Errorf("rendering template: %w", err)
+	}
 
-# Label for OpenShift
-LABEL io.openshift.release.operator=true
+	var buf bytes.Buffer
+	if err := declcfg.WriteYAML(*cfg, &buf); err != nil {
+		return "", fmt.Errorf("writing catalog YAML: %w", err)
+	}
 
-# Serve the catalog
-ENTRYPOINT ["/bin/opm"]
-CMD ["serve", "/configs"]
-`
+	return buf.String(), nil
 }
 
 // extractDigestSuffix extracts the first 8 characters of a digest
@@ -242,7 +468,7 @@ func extractBundleInfo(bundleImage string) (*BundleInfo, error) {
 		return nil, fmt.Errorf("creating image registry: %w", err)
 	}
 
-	migs, err := migrations.NewMigrations("bundle-object-to-csv-metadata")
+	migs, err := migrations.NewMigrations(catalog.MigrationLevel)
 	if err != nil {
 		return nil, fmt.Errorf("creating migrations: %w", err)
 	}
@@ -261,9 +487,17 @@ func extractBundleInfo(bundleImage string) (*BundleInfo, error) {
 
 	for _, bundle := range cfg.Bundles {
 		if bundle.Image == bundleImage {
+			icon, description, err := csvPackageMetadata(bundle.CsvJSON)
+			if err != nil {
+				return nil, fmt.Errorf("reading CSV of %s: %w", bundle.Name, err)
+			}
 			return &BundleInfo{
-				Name:    bundle.Name,
-				Package: bundle.Package,
+				Image:       bundleImage,
+				Name:        bundle.Name,
+				Package:     bundle.Package,
+				Version:     catalog.BundleVersion(bundle),
+				Icon:        icon,
+				Description: description,
 			}, nil
 		}
 	}
@@ -271,10 +505,33 @@ func extractBundleInfo(bundleImage string) (*BundleInfo, error) {
 	return nil, fmt.Errorf("bundle not found in rendered config")
 }
 
+// csvPackageMetadata returns the first icon and the description of
+// a CSV, for use in the olm.package entry.
+func csvPackageMetadata(csvJSON string) (*declcfg.Icon, string, error) {
+	if csvJSON == "" {
+		return nil, "", nil
+	}
+
+	var csv v1alpha1.ClusterServiceVersion
+	if err := json.Unmarshal([]byte(csvJSON), &csv); err != nil {
+		return nil, "", fmt.Errorf("parsing CSV: %w", err)
+	}
+
+	var icon *declcfg.Icon
+	if len(csv.Spec.Icon) > 0 && csv.Spec.Icon[0].Data != "" {
+		data, err := base64.StdEncoding.DecodeString(csv.Spec.Icon[0].Data)
+		if err != nil {
+			return nil, "", fmt.Errorf("decoding icon: %w", err)
+		}
+		icon = &declcfg.Icon{Data: data, MediaType: csv.Spec.Icon[0].MediaType}
+	}
+	return icon, csv.Spec.Description, nil
+}
+
 // RenderCatalogWithBinary uses an external opm binary to render the
-// FBC template into a full catalog.
-func RenderCatalogWithBinary(ctx context.Context, fbcTemplate *FBCTemplate, ompBinPath string) (string, error) {
-	templateYAML, err := yaml.Marshal(fbcTemplate)
+// catalog template into a full catalog.
+func RenderCatalogWithBinary(ctx context.Context, tmpl CatalogTemplate, ompBinPath string) (string, error) {
+	templateYAML, err := yaml.Marshal(tmpl)
 	if err != nil {
 		return "", fmt.Errorf("marshaling FBC template: %w", err)
 	}
@@ -290,8 +547,8 @@ func RenderCatalogWithBinary(ctx context.Context, fbcTemplate *FBCTemplate, ompB
 		return "", fmt.Errorf("writing template file: %w", err)
 	}
 
-	cmd := exec.CommandContext(ctx, ompBinPath, "alpha", "render-template", "basic",
-		"--migrate-level=bundle-object-to-csv-metadata",
+	cmd := exec.CommandContext(ctx, ompBinPath, "alpha", "render-template", templateType(tmpl),
+		"--migrate-level="+catalog.MigrationLevel,
 		"-o", "yaml",
 		templateFile)
 	cmd.Dir = tempDir
@@ -319,74 +576,3 @@ func getUsernameOrDefault() string {
 	}
 	return "$(USER)"
 }
-
-// GenerateMakefile generates a Makefile for building and deploying
-// the catalog.
-func GenerateMakefile(bundleImage, binaryPath, imageUUID, randomTTL string) string {
-	digestSuffix := extractDigestSuffix(bundleImage)
-
-	localTag := "bpfman-catalog"
-	if digestSuffix != "" {
-		localTag = fmt.Sprintf("bpfman-catalog-sha-%s", digestSuffix)
-	}
-
-	tmpl, err := template.New("makefile").Parse(makefileTemplate)
-	if err != nil {
-		return fmt.Sprintf("# Error parsing Makefile template: %v\n# Bundle: %s\n", err, bundleImage)
-	}
-
-	data := struct {
-		BundleImage string
-		LocalTag    string
-		BinaryPath  string
-		ImageUUID   string
-		RandomTTL   string
-		Username    string
-	}{
-		BundleImage: bundleImage,
-		LocalTag:    localTag,
-		BinaryPath:  binaryPath,
-		ImageUUID:   imageUUID,
-		RandomTTL:   randomTTL,
-		Username:    getUsernameOrDefault(),
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Sprintf("# Error executing Makefile template: %v\n# Bundle: %s\n", err, bundleImage)
-	}
-
-	return buf.String()
-}
-
-// GenerateWorkflow generates a WORKFLOW.txt file with deployment
-// instructions.
-func GenerateWorkflow(bundleCount int, catalogRendered bool, outputDir, imageUUID, randomTTL string) string {
-	tmpl, err := template.New("workflow").Parse(workflowTemplate)
-	if err != nil {
-		return fmt.Sprintf("# Error parsing WORKFLOW template: %v\n", err)
-	}
-
-	data := struct {
-		BundleCount     int
-		CatalogRendered bool
-		ImageUUID       string
-		RandomTTL       string
-		OutputDir       string
-		Username        string
-	}{
-		BundleCount:     bundleCount,
-		CatalogRendered: catalogRendered,
-		ImageUUID:       imageUUID,
-		RandomTTL:       randomTTL,
-		OutputDir:       outputDir,
-		Username:        getUsernameOrDefault(),
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Sprintf("# Error executing WORKFLOW template: %v\n", err)
-	}
-
-	return buf.String()
-}
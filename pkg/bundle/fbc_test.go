@@ -0,0 +1,276 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift/bpfman-catalog/pkg/provenance"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"sigs.k8s.io/yaml"
+)
+
+// TestBuildFBCTemplate tests that bundles are ordered by version and
+// chained with replaces edges in every channel.
+func TestBuildFBCTemplate(t *testing.T) {
+	bundles := []BundleInfo{
+		{Image: "quay.io/bpfman/bundle:v0.5.10", Name: "bpfman-operator.v0.5.10", Package: "bpfman-operator", Version: "0.5.10"},
+		{Image: "quay.io/bpfman/bundle:v0.5.8", Name: "bpfman-operator.v0.5.8", Package: "bpfman-operator", Version: "0.5.8"},
+		{Image: "quay.io/bpfman/bundle:v0.5.9", Name: "bpfman-operator.v0.5.9", Package: "bpfman-operator", Version: "0.5.9"},
+	}
+
+	tests := []struct {
+		name        string
+		bundles     []BundleInfo
+		opts        FBCOptions
+		wantDefault string
+		wantEntries []ChannelEntryItem
+		wantErr     string
+	}{
+		{
+			name:        "single bundle defaults to preview",
+			bundles:     bundles[:1],
+			wantDefault: "preview",
+			wantEntries: []ChannelEntryItem{{Name: "bpfman-operator.v0.5.10"}},
+		},
+		{
+			name:        "bundles ordered by version",
+			bundles:     bundles,
+			opts:        FBCOptions{Channels: []string{"stable"}},
+			wantDefault: "stable",
+			wantEntries: []ChannelEntryItem{
+				{Name: "bpfman-operator.v0.5.8"},
+				{Name: "bpfman-operator.v0.5.9", Replaces: "bpfman-operator.v0.5.8"},
+				{Name: "bpfman-operator.v0.5.10", Replaces: "bpfman-operator.v0.5.9"},
+			},
+		},
+		{
+			name:        "skip range and explicit default channel",
+			bundles:     bundles,
+			opts:        FBCOptions{Channels: []string{"candidate", "stable"}, DefaultChannel: "stable", SkipRange: true},
+			wantDefault: "stable",
+			wantEntries: []ChannelEntryItem{
+				{Name: "bpfman-operator.v0.5.8"},
+				{Name: "bpfman-operator.v0.5.9", Replaces: "bpfman-operator.v0.5.8", SkipRange: ">=0.5.8 <0.5.9"},
+				{Name: "bpfman-operator.v0.5.10", Replaces: "bpfman-operator.v0.5.9", SkipRange: ">=0.5.8 <0.5.10"},
+			},
+		},
+		{
+			name:    "default channel not generated",
+			bundles: bundles,
+			opts:    FBCOptions{Channels: []string{"candidate"}, DefaultChannel: "stable"},
+			wantErr: `default channel "stable" is not one of the generated channels`,
+		},
+		{
+			name:    "duplicate version",
+			bundles: append([]BundleInfo{{Image: "quay.io/bpfman/bundle:dup", Name: "bpfman-operator.v0.5.8-dup", Package: "bpfman-operator", Version: "0.5.8"}}, bundles...),
+			wantErr: "have the same version 0.5.8",
+		},
+		{
+			name:    "mixed packages",
+			bundles: append([]BundleInfo{{Image: "quay.io/other/bundle:v1.0.0", Name: "other.v1.0.0", Package: "other", Version: "1.0.0"}}, bundles...),
+			wantErr: `belongs to package "other"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := BuildFBCTemplate(tt.bundles, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var channels int
+			for _, entry := range tmpl.Entries {
+				switch e := entry.(type) {
+				case PackageEntry:
+					if e.DefaultChannel != tt.wantDefault {
+						t.Errorf("default channel: got %q, want %q", e.DefaultChannel, tt.wantDefault)
+					}
+				case ChannelEntry:
+					channels++
+					if len(e.Entries) != len(tt.wantEntries) {
+						t.Fatalf("channel %s: got %d entries, want %d", e.Name, len(e.Entries), len(tt.wantEntries))
+					}
+					for i, want := range tt.wantEntries {
+						if e.Entries[i] != want {
+							t.Errorf("channel %s entry %d: got %+v, want %+v", e.Name, i, e.Entries[i], want)
+						}
+					}
+				}
+			}
+			if want := max(len(tt.opts.Channels), 1); channels != want {
+				t.Errorf("got %d channels, want %d", channels, want)
+			}
+
+			images := tmpl.BundleImages()
+			if len(images) != len(tt.wantEntries) || !strings.HasSuffix(images[len(images)-1], "v0.5.10") {
+				t.Errorf("bundle images not in version order: %v", images)
+			}
+		})
+	}
+}
+
+// TestBuildSemverTemplate tests that bundles are listed oldest first
+// in each archetype and that basic-only options are rejected.
+func TestBuildSemverTemplate(t *testing.T) {
+	bundles := []BundleInfo{
+		{Image: "quay.io/bpfman/bundle:v0.6.0", Name: "bpfman-operator.v0.6.0", Package: "bpfman-operator", Version: "0.6.0"},
+		{Image: "quay.io/bpfman/bundle:v0.5.8", Name: "bpfman-operator.v0.5.8", Package: "bpfman-operator", Version: "0.5.8"},
+	}
+
+	tmpl, err := BuildSemverTemplate(bundles, FBCOptions{TemplateType: TemplateTypeSemver, Channels: []string{"fast", "stable"}, GenerateMajorChannels: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tmpl.Candidate != nil || tmpl.Fast == nil || tmpl.Stable == nil || !tmpl.GenerateMajorChannels {
+		t.Fatalf("unexpected template: %+v", tmpl)
+	}
+	want := []string{"quay.io/bpfman/bundle:v0.5.8", "quay.io/bpfman/bundle:v0.6.0"}
+	if got := tmpl.BundleImages(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("bundle images: got %v, want %v", got, want)
+	}
+
+	for _, opts := range []FBCOptions{
+		{TemplateType: TemplateTypeSemver, Channels: []string{"preview"}},
+		{TemplateType: TemplateTypeSemver, DefaultChannel: "stable"},
+		{TemplateType: TemplateTypeSemver, SkipRange: true},
+		{TemplateType: TemplateTypeBasic, GenerateMinorChannels: true},
+	} {
+		// The options are rejected before any bundle is pulled.
+		_, err := GenerateFBCTemplate([]string{"quay.io/bpfman/bundle:v0.5.8"}, opts)
+		if err == nil || strings.Contains(err.Error(), "extracting bundle info") {
+			t.Errorf("options %+v: got error %v, want an options error", opts, err)
+		}
+	}
+}
+
+// TestFBCTemplateYAML tests that the template is written with the
+// lowercase keys opm documents and without empty optional fields.
+func TestFBCTemplateYAML(t *testing.T) {
+	tmpl, err := BuildFBCTemplate([]BundleInfo{
+		{Image: "quay.io/bpfman/bundle:v0.5.8", Name: "bpfman-operator.v0.5.8", Package: "bpfman-operator", Version: "0.5.8"},
+	}, FBCOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := yaml.Marshal(tmpl)
+	if err != nil {
+		t.Fatalf("marshaling template: %v", err)
+	}
+	got := string(out)
+
+	for _, want := range []string{"schema: olm.template.basic\n", "defaultChannel: preview\n", "- name: bpfman-operator.v0.5.8\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("template missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"replaces:", "skipRange:", "Schema:"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("template unexpectedly contains %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+// TestPackageMetadata tests that the package takes its icon and
+// description from the newest bundle's CSV, or from a reference
+// olm.package when one is given.
+func TestPackageMetadata(t *testing.T) {
+	icon, description, err := csvPackageMetadata(`{"kind":"ClusterServiceVersion","spec":{"description":"eBPF Manager","icon":[{"base64data":"PHN2Zz4=","mediatype":"image/svg+xml"}]}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if icon == nil || string(icon.Data) != "<svg>" || icon.MediaType != "image/svg+xml" || description != "eBPF Manager" {
+		t.Fatalf("got icon %+v and description %q", icon, description)
+	}
+
+	tmpl, err := BuildFBCTemplate([]BundleInfo{
+		{Image: "quay.io/bpfman/bundle:v0.5.9", Name: "bpfman-operator.v0.5.9", Package: "bpfman-operator", Version: "0.5.9", Icon: icon, Description: description},
+		{Image: "quay.io/bpfman/bundle:v0.5.8", Name: "bpfman-operator.v0.5.8", Package: "bpfman-operator", Version: "0.5.8", Description: "old"},
+	}, FBCOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pkg := tmpl.Entries[0].(PackageEntry); pkg.Icon != icon || pkg.Description != "eBPF Manager" {
+		t.Errorf("package not described by the newest bundle: %+v", pkg)
+	}
+
+	reference := declcfg.Package{Name: "bpfman-operator", Icon: &declcfg.Icon{Data: []byte("<svg/>"), MediaType: "image/svg+xml"}}
+	if err := tmpl.CopyPackageMetadata([]declcfg.Package{{Name: "other"}, reference}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pkg := tmpl.Entries[0].(PackageEntry); pkg.Icon != reference.Icon || pkg.Description != "" {
+		t.Errorf("reference metadata not copied: %+v", pkg)
+	}
+
+	if err := tmpl.CopyPackageMetadata([]declcfg.Package{{Name: "other"}}); err == nil {
+		t.Error("expected an error when no olm.package matches")
+	}
+}
+
+// TestGenerateMakefile tests the bundle listing in the Makefile
+// header and that digests are resolved without external tools.
+func TestGenerateMakefile(t *testing.T) {
+	tests := []struct {
+		name   string
+		images []string
+		want   []string
+	}{
+		{
+			name: "from catalog.yaml",
+			want: []string{"# Generated from catalog.yaml\n", "quay.io/$(QUAY_USER)/bpfman-catalog:latest"},
+		},
+		{
+			name:   "single bundle",
+			images: []string{"quay.io/bpfman/bundle@sha256:f015580da52da53c"},
+			want:   []string{"# Generated from bundle: quay.io/bpfman/bundle@sha256:f015580da52da53c\n", "bpfman-catalog-sha-f015580d:latest"},
+		},
+		{
+			name:   "several bundles",
+			images: []string{"quay.io/bpfman/bundle@sha256:aaaaaaaaaaaa", "quay.io/bpfman/bundle@sha256:bbbbbbbbbbbb"},
+			want: []string{
+				"# Generated from 2 bundles, oldest first:\n#   quay.io/bpfman/bundle@sha256:aaaaaaaaaaaa\n#   quay.io/bpfman/bundle@sha256:bbbbbbbbbbbb\n",
+				"bpfman-catalog-sha-bbbbbbbb:latest",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultTemplates().GenerateMakefile(NewCatalogData(tt.images, ""), "bpfman-catalog", "uuid", "15m")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Makefile missing %q:\n%s", want, got)
+				}
+			}
+			for _, tool := range []string{"skopeo", "jq "} {
+				if strings.Contains(got, tool) {
+					t.Errorf("Makefile uses %q:\n%s", tool, got)
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateImageUUIDAndTTL tests that seeded sources give the same
+// image name and TTL.
+func TestGenerateImageUUIDAndTTL(t *testing.T) {
+	settings := provenance.Settings{Deterministic: true, Seed: "1700000000"}
+	uuid1, ttl1 := GenerateImageUUIDAndTTL(settings.Rand())
+	uuid2, ttl2 := GenerateImageUUIDAndTTL(settings.Rand())
+	if uuid1 != uuid2 || ttl1 != ttl2 {
+		t.Errorf("got %s %s and %s %s", uuid1, ttl1, uuid2, ttl2)
+	}
+	if len(uuid1) != 73 || !strings.HasSuffix(ttl1, "m") {
+		t.Errorf("unexpected image name %s or TTL %s", uuid1, ttl1)
+	}
+}
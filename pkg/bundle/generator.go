@@ -3,8 +3,10 @@ package bundle
 import (
 	"context"
 	"fmt"
-	"os"
 
+	"github.com/openshift/bpfman-catalog/pkg/catalog"
+	"github.com/openshift/bpfman-catalog/pkg/provenance"
+	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 )
 
@@ -14,43 +16,50 @@ type Artefacts struct {
 	CatalogYAML string // Rendered catalog (if opm is available)
 	Dockerfile  string // Dockerfile for building catalog image
 	Makefile    string // Makefile for building and deploying catalog
+
+	Catalog CatalogData // Catalog description passed to the templates
+
+	Provenance *provenance.Record // Inputs the artefacts were generated from
 }
 
 // Generator handles bundle to catalog conversion.
 type Generator struct {
-	bundleImage string
-	channel     string
-	ompBinPath  string // Optional path to external opm binary
+	bundleImages []string
+	options      FBCOptions
+	dockerfile   DockerfileOptions
+	settings     provenance.Settings
+	templates    *Templates
+	ompBinPath   string // Optional path to external opm binary
 }
 
-// NewGenerator creates a new bundle generator.
-func NewGenerator(bundleImage, channel string) *Generator {
-	if channel == "" {
-		channel = "preview"
+// NewGenerator creates a new bundle generator. The Makefile and
+// Dockerfile are rendered from templates, or from the embedded
+// templates when it is nil.
+func NewGenerator(bundleImages []string, options FBCOptions, dockerfile DockerfileOptions, settings provenance.Settings, templates *Templates) *Generator {
+	if templates == nil {
+		templates = DefaultTemplates()
 	}
 	return &Generator{
-		bundleImage: bundleImage,
-		channel:     channel,
+		bundleImages: bundleImages,
+		options:      options,
+		dockerfile:   dockerfile,
+		settings:     settings,
+		templates:    templates,
 	}
 }
 
 // NewGeneratorWithOmp NewGeneratorWithOpm creates a new bundle generator with external
 // opm binary.
-func NewGeneratorWithOmp(bundleImage, channel, ompBinPath string) *Generator {
-	if channel == "" {
-		channel = "preview"
-	}
-	return &Generator{
-		bundleImage: bundleImage,
-		channel:     channel,
-		ompBinPath:  ompBinPath,
-	}
+func NewGeneratorWithOmp(bundleImages []string, options FBCOptions, dockerfile DockerfileOptions, settings provenance.Settings, templates *Templates, ompBinPath string) *Generator {
+	g := NewGenerator(bundleImages, options, dockerfile, settings, templates)
+	g.ompBinPath = ompBinPath
+	return g
 }
 
-// Generate creates all artefacts needed to build a catalog from a
-// bundle.
+// Generate creates all artefacts needed to build a catalog from one
+// or more bundles.
 func (g *Generator) Generate(ctx context.Context) (*Artefacts, error) {
-	fbcTemplate, err := GenerateFBCTemplate(g.bundleImage, g.channel)
+	fbcTemplate, err := GenerateFBCTemplate(g.bundleImages, g.options)
 	if err != nil {
 		return nil, fmt.Errorf("generating FBC template: %w", err)
 	}
@@ -60,33 +69,64 @@ func (g *Generator) Generate(ctx context.Context) (*Artefacts, error) {
 		return nil, fmt.Errorf("marshaling FBC template: %w", err)
 	}
 
-	execPath := getExecutablePath()
-	imageUUID, randomTTL := GenerateImageUUIDAndTTL()
+	record := g.provenance(ctx, fbcTemplate)
+
+	imageUUID, randomTTL := GenerateImageUUIDAndTTL(g.settings.Rand())
 
 	artefacts := &Artefacts{
 		FBCTemplate: string(fbcYAML),
-		Dockerfile:  GenerateCatalogDockerfile(),
-		Makefile:    GenerateMakefile(g.bundleImage, execPath, imageUUID, randomTTL),
+		Provenance:  record,
 	}
 
-	catalogYAML, err := g.renderCatalog(ctx, fbcTemplate)
+	// The Makefile and Dockerfile are still generated when rendering
+	// fails, so the catalog can be rendered by hand and built.
+	catalogYAML, renderErr := g.renderCatalog(ctx, fbcTemplate)
+	artefacts.Catalog = NewCatalogData(fbcTemplate.BundleImages(), catalogYAML)
+	artefacts.Makefile, err = g.templates.GenerateMakefile(artefacts.Catalog, g.settings.Executable(), imageUUID, randomTTL)
 	if err != nil {
-		return artefacts, fmt.Errorf("rendering catalog: %w", err)
+		return nil, err
+	}
+	artefacts.Dockerfile, err = g.templates.GenerateCatalogDockerfile(artefacts.Catalog, g.dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	if renderErr != nil {
+		return artefacts, fmt.Errorf("rendering catalog: %w", renderErr)
 	}
 
 	artefacts.CatalogYAML = catalogYAML
 	return artefacts, nil
 }
 
-func getExecutablePath() string {
-	execPath, err := os.Executable()
-	if err != nil {
-		return "bpfman-catalog"
+// provenance records the bundle digests and rendering mode the
+// artefacts are generated with. Bundles referenced by tag are
+// resolved to the digest the registry serves. A digest that cannot be
+// resolved is recorded as unresolved rather than failing, so that the
+// artefacts are still generated when the registry is unreachable.
+func (g *Generator) provenance(ctx context.Context, fbcTemplate CatalogTemplate) *provenance.Record {
+	record := provenance.NewRecord("prepare-catalog-build-from-bundle", g.settings)
+	record.TemplateType = templateType(fbcTemplate)
+	record.MigrationLevel = catalog.MigrationLevel
+	record.Templates = g.templates.Overrides()
+	record.OpmMode = provenance.OpmModeLibrary
+	if g.ompBinPath != "" {
+		record.OpmMode = provenance.OpmModeBinary
+		record.OpmBinary = g.ompBinPath
+	}
+
+	for _, image := range fbcTemplate.BundleImages() {
+		d, err := catalog.ResolveDigest(ctx, image)
+		if err != nil {
+			logrus.Warnf("Could not resolve the digest of %s: %v", image, err)
+			record.Bundles = append(record.Bundles, provenance.Bundle{Image: image, Error: err.Error()})
+			continue
+		}
+		record.Bundles = append(record.Bundles, provenance.Bundle{Image: image, Digest: d.String()})
 	}
-	return execPath
+	return record
 }
 
-func (g *Generator) renderCatalog(ctx context.Context, fbcTemplate *FBCTemplate) (string, error) {
+func (g *Generator) renderCatalog(ctx context.Context, fbcTemplate CatalogTemplate) (string, error) {
 	if g.ompBinPath != "" {
 		return RenderCatalogWithBinary(ctx, fbcTemplate, g.ompBinPath)
 	}
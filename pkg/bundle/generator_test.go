@@ -0,0 +1,42 @@
+package bundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/bpfman-catalog/pkg/provenance"
+)
+
+// TestProvenanceUnresolvedDigest tests that a bundle digest that
+// cannot be resolved is recorded rather than failing generation.
+func TestProvenanceUnresolvedDigest(t *testing.T) {
+	const (
+		pinned = "quay.io/bpfman/bundle@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		tagged = "quay.io/bpfman/bundle:latest"
+	)
+	g := NewGenerator([]string{pinned, tagged}, FBCOptions{}, DockerfileOptions{}, provenance.Settings{}, nil)
+
+	// A cancelled context fails any registry lookup.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	record := g.provenance(ctx, testCatalogTemplate{pinned, tagged})
+
+	bundles := make(map[string]provenance.Bundle)
+	for _, b := range record.Bundles {
+		bundles[b.Image] = b
+	}
+	if b := bundles[pinned]; b.Digest != "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" || b.Error != "" {
+		t.Errorf("got pinned bundle %+v", b)
+	}
+	if b := bundles[tagged]; b.Digest != "" || b.Error == "" {
+		t.Errorf("got tagged bundle %+v, want an unresolved digest", b)
+	}
+}
+
+// testCatalogTemplate is a catalog template of the given bundle
+// images, built without pulling them.
+type testCatalogTemplate []string
+
+func (t testCatalogTemplate) BundleImages() []string {
+	return t
+}
@@ -0,0 +1,232 @@
+package bundle
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateDataTypes maps each template to the type of the data it is
+// executed with.
+var templateDataTypes = map[string]reflect.Type{
+	DockerfileTemplate: reflect.TypeFor[DockerfileData](),
+	MakefileTemplate:   reflect.TypeFor[MakefileData](),
+	WorkflowTemplate:   reflect.TypeFor[WorkflowData](),
+}
+
+// checkTemplateFields checks every field a template refers to against
+// the type of its data, on every branch, so that a misspelt field is
+// reported when the template is loaded rather than when a rarely
+// taken branch is executed. Fields reached through function results
+// cannot be checked, and are left to execution.
+func checkTemplateFields(tmpl *template.Template, data reflect.Type) error {
+	c := &fieldChecker{tmpl: tmpl, checked: map[templateCall]bool{}}
+	return c.walk(tmpl.Tree.Root, data, map[string]reflect.Type{"$": data})
+}
+
+// fieldChecker walks a template's parse tree, tracking the type of
+// dot and of each variable. A nil type is unknown and is not checked.
+type fieldChecker struct {
+	tmpl    *template.Template
+	checked map[templateCall]bool // Associated templates already walked
+}
+
+// templateCall identifies an associated template walked with a type
+// of dot. A template called with different data is checked for each.
+type templateCall struct {
+	name string
+	dot  reflect.Type
+}
+
+func (c *fieldChecker) walk(node parse.Node, dot reflect.Type, vars map[string]reflect.Type) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := c.walk(child, dot, vars); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := c.pipe(n.Pipe, dot, vars)
+		return err
+	case *parse.IfNode:
+		return c.branch(&n.BranchNode, dot, vars, func(reflect.Type) reflect.Type { return dot })
+	case *parse.WithNode:
+		return c.branch(&n.BranchNode, dot, vars, func(t reflect.Type) reflect.Type { return t })
+	case *parse.RangeNode:
+		return c.branch(&n.BranchNode, dot, vars, elemType)
+	case *parse.TemplateNode:
+		var inner reflect.Type
+		if n.Pipe != nil {
+			var err error
+			if inner, err = c.pipe(n.Pipe, dot, maps.Clone(vars)); err != nil {
+				return err
+			}
+		}
+		t := c.tmpl.Lookup(n.Name)
+		call := templateCall{name: n.Name, dot: inner}
+		if t == nil || t.Tree == nil || c.checked[call] {
+			return nil
+		}
+		c.checked[call] = true
+		return c.walk(t.Tree.Root, inner, map[string]reflect.Type{"$": inner})
+	}
+	return nil
+}
+
+// branch checks an if, with or range. The body is walked with the dot
+// returned by body; the else branch keeps the enclosing dot.
+func (c *fieldChecker) branch(n *parse.BranchNode, dot reflect.Type, vars map[string]reflect.Type, body func(reflect.Type) reflect.Type) error {
+	scope := maps.Clone(vars)
+	t, err := c.pipe(n.Pipe, dot, scope)
+	if err != nil {
+		return err
+	}
+	if n.NodeType == parse.NodeRange && len(n.Pipe.Decl) > 0 {
+		// {{range $i, $v := ...}} or {{range $v := ...}}.
+		elem := elemType(t)
+		scope[n.Pipe.Decl[len(n.Pipe.Decl)-1].Ident[0]] = elem
+		if len(n.Pipe.Decl) == 2 {
+			scope[n.Pipe.Decl[0].Ident[0]] = keyType(t)
+		}
+	}
+	if err := c.walk(n.List, body(t), scope); err != nil {
+		return err
+	}
+	return c.walk(n.ElseList, dot, maps.Clone(vars))
+}
+
+// pipe checks a pipeline and returns the type of its result, declaring
+// any variables it assigns.
+func (c *fieldChecker) pipe(p *parse.PipeNode, dot reflect.Type, vars map[string]reflect.Type) (reflect.Type, error) {
+	if p == nil {
+		return nil, nil
+	}
+	var result reflect.Type
+	for _, cmd := range p.Cmds {
+		var err error
+		if result, err = c.command(cmd, dot, vars); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range p.Decl {
+		vars[v.Ident[0]] = result
+	}
+	return result, nil
+}
+
+// command checks the arguments of a command. A command that is a
+// single field, chain, variable or dot has that operand's type; any
+// other command, such as a function call, has an unknown type.
+func (c *fieldChecker) command(cmd *parse.CommandNode, dot reflect.Type, vars map[string]reflect.Type) (reflect.Type, error) {
+	var result reflect.Type
+	for _, arg := range cmd.Args {
+		t, err := c.operand(arg, dot, vars)
+		if err != nil {
+			return nil, err
+		}
+		result = t
+	}
+	if len(cmd.Args) != 1 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (c *fieldChecker) operand(node parse.Node, dot reflect.Type, vars map[string]reflect.Type) (reflect.Type, error) {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot, nil
+	case *parse.FieldNode:
+		return c.fields(n, dot, n.Ident)
+	case *parse.VariableNode:
+		return c.fields(n, vars[n.Ident[0]], n.Ident[1:])
+	case *parse.ChainNode:
+		t, err := c.operand(n.Node, dot, vars)
+		if err != nil {
+			return nil, err
+		}
+		return c.fields(n, t, n.Field)
+	case *parse.PipeNode:
+		return c.pipe(n, dot, maps.Clone(vars))
+	}
+	return nil, nil
+}
+
+// fields resolves a sequence of field names, e.g. .CatalogData.Package,
+// starting from t.
+func (c *fieldChecker) fields(node parse.Node, t reflect.Type, names []string) (reflect.Type, error) {
+	for _, name := range names {
+		if t == nil {
+			return nil, nil
+		}
+		next, ok := fieldType(t, name)
+		if !ok {
+			location, _ := c.tmpl.ErrorContext(node)
+			return nil, fmt.Errorf("%s: can't evaluate field %s in type %s (fields: %s)", location, name, t, strings.Join(typeFieldNames(t), ", "))
+		}
+		t = next
+	}
+	return t, nil
+}
+
+// fieldType returns the type of a field or method of t as the
+// template package resolves it. Map keys and interface values cannot
+// be checked, and have an unknown type.
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if m, ok := reflect.PointerTo(t).MethodByName(name); ok {
+		if m.Type.NumOut() == 0 {
+			return nil, false
+		}
+		return m.Type.Out(0), true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		f, ok := t.FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, false
+		}
+		return f.Type, true
+	case reflect.Map:
+		return elemOrUnknown(t.Elem()), true
+	case reflect.Interface:
+		return nil, true
+	}
+	return nil, false
+}
+
+// elemType returns the type of the elements ranged over in t.
+func elemType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return elemOrUnknown(t.Elem())
+	}
+	return nil
+}
+
+// keyType returns the type of the keys ranged over in t.
+func keyType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Map {
+		return t.Key()
+	}
+	return nil
+}
+
+func elemOrUnknown(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	return t
+}
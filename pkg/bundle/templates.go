@@ -0,0 +1,256 @@
+package bundle
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// Names of the templates the generated files are rendered from. A
+// file of the same name in a template directory overrides the
+// embedded template.
+const (
+	DockerfileTemplate = "Dockerfile.tmpl"
+	MakefileTemplate   = "Makefile.tmpl"
+	WorkflowTemplate   = "WORKFLOW.txt.tmpl"
+)
+
+// TemplateNames lists the templates that may be overridden.
+var TemplateNames = []string{DockerfileTemplate, MakefileTemplate, WorkflowTemplate}
+
+// CatalogData describes the catalog to every template.
+type CatalogData struct {
+	Package        string   // Package of the catalog (bpfman-operator when the catalog is not rendered)
+	DefaultChannel string   // Default channel of the package; empty when the catalog is not rendered
+	Channels       []string // Channels of the package, sorted; empty when the catalog is not rendered
+	Version        string   // Version of the default channel head; empty when the catalog is not rendered
+	CatalogDigest  string   // Digest of catalog.yaml (sha256:...); empty when the catalog is not rendered
+	BundleImages   []string // Bundle images the catalog was generated from, oldest first; empty for catalog.yaml
+	BundleImage    string   // Newest bundle image; empty for catalog.yaml
+}
+
+// NewCatalogData describes a catalog generated from bundleImages,
+// which may be empty, and rendered to catalogYAML, which may be empty
+// when rendering failed.
+func NewCatalogData(bundleImages []string, catalogYAML string) CatalogData {
+	data := CatalogData{BundleImages: bundleImages}
+	if len(bundleImages) > 0 {
+		data.BundleImage = bundleImages[len(bundleImages)-1]
+	}
+	data.Package, data.Version = CatalogBuildInfo(catalogYAML)
+	if catalogYAML == "" {
+		return data
+	}
+	data.CatalogDigest = digest.FromString(catalogYAML).String()
+
+	cfg, err := declcfg.LoadReader(strings.NewReader(catalogYAML))
+	if err != nil {
+		return data
+	}
+	for _, p := range cfg.Packages {
+		if p.Name == data.Package {
+			data.DefaultChannel = p.DefaultChannel
+		}
+	}
+	for _, ch := range cfg.Channels {
+		if ch.Package == data.Package {
+			data.Channels = append(data.Channels, ch.Name)
+		}
+	}
+	slices.Sort(data.Channels)
+	return data
+}
+
+// MakefileData is passed to Makefile.tmpl.
+type MakefileData struct {
+	CatalogData
+	LocalTag   string // Image name for local builds, derived from the newest bundle's digest
+	BinaryPath string // Path of the bpfman-catalog binary the Makefile invokes
+	ImageUUID  string // Random image name for ttl.sh examples
+	RandomTTL  string // Random ttl.sh tag, such as 15m
+	Username   string // quay.io user for examples: $BPFMAN_CATALOG_QUAY_USER, $USER or $(USER)
+}
+
+// WorkflowData is passed to WORKFLOW.txt.tmpl.
+type WorkflowData struct {
+	CatalogData
+	BundleCount     int    // Number of bundle images; 0 for catalog.yaml
+	CatalogRendered bool   // Whether catalog.yaml was written
+	OutputDir       string // Directory the artefacts are written to
+	ImageUUID       string // Random image name for ttl.sh examples
+	RandomTTL       string // Random ttl.sh tag, such as 15m
+	Username        string // quay.io user for examples: $BPFMAN_CATALOG_QUAY_USER, $USER or $(USER)
+}
+
+// DockerfileData is passed to Dockerfile.tmpl.
+type DockerfileData struct {
+	CatalogData
+	BaseImage string  // opm base image
+	Commit    string  // Source commit for the upstream-vcs-ref label
+	Labels    []Label // Catalog image labels, sorted by name, referring to the COMMIT and BUILDVERSION build arguments
+}
+
+// Templates renders the generated Makefile, WORKFLOW.txt and
+// Dockerfile.
+type Templates struct {
+	templates map[string]*template.Template
+	overrides map[string]string // Digest of each overriding template by name
+}
+
+// DefaultTemplates returns the embedded templates.
+func DefaultTemplates() *Templates {
+	t, err := LoadTemplates("")
+	if err != nil {
+		panic(fmt.Sprintf("parsing embedded templates: %v", err))
+	}
+	return t
+}
+
+// LoadTemplates returns the embedded templates, overridden by the
+// files of the same name in dir when dir is set. Each override is
+// rendered with sample data, so that a template referring to an
+// unknown field fails here rather than after the catalog is rendered.
+// Other .tmpl files in dir are rejected, as they are most likely
+// misnamed overrides.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{templates: map[string]*template.Template{}, overrides: map[string]string{}}
+	for _, name := range TemplateNames {
+		text, err := embeddedTemplates.ReadFile("templates/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("reading embedded template %s: %w", name, err)
+		}
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("parsing embedded template %s: %w", name, err)
+		}
+		t.templates[name] = tmpl
+	}
+	if dir == "" {
+		return t, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading template directory: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".tmpl") {
+			continue
+		}
+		if !slices.Contains(TemplateNames, name) {
+			return nil, fmt.Errorf("unknown template %s in %s (templates: %s)", name, dir, strings.Join(TemplateNames, ", "))
+		}
+		path := filepath.Join(dir, name)
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", path, err)
+		}
+		t.templates[name] = tmpl
+		t.overrides[name] = digest.FromBytes(text).String()
+
+		if err := checkTemplateFields(tmpl, templateDataTypes[name]); err != nil {
+			return nil, fmt.Errorf("checking template %s: %w", path, err)
+		}
+	}
+	return t, nil
+}
+
+// Overrides returns the digest of each template overridden from the
+// template directory by name.
+func (t *Templates) Overrides() map[string]string {
+	return t.overrides
+}
+
+// GenerateMakefile generates a Makefile for building and deploying
+// the catalog. The local image tag is derived from the newest bundle.
+func (t *Templates) GenerateMakefile(catalog CatalogData, binaryPath, imageUUID, randomTTL string) (string, error) {
+	localTag := "bpfman-catalog"
+	if suffix := extractDigestSuffix(catalog.BundleImage); suffix != "" {
+		localTag = fmt.Sprintf("bpfman-catalog-sha-%s", suffix)
+	}
+	return t.execute(MakefileTemplate, MakefileData{
+		CatalogData: catalog,
+		LocalTag:    localTag,
+		BinaryPath:  binaryPath,
+		ImageUUID:   imageUUID,
+		RandomTTL:   randomTTL,
+		Username:    getUsernameOrDefault(),
+	})
+}
+
+// GenerateWorkflow generates a WORKFLOW.txt file with deployment
+// instructions.
+func (t *Templates) GenerateWorkflow(catalog CatalogData, outputDir, imageUUID, randomTTL string) (string, error) {
+	return t.execute(WorkflowTemplate, WorkflowData{
+		CatalogData:     catalog,
+		BundleCount:     len(catalog.BundleImages),
+		CatalogRendered: catalog.CatalogDigest != "",
+		OutputDir:       outputDir,
+		ImageUUID:       imageUUID,
+		RandomTTL:       randomTTL,
+		Username:        getUsernameOrDefault(),
+	})
+}
+
+// GenerateCatalogDockerfile generates a Dockerfile for building a
+// catalog image from catalog.yaml. The catalog is copied into a
+// directory named after its package and the version label is set to
+// the head of the package's default channel; when the catalog has not
+// been rendered yet the version is left for the build to supply.
+func (t *Templates) GenerateCatalogDockerfile(catalog CatalogData, opts DockerfileOptions) (string, error) {
+	return t.execute(DockerfileTemplate, DockerfileData{
+		CatalogData: catalog,
+		BaseImage:   opts.ResolvedBaseImage(),
+		Commit:      opts.Commit,
+		Labels:      SortedLabels(CatalogLabels("$BUILDVERSION", "$COMMIT")),
+	})
+}
+
+// execute renders a template. Errors from unknown fields list the
+// fields the template may use.
+func (t *Templates) execute(name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.templates[name].Execute(&buf, data); err != nil {
+		if strings.Contains(err.Error(), "can't evaluate field") {
+			return "", fmt.Errorf("rendering %s: %w (fields: %s)", name, err, strings.Join(typeFieldNames(reflect.TypeOf(data)), ", "))
+		}
+		return "", fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// typeFieldNames returns the fields of a template's data type,
+// including those of embedded structs.
+func typeFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for _, f := range reflect.VisibleFields(t) {
+		if f.IsExported() && !f.Anonymous {
+			names = append(names, f.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,155 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const templatesTestCatalog = `---
+schema: olm.package
+name: bpfman-operator
+defaultChannel: stable
+---
+schema: olm.channel
+package: bpfman-operator
+name: stable
+entries:
+  - name: bpfman-operator.v0.5.9
+---
+schema: olm.channel
+package: bpfman-operator
+name: preview
+entries:
+  - name: bpfman-operator.v0.5.9
+---
+schema: olm.bundle
+name: bpfman-operator.v0.5.9
+package: bpfman-operator
+image: quay.io/bpfman/bundle@sha256:f015580da52da53c
+properties:
+  - type: olm.package
+    value:
+      packageName: bpfman-operator
+      version: 0.5.9
+`
+
+// TestLoadTemplates tests that files in the template directory
+// override the embedded templates and that templates using unknown
+// fields or names are rejected when they are loaded.
+func TestLoadTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantErr []string
+	}{
+		{
+			name: "embedded",
+			want: "# Generated from bundle: quay.io/bpfman/bundle@sha256:f015580da52da53c\n",
+		},
+		{
+			name: "override",
+			files: map[string]string{
+				MakefileTemplate: "deploy:\n\toc apply -f {{.BundleImage}} # {{.Package}} {{.DefaultChannel}} {{.Channels}} {{.CatalogDigest}} {{.LocalTag}}\n",
+				"README.md":      "Not a template.\n",
+			},
+			want: "\toc apply -f quay.io/bpfman/bundle@sha256:f015580da52da53c # bpfman-operator stable [preview stable] sha256:",
+		},
+		{
+			name:    "unknown field",
+			files:   map[string]string{MakefileTemplate: "IMAGE ?= {{.Registry}}/catalog\n"},
+			wantErr: []string{MakefileTemplate, "Registry", "fields: BinaryPath, BundleImage, BundleImages, CatalogDigest"},
+		},
+		{
+			name:  "ranges and variables",
+			files: map[string]string{MakefileTemplate: "{{range $i, $image := .BundleImages}}{{$i}} {{$image}} {{$.LocalTag}}{{end}}{{with .CatalogData}}{{.Package}}{{end}}\n"},
+			want:  "0 quay.io/bpfman/bundle@sha256:f015580da52da53c bpfman-catalog-sha-f015580dbpfman-operator\n",
+		},
+		{
+			name:    "unknown field in a branch not taken",
+			files:   map[string]string{WorkflowTemplate: "{{if .CatalogRendered}}ok{{else}}{{.Bogus}}{{end}}\n"},
+			wantErr: []string{WorkflowTemplate, "Bogus", "fields: BundleCount, BundleImage"},
+		},
+		{
+			name:    "unknown field of a range element",
+			files:   map[string]string{MakefileTemplate: "{{range .BundleImages}}{{.Digest}}{{end}}\n"},
+			wantErr: []string{MakefileTemplate, "can't evaluate field Digest in type string"},
+		},
+		{
+			name:    "unknown field of a variable",
+			files:   map[string]string{DockerfileTemplate: "{{$c := .CatalogData}}{{$c.Commit}}\n"},
+			wantErr: []string{DockerfileTemplate, "can't evaluate field Commit in type bundle.CatalogData"},
+		},
+		{
+			name: "unknown field of a template called with other data",
+			files: map[string]string{MakefileTemplate: `{{define "name"}}{{.Package}}{{end}}` +
+				"{{template \"name\" .CatalogData}}{{range .BundleImages}}{{template \"name\" .}}{{end}}\n"},
+			wantErr: []string{MakefileTemplate, "can't evaluate field Package in type string"},
+		},
+		{
+			name:    "unknown template",
+			files:   map[string]string{"makefile.tmpl": "all:\n"},
+			wantErr: []string{"unknown template makefile.tmpl", MakefileTemplate},
+		},
+		{
+			name:    "parse error",
+			files:   map[string]string{WorkflowTemplate: "{{if .BundleCount}}\n"},
+			wantErr: []string{WorkflowTemplate, "unexpected EOF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dir string
+			if tt.files != nil {
+				dir = t.TempDir()
+				for name, content := range tt.files {
+					if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			templates, err := LoadTemplates(dir)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not mention %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			catalog := NewCatalogData([]string{"quay.io/bpfman/bundle@sha256:f015580da52da53c"}, templatesTestCatalog)
+			got, err := templates.GenerateMakefile(catalog, "bpfman-catalog", "uuid", "15m")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("Makefile missing %q:\n%s", tt.want, got)
+			}
+			if _, ok := templates.Overrides()[MakefileTemplate]; ok != (tt.files != nil) {
+				t.Errorf("got overrides %v", templates.Overrides())
+			}
+		})
+	}
+}
+
+// TestEmbeddedTemplateFields tests that the embedded templates only
+// refer to fields of their data.
+func TestEmbeddedTemplateFields(t *testing.T) {
+	templates := DefaultTemplates()
+	for _, name := range TemplateNames {
+		if err := checkTemplateFields(templates.templates[name], templateDataTypes[name]); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
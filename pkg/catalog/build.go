@@ -0,0 +1,482 @@
+package catalog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/oci/archive"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/cache"
+)
+
+// Paths of the catalog and its serve cache in a catalog image, as in
+// the repository Dockerfile.
+const (
+	ImageConfigsDir = "/configs"
+	ImageCacheDir   = "/tmp/cache"
+)
+
+// OpmVersionLabel records the operator-registry version the serve
+// cache in a catalog image was built with. opm serve rebuilds a cache
+// built by another version at startup, so a pre-built cache only
+// saves that work when the base image's opm is the same version.
+const OpmVersionLabel = "io.openshift.bpfman-catalog.opm-version"
+
+const operatorRegistryModule = "github.com/operator-framework/operator-registry"
+
+// ImageBuildOptions configures an in-process catalog image build.
+type ImageBuildOptions struct {
+	BaseImage   string            // opm base image; a docker://, oci: or oci-archive: reference (default transport: docker)
+	Destination string            // Where to write the image; a docker://, oci: or oci-archive: reference
+	CatalogYAML []byte            // Rendered file-based catalog
+	Package     string            // Catalog directory under /configs
+	Labels      map[string]string // Image config labels, added to the base image's
+	ServeCache  bool              // Pre-build the opm serve cache into the image
+	Arch        string            // Architecture to select from a multi-arch base image (default: the host's)
+	Created     time.Time         // Creation time recorded in the image
+	AuthFile    string            // Registry credentials (default: the containers auth file)
+}
+
+// ImageBuildResult describes a built catalog image.
+type ImageBuildResult struct {
+	Destination string        `json:"destination"`
+	Digest      digest.Digest `json:"digest"` // Manifest digest at the destination
+	BaseImage   string        `json:"base_image"`
+	BaseDigest  digest.Digest `json:"base_digest"`
+}
+
+// imageTransports are the transports image references may use.
+var imageTransports = []types.ImageTransport{docker.Transport, layout.Transport, archive.Transport}
+
+// ParseImageName parses an image reference with an optional
+// transport prefix (docker://, oci: or oci-archive:). References
+// without one are registry references.
+func ParseImageName(name string) (types.ImageReference, error) {
+	for _, t := range imageTransports {
+		if rest, ok := strings.CutPrefix(name, t.Name()+":"); ok {
+			return t.ParseReference(rest)
+		}
+	}
+	return docker.ParseReference("//" + name)
+}
+
+// BuildImage builds a catalog image without a container engine. It
+// copies the base image into a temporary OCI layout, adds a layer
+// holding the catalog (and, optionally, the opm serve cache), sets the
+// labels and the opm serve entrypoint, and copies the result to the
+// destination, returning its manifest digest.
+func BuildImage(ctx context.Context, opts ImageBuildOptions) (*ImageBuildResult, error) {
+	if opts.Package == "" || strings.Contains(opts.Package, "/") {
+		return nil, fmt.Errorf("invalid catalog package directory %q", opts.Package)
+	}
+	if _, err := declcfg.LoadReader(bytes.NewReader(opts.CatalogYAML)); err != nil {
+		return nil, fmt.Errorf("parsing catalog: %w", err)
+	}
+	baseRef, err := ParseImageName(opts.BaseImage)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base image %s: %w", opts.BaseImage, err)
+	}
+	destRef, err := ParseImageName(opts.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("parsing destination %s: %w", opts.Destination, err)
+	}
+
+	sysCtx := &types.SystemContext{
+		ArchitectureChoice: opts.Arch,
+		OSChoice:           "linux",
+		AuthFilePath:       opts.AuthFile,
+	}
+	policy, err := acceptAnythingPolicy()
+	if err != nil {
+		return nil, err
+	}
+	defer policy.Destroy()
+
+	workDir, err := os.MkdirTemp("", "catalog-build-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+	layoutDir := filepath.Join(workDir, "layout")
+
+	base, err := layout.NewReference(layoutDir, "base")
+	if err != nil {
+		return nil, fmt.Errorf("creating OCI layout: %w", err)
+	}
+	baseManifestBlob, err := copy.Image(ctx, policy, base, baseRef, &copy.Options{
+		SourceCtx:             sysCtx,
+		DestinationCtx:        sysCtx,
+		ForceManifestMIMEType: imgspecv1.MediaTypeImageManifest,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("copying base image %s: %w", opts.BaseImage, err)
+	}
+	baseDigest, err := manifest.Digest(baseManifestBlob)
+	if err != nil {
+		return nil, fmt.Errorf("computing base image digest: %w", err)
+	}
+
+	var baseManifest imgspecv1.Manifest
+	if err := json.Unmarshal(baseManifestBlob, &baseManifest); err != nil {
+		return nil, fmt.Errorf("parsing base image manifest: %w", err)
+	}
+	configBlob, err := os.ReadFile(blobPath(layoutDir, baseManifest.Config.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("reading base image config: %w", err)
+	}
+	var config imgspecv1.Image
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, fmt.Errorf("parsing base image config: %w", err)
+	}
+
+	files, err := catalogImageFiles(ctx, workDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	layer, diffID, err := writeLayer(layoutDir, files, imageUser(config.Config.User), opts.Created)
+	if err != nil {
+		return nil, fmt.Errorf("writing catalog layer: %w", err)
+	}
+
+	created := opts.Created
+	config.Created = &created
+	config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+	config.Config.Labels = maps.Clone(config.Config.Labels)
+	if config.Config.Labels == nil {
+		config.Config.Labels = map[string]string{}
+	}
+	maps.Copy(config.Config.Labels, opts.Labels)
+	if opts.ServeCache {
+		config.Config.Labels[OpmVersionLabel] = opmVersion()
+	}
+	config.Config.Entrypoint = []string{"/bin/opm"}
+	config.Config.Cmd = []string{"serve", ImageConfigsDir, "--cache-dir=" + ImageCacheDir}
+	config.History = append(config.History, imgspecv1.History{
+		Created:   &created,
+		CreatedBy: "bpfman-catalog build-catalog-image",
+		Comment:   fmt.Sprintf("catalog %s", path.Join(ImageConfigsDir, opts.Package)),
+	})
+	configDesc, err := writeJSONBlob(layoutDir, imgspecv1.MediaTypeImageConfig, config)
+	if err != nil {
+		return nil, fmt.Errorf("writing image config: %w", err)
+	}
+
+	imageManifest := imgspecv1.Manifest{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    append(baseManifest.Layers, layer),
+		Annotations: map[string]string{
+			imgspecv1.AnnotationBaseImageName:   opts.BaseImage,
+			imgspecv1.AnnotationBaseImageDigest: baseDigest.String(),
+		},
+	}
+	manifestDesc, err := writeJSONBlob(layoutDir, imgspecv1.MediaTypeImageManifest, imageManifest)
+	if err != nil {
+		return nil, fmt.Errorf("writing image manifest: %w", err)
+	}
+	manifestDesc.Annotations = map[string]string{imgspecv1.AnnotationRefName: "catalog"}
+	index := imgspecv1.Index{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageIndex,
+		Manifests: []imgspecv1.Descriptor{manifestDesc},
+	}
+	indexBlob, err := json.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling OCI index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(layoutDir, imgspecv1.ImageIndexFile), indexBlob, 0644); err != nil {
+		return nil, fmt.Errorf("writing OCI index: %w", err)
+	}
+
+	built, err := layout.NewReference(layoutDir, "catalog")
+	if err != nil {
+		return nil, fmt.Errorf("opening built image: %w", err)
+	}
+	copied, err := copy.Image(ctx, policy, destRef, built, &copy.Options{
+		SourceCtx:      sysCtx,
+		DestinationCtx: sysCtx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("copying catalog image to %s: %w", opts.Destination, err)
+	}
+	imageDigest, err := manifest.Digest(copied)
+	if err != nil {
+		return nil, fmt.Errorf("computing image digest: %w", err)
+	}
+
+	return &ImageBuildResult{
+		Destination: opts.Destination,
+		Digest:      imageDigest,
+		BaseImage:   opts.BaseImage,
+		BaseDigest:  baseDigest,
+	}, nil
+}
+
+// PushOptions configures pushing an image to a registry.
+type PushOptions struct {
+	AuthFile      string // Registry credentials (default: the containers auth file)
+	SkipTLSVerify bool   // Allow HTTP or unverified HTTPS registries
+}
+
+// PushImage copies a locally built image, such as an OCI layout
+// written by BuildImage, to a registry. It returns the pushed image's
+// digest reference, with the digest resolved from the registry as for
+// any other catalog image.
+func PushImage(ctx context.Context, source, destination string, opts PushOptions) (string, error) {
+	srcRef, err := ParseImageName(source)
+	if err != nil {
+		return "", fmt.Errorf("parsing source %s: %w", source, err)
+	}
+	destRef, err := ParseImageName(destination)
+	if err != nil {
+		return "", fmt.Errorf("parsing destination %s: %w", destination, err)
+	}
+	if destRef.Transport() != docker.Transport {
+		return "", fmt.Errorf("destination %s is not a registry reference", destination)
+	}
+
+	sysCtx := &types.SystemContext{AuthFilePath: opts.AuthFile}
+	if opts.SkipTLSVerify {
+		sysCtx.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+	}
+	policy, err := acceptAnythingPolicy()
+	if err != nil {
+		return "", err
+	}
+	defer policy.Destroy()
+
+	if _, err := copy.Image(ctx, policy, destRef, srcRef, &copy.Options{
+		SourceCtx:      sysCtx,
+		DestinationCtx: sysCtx,
+	}); err != nil {
+		return "", fmt.Errorf("pushing %s to %s: %w", source, destination, err)
+	}
+
+	named := destRef.DockerReference()
+	d, err := FetchDigest(ctx, named.String(), sysCtx)
+	if err != nil {
+		return "", fmt.Errorf("resolving digest of %s: %w", named, err)
+	}
+	canonical, err := reference.WithDigest(reference.TrimNamed(named), d)
+	if err != nil {
+		return "", fmt.Errorf("building digest reference: %w", err)
+	}
+	return canonical.String(), nil
+}
+
+// acceptAnythingPolicy returns a signature policy context that
+// accepts any image. Catalog images are built from base images the
+// user chooses, so signatures are left to the registry and cluster.
+func acceptAnythingPolicy() (*signature.PolicyContext, error) {
+	policy, err := signature.NewPolicyContext(&signature.Policy{
+		Default: signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating signature policy: %w", err)
+	}
+	return policy, nil
+}
+
+// layerFile is a file or directory added to the catalog layer, with
+// an absolute path in the image.
+type layerFile struct {
+	Path     string
+	Dir      bool
+	Data     []byte
+	Writable bool // Owned by the image user, for the serve cache
+}
+
+// catalogImageFiles returns the files of the catalog layer: the
+// catalog under /configs/<package> and, if requested, the serve cache
+// opm builds from it.
+func catalogImageFiles(ctx context.Context, workDir string, opts ImageBuildOptions) ([]layerFile, error) {
+	catalogDir := path.Join(ImageConfigsDir, opts.Package)
+	files := []layerFile{
+		{Path: ImageConfigsDir, Dir: true},
+		{Path: catalogDir, Dir: true},
+		{Path: path.Join(catalogDir, "index.yaml"), Data: opts.CatalogYAML},
+	}
+	if !opts.ServeCache {
+		return files, nil
+	}
+
+	// The cache is built from a copy of the configs directory so that
+	// its digest matches what opm computes when serving the image.
+	configsDir := filepath.Join(workDir, "configs")
+	if err := os.MkdirAll(filepath.Join(configsDir, opts.Package), 0755); err != nil {
+		return nil, fmt.Errorf("creating configs directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(configsDir, opts.Package, "index.yaml"), opts.CatalogYAML, 0644); err != nil {
+		return nil, fmt.Errorf("writing catalog: %w", err)
+	}
+	cacheDir := filepath.Join(workDir, "cache")
+	store, err := cache.New(cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("creating serve cache: %w", err)
+	}
+	buildErr := store.Build(ctx, os.DirFS(configsDir))
+	if err := store.Close(); err != nil && buildErr == nil {
+		buildErr = err
+	}
+	if buildErr != nil {
+		return nil, fmt.Errorf("building serve cache: %w", buildErr)
+	}
+
+	files = append(files, layerFile{Path: ImageCacheDir, Dir: true, Writable: true})
+	err = filepath.WalkDir(cacheDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == cacheDir {
+			return err
+		}
+		rel, err := filepath.Rel(cacheDir, p)
+		if err != nil {
+			return err
+		}
+		f := layerFile{Path: path.Join(ImageCacheDir, filepath.ToSlash(rel)), Dir: d.IsDir(), Writable: true}
+		if !f.Dir {
+			if f.Data, err = os.ReadFile(p); err != nil {
+				return err
+			}
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading serve cache: %w", err)
+	}
+	return files, nil
+}
+
+// opmVersion returns the version of the operator-registry library
+// linked into the running binary, which builds the serve cache.
+func opmVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != operatorRegistryModule {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
+
+// imageUser returns the numeric user ID an image config runs as, or 0
+// if it is unset or a name.
+func imageUser(user string) int {
+	uid, _, _ := strings.Cut(user, ":")
+	id, err := strconv.Atoi(uid)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// writeLayer writes the files as a gzip-compressed layer blob and
+// returns its descriptor and uncompressed digest. Writable files are
+// owned by uid with group 0 and group-writable, so that opm can open
+// the cache under an arbitrary OpenShift user ID.
+func writeLayer(layoutDir string, files []layerFile, uid int, modTime time.Time) (imgspecv1.Descriptor, digest.Digest, error) {
+	var compressed bytes.Buffer
+	diffID := sha256.New()
+	gz := gzip.NewWriter(&compressed)
+	tw := tar.NewWriter(io.MultiWriter(gz, diffID))
+
+	for _, f := range files {
+		hdr := &tar.Header{
+			Name:    strings.TrimPrefix(f.Path, "/"),
+			ModTime: modTime,
+			Format:  tar.FormatPAX,
+		}
+		switch {
+		case f.Dir:
+			hdr.Typeflag, hdr.Name, hdr.Mode = tar.TypeDir, hdr.Name+"/", 0755
+		default:
+			hdr.Typeflag, hdr.Size, hdr.Mode = tar.TypeReg, int64(len(f.Data)), 0644
+		}
+		if f.Writable {
+			hdr.Uid, hdr.Mode = uid, hdr.Mode|0020
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return imgspecv1.Descriptor{}, "", err
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return imgspecv1.Descriptor{}, "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+
+	desc, err := writeBlob(layoutDir, imgspecv1.MediaTypeImageLayerGzip, compressed.Bytes())
+	if err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	return desc, digest.NewDigestFromEncoded(digest.SHA256, hex.EncodeToString(diffID.Sum(nil))), nil
+}
+
+// writeJSONBlob marshals v and writes it as a blob of an OCI layout.
+func writeJSONBlob(layoutDir, mediaType string, v any) (imgspecv1.Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	return writeBlob(layoutDir, mediaType, data)
+}
+
+// writeBlob writes a blob to an OCI layout and returns its
+// descriptor.
+func writeBlob(layoutDir, mediaType string, data []byte) (imgspecv1.Descriptor, error) {
+	desc := imgspecv1.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	p := blobPath(layoutDir, desc.Digest)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// blobPath returns the path of a blob in an OCI layout.
+func blobPath(layoutDir string, d digest.Digest) string {
+	return filepath.Join(layoutDir, imgspecv1.ImageBlobsDir, d.Algorithm().String(), d.Encoded())
+}
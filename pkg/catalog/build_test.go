@@ -0,0 +1,218 @@
+package catalog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const buildTestCatalog = `---
+schema: olm.package
+name: bpfman-operator
+defaultChannel: stable
+---
+schema: olm.channel
+package: bpfman-operator
+name: stable
+entries:
+  - name: bpfman-operator.v0.5.9
+---
+schema: olm.bundle
+name: bpfman-operator.v0.5.9
+package: bpfman-operator
+image: quay.io/bpfman/bundle:v0.5.9
+properties:
+  - type: olm.package
+    value:
+      packageName: bpfman-operator
+      version: 0.5.9
+`
+
+// TestBuildImage tests that the catalog layer, labels and entrypoint
+// are added to a local OCI base image.
+func TestBuildImage(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), "base")
+	writeTestBaseLayout(t, baseDir)
+
+	for _, serveCache := range []bool{false, true} {
+		outDir := filepath.Join(t.TempDir(), "out")
+		result, err := BuildImage(context.Background(), ImageBuildOptions{
+			BaseImage:   "oci:" + baseDir + ":opm",
+			Destination: "oci:" + outDir + ":catalog",
+			CatalogYAML: []byte(buildTestCatalog),
+			Package:     "bpfman-operator",
+			Labels:      map[string]string{"version": "0.5.9"},
+			ServeCache:  serveCache,
+			Arch:        "amd64",
+			Created:     time.Unix(0, 0).UTC(),
+		})
+		if err != nil {
+			t.Fatalf("serve cache %v: unexpected error: %v", serveCache, err)
+		}
+
+		var index imgspecv1.Index
+		readTestJSON(t, filepath.Join(outDir, imgspecv1.ImageIndexFile), &index)
+		if len(index.Manifests) != 1 || index.Manifests[0].Digest != result.Digest {
+			t.Fatalf("output index %+v does not hold digest %s", index.Manifests, result.Digest)
+		}
+		var m imgspecv1.Manifest
+		readTestJSON(t, blobPath(outDir, result.Digest), &m)
+		if len(m.Layers) != 2 {
+			t.Fatalf("got %d layers, want the base layer and the catalog layer", len(m.Layers))
+		}
+		var config imgspecv1.Image
+		readTestJSON(t, blobPath(outDir, m.Config.Digest), &config)
+		if config.Config.Labels["version"] != "0.5.9" || config.Config.Labels["base"] != "kept" {
+			t.Errorf("unexpected labels: %v", config.Config.Labels)
+		}
+		if strings.Join(config.Config.Entrypoint, " ") != "/bin/opm" || strings.Join(config.Config.Cmd, " ") != "serve /configs --cache-dir=/tmp/cache" {
+			t.Errorf("unexpected entrypoint %v and cmd %v", config.Config.Entrypoint, config.Config.Cmd)
+		}
+		if len(config.RootFS.DiffIDs) != 2 {
+			t.Errorf("got %d diff IDs, want 2", len(config.RootFS.DiffIDs))
+		}
+
+		files := readTestLayer(t, blobPath(outDir, m.Layers[1].Digest))
+		if hdr := files["configs/bpfman-operator/index.yaml"]; hdr == nil || hdr.Size != int64(len(buildTestCatalog)) {
+			t.Errorf("catalog missing from layer: %v", files)
+		}
+		var cached bool
+		for name, hdr := range files {
+			if strings.HasPrefix(name, "tmp/cache/") {
+				cached = true
+				if hdr.Uid != 1001 || hdr.Mode&0020 == 0 {
+					t.Errorf("cache file %s not writable by the image user: uid %d mode %o", name, hdr.Uid, hdr.Mode)
+				}
+			}
+		}
+		if cached != serveCache {
+			t.Errorf("serve cache %v: cache in layer is %v", serveCache, cached)
+		}
+		if v, ok := config.Config.Labels[OpmVersionLabel]; ok != serveCache || (ok && v != opmVersion()) {
+			t.Errorf("serve cache %v: got opm version label %q (set %v), want %q", serveCache, v, ok, opmVersion())
+		}
+	}
+}
+
+// writeTestBaseLayout writes an OCI layout holding a single-layer
+// image tagged opm.
+func writeTestBaseLayout(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, imgspecv1.ImageLayoutFile), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	layer, diffID, err := writeLayer(dir, []layerFile{{Path: "/bin", Dir: true}, {Path: "/bin/opm", Data: []byte("#!/bin/sh\n")}}, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := imgspecv1.Image{
+		Platform: imgspecv1.Platform{Architecture: "amd64", OS: "linux"},
+		Config:   imgspecv1.ImageConfig{User: "1001", Labels: map[string]string{"base": "kept", "version": "base"}},
+		RootFS:   imgspecv1.RootFS{Type: "layers", DiffIDs: []digest.Digest{diffID}},
+	}
+	configDesc, err := writeJSONBlob(dir, imgspecv1.MediaTypeImageConfig, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc, err := writeJSONBlob(dir, imgspecv1.MediaTypeImageManifest, imgspecv1.Manifest{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []imgspecv1.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc.Annotations = map[string]string{imgspecv1.AnnotationRefName: "opm"}
+	index, err := json.Marshal(imgspecv1.Index{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		Manifests: []imgspecv1.Descriptor{manifestDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, imgspecv1.ImageIndexFile), index, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+}
+
+// readTestLayer returns the tar headers of a gzip-compressed layer
+// blob by name.
+func readTestLayer(t *testing.T, path string) map[string]*tar.Header {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]*tar.Header{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = hdr
+	}
+}
+
+// TestPushImage tests that a local OCI layout is pushed to a registry
+// and its digest resolved from there.
+func TestPushImage(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), "base")
+	writeTestBaseLayout(t, baseDir)
+	var index imgspecv1.Index
+	readTestJSON(t, filepath.Join(baseDir, imgspecv1.ImageIndexFile), &index)
+
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	got, err := PushImage(context.Background(), "oci:"+baseDir+":opm", "docker://"+host+"/bpfman/catalog:test", PushOptions{SkipTLSVerify: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := host + "/bpfman/catalog@" + index.Manifests[0].Digest.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if _, err := PushImage(context.Background(), "oci:"+baseDir+":opm", "oci:"+t.TempDir(), PushOptions{}); err == nil || !strings.Contains(err.Error(), "not a registry reference") {
+		t.Errorf("got error %v for an OCI layout destination", err)
+	}
+}
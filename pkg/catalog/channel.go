@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// FindChannel returns the named channel of a package.
+func FindChannel(cfg *declcfg.DeclarativeConfig, packageName, channelName string) (*declcfg.Channel, error) {
+	for i := range cfg.Channels {
+		if cfg.Channels[i].Package == packageName && cfg.Channels[i].Name == channelName {
+			return &cfg.Channels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("channel %s not found for package %s", channelName, packageName)
+}
+
+// DefaultChannel returns the default channel of a package.
+func DefaultChannel(cfg *declcfg.DeclarativeConfig, packageName string) (string, error) {
+	for _, p := range cfg.Packages {
+		if p.Name == packageName {
+			if p.DefaultChannel == "" {
+				return "", fmt.Errorf("package %s has no default channel", packageName)
+			}
+			return p.DefaultChannel, nil
+		}
+	}
+	return "", fmt.Errorf("package %s not found", packageName)
+}
+
+// ChannelHead returns the entry of a channel that no other entry
+// replaces or skips. As in operator-registry, a channel must have
+// exactly one head.
+func ChannelHead(channel *declcfg.Channel) (string, error) {
+	incoming := make(map[string]bool)
+	for _, e := range channel.Entries {
+		if e.Replaces != "" {
+			incoming[e.Replaces] = true
+		}
+		for _, skip := range e.Skips {
+			incoming[skip] = true
+		}
+	}
+
+	var heads []string
+	for _, e := range channel.Entries {
+		if !incoming[e.Name] {
+			heads = append(heads, e.Name)
+		}
+	}
+	if len(heads) != 1 {
+		sort.Strings(heads)
+		return "", fmt.Errorf("channel %s must have exactly one head, found %d: %s", channel.Name, len(heads), strings.Join(heads, ", "))
+	}
+	return heads[0], nil
+}
+
+// ReplacesChain returns the bundle names of a channel's replaces
+// chain, oldest first, ending at the channel head.
+func ReplacesChain(cfg *declcfg.DeclarativeConfig, packageName, channelName string) ([]string, error) {
+	channel, err := FindChannel(cfg, packageName, channelName)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := ChannelHead(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]declcfg.ChannelEntry)
+	for _, e := range channel.Entries {
+		entries[e.Name] = e
+	}
+
+	var chain []string
+	seen := make(map[string]bool)
+	for name := head; name != ""; name = entries[name].Replaces {
+		if seen[name] {
+			return nil, fmt.Errorf("channel %s has a replaces cycle at %s", channelName, name)
+		}
+		seen[name] = true
+		chain = append([]string{name}, chain...)
+		if _, ok := entries[entries[name].Replaces]; !ok {
+			break
+		}
+	}
+
+	return chain, nil
+}
+
+// BundleVersion returns the version from a bundle's olm.package
+// property, which the CSV's spec.version is rendered into.
+func BundleVersion(b declcfg.Bundle) string {
+	for _, p := range b.Properties {
+		if p.Type != property.TypePackage {
+			continue
+		}
+		var pkg property.Package
+		if err := json.Unmarshal(p.Value, &pkg); err == nil {
+			return pkg.Version
+		}
+	}
+	return ""
+}
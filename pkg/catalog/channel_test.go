@@ -0,0 +1,37 @@
+package catalog
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// TestReplacesChain tests that a channel is walked from its oldest
+// entry to its head.
+func TestReplacesChain(t *testing.T) {
+	cfg := &declcfg.DeclarativeConfig{
+		Channels: []declcfg.Channel{{
+			Package: "bpfman-operator",
+			Name:    "stable",
+			Entries: []declcfg.ChannelEntry{
+				{Name: "bpfman-operator.v0.5.6", Replaces: "bpfman-operator.v0.5.5"},
+				{Name: "bpfman-operator.v0.5.4"},
+				{Name: "bpfman-operator.v0.5.5", Replaces: "bpfman-operator.v0.5.4"},
+			},
+		}},
+	}
+
+	got, err := ReplacesChain(cfg, "bpfman-operator", "stable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"bpfman-operator.v0.5.4", "bpfman-operator.v0.5.5", "bpfman-operator.v0.5.6"}
+	if !slices.Equal(got, want) {
+		t.Errorf("chain = %v, want %v", got, want)
+	}
+
+	if _, err := ReplacesChain(cfg, "bpfman-operator", "fast"); err == nil {
+		t.Error("expected an error for an unknown channel")
+	}
+}
@@ -0,0 +1,365 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"gopkg.in/yaml.v3"
+)
+
+// Deprecation scopes, named after the schema an olm.deprecations
+// entry references.
+const (
+	ScopePackage = "package"
+	ScopeChannel = "channel"
+	ScopeBundle  = "bundle"
+)
+
+// Deprecation is an olm.deprecations entry that applies to a bundle,
+// either directly or through its package or one of its channels.
+type Deprecation struct {
+	Scope   string `json:"scope"`          // package, channel or bundle
+	Name    string `json:"name,omitempty"` // Channel or bundle name
+	Message string `json:"message"`
+}
+
+// String describes the deprecation on one line.
+func (d Deprecation) String() string {
+	if d.Name == "" {
+		return fmt.Sprintf("%s: %s", d.Scope, strings.TrimSpace(d.Message))
+	}
+	return fmt.Sprintf("%s %s: %s", d.Scope, d.Name, strings.TrimSpace(d.Message))
+}
+
+// BundleDeprecations returns the deprecations in a catalog that apply
+// to a bundle: those of its package, of every channel that contains
+// it and of the bundle itself.
+func BundleDeprecations(cfg *declcfg.DeclarativeConfig, packageName, bundleName string) []Deprecation {
+	var channels []string
+	for _, ch := range cfg.Channels {
+		if ch.Package != packageName {
+			continue
+		}
+		for _, entry := range ch.Entries {
+			if entry.Name == bundleName {
+				channels = append(channels, ch.Name)
+				break
+			}
+		}
+	}
+
+	var deprecations []Deprecation
+	for _, d := range cfg.Deprecations {
+		if d.Package != packageName {
+			continue
+		}
+		for _, entry := range d.Entries {
+			ref := entry.Reference
+			switch {
+			case ref.Schema == declcfg.SchemaPackage:
+				deprecations = append(deprecations, Deprecation{Scope: ScopePackage, Message: entry.Message})
+			case ref.Schema == declcfg.SchemaChannel && slices.Contains(channels, ref.Name):
+				deprecations = append(deprecations, Deprecation{Scope: ScopeChannel, Name: ref.Name, Message: entry.Message})
+			case ref.Schema == declcfg.SchemaBundle && ref.Name == bundleName:
+				deprecations = append(deprecations, Deprecation{Scope: ScopeBundle, Name: ref.Name, Message: entry.Message})
+			}
+		}
+	}
+	return deprecations
+}
+
+// DeprecationTargets selects what a deprecation applies to.
+type DeprecationTargets struct {
+	Package  bool     // Deprecate the whole package
+	Channels []string // Channel names
+	Bundles  []string // Bundle names, or versions such as 0.5.8
+}
+
+// Deprecate adds olm.deprecations entries with the given message to a
+// basic catalog template and returns the edited template along with
+// the entries it set. Entries that already exist for a target have
+// their message replaced. The package defaults to the template's only
+// olm.package. The template is edited in place so that comments and
+// the order of its entries are kept.
+func Deprecate(data []byte, packageName string, targets DeprecationTargets, message string) ([]byte, []Deprecation, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, nil, fmt.Errorf("deprecation message must not be empty")
+	}
+	if !targets.Package && len(targets.Channels) == 0 && len(targets.Bundles) == 0 {
+		return nil, nil, fmt.Errorf("nothing to deprecate: no package, channel or bundle given")
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing template: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("template is not a YAML mapping")
+	}
+	root := doc.Content[0]
+
+	switch schema := scalarValue(root, "schema"); schema {
+	case SchemaBasicTemplate:
+	case SchemaSemverTemplate:
+		return nil, nil, fmt.Errorf("%s templates cannot hold olm.deprecations; convert to or render a basic template first", schema)
+	default:
+		return nil, nil, fmt.Errorf("unsupported template schema %q (expected %s)", schema, SchemaBasicTemplate)
+	}
+	entries := mappingValue(root, "entries")
+	if entries == nil || entries.Kind != yaml.SequenceNode {
+		return nil, nil, fmt.Errorf("template has no entries")
+	}
+
+	var packages []string
+	for _, e := range entries.Content {
+		if scalarValue(e, "schema") == declcfg.SchemaPackage {
+			packages = append(packages, scalarValue(e, "name"))
+		}
+	}
+	switch {
+	case packageName == "" && len(packages) == 1:
+		packageName = packages[0]
+	case packageName == "":
+		return nil, nil, fmt.Errorf("template has %d packages, the package to deprecate in must be given", len(packages))
+	case !slices.Contains(packages, packageName):
+		return nil, nil, fmt.Errorf("package %q not found in template", packageName)
+	}
+
+	// Bundles without a name in an olm.bundle entry are named after
+	// their CSV when rendered, and carry no package in a basic
+	// template, so this package's channel entries are the only
+	// reliable list of its bundle names.
+	var channels, bundles []string
+	var deprecations *yaml.Node
+	index := len(entries.Content)
+	for i, e := range entries.Content {
+		if scalarValue(e, "package") != packageName {
+			continue
+		}
+		switch scalarValue(e, "schema") {
+		case declcfg.SchemaChannel:
+			channels = append(channels, scalarValue(e, "name"))
+			if list := mappingValue(e, "entries"); list != nil {
+				for _, entry := range list.Content {
+					if name := scalarValue(entry, "name"); name != "" && !slices.Contains(bundles, name) {
+						bundles = append(bundles, name)
+					}
+				}
+			}
+		case declcfg.SchemaDeprecation:
+			deprecations, index = e, i
+		}
+	}
+
+	var refs []declcfg.PackageScopedReference
+	if targets.Package {
+		refs = append(refs, declcfg.PackageScopedReference{Schema: declcfg.SchemaPackage})
+	}
+	for _, ch := range targets.Channels {
+		if !slices.Contains(channels, ch) {
+			return nil, nil, fmt.Errorf("channel %q not found in package %s (channels: %s)", ch, packageName, strings.Join(channels, ", "))
+		}
+		refs = append(refs, declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: ch})
+	}
+	for _, b := range targets.Bundles {
+		name, err := resolveBundleName(bundles, b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("package %s: %w", packageName, err)
+		}
+		refs = append(refs, declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: name})
+	}
+
+	if deprecations == nil {
+		deprecations = newMapping(
+			"schema", scalarNode(declcfg.SchemaDeprecation),
+			"package", scalarNode(packageName),
+			"entries", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"},
+		)
+		entries.Content = append(entries.Content, deprecations)
+	}
+	list := mappingValue(deprecations, "entries")
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		deprecations.Content = append(deprecations.Content, scalarNode("entries"), list)
+	}
+
+	var applied []Deprecation
+	for _, ref := range refs {
+		var existing *yaml.Node
+		for _, entry := range list.Content {
+			reference := mappingValue(entry, "reference")
+			if reference != nil && scalarValue(reference, "schema") == ref.Schema && scalarValue(reference, "name") == ref.Name {
+				existing = entry
+				break
+			}
+		}
+		if existing != nil {
+			if msg := mappingValue(existing, "message"); msg != nil {
+				msg.Value = message
+			} else {
+				existing.Content = append(existing.Content, scalarNode("message"), scalarNode(message))
+			}
+		} else {
+			reference := newMapping("schema", scalarNode(ref.Schema))
+			if ref.Name != "" {
+				reference.Content = append(reference.Content, scalarNode("name"), scalarNode(ref.Name))
+			}
+			list.Content = append(list.Content, newMapping("reference", reference, "message", scalarNode(message)))
+		}
+		applied = append(applied, Deprecation{Scope: strings.TrimPrefix(ref.Schema, "olm."), Name: ref.Name, Message: message})
+	}
+
+	// Re-encoding the whole template would reflow long scalars such
+	// as the icon and realign comments, so only the olm.deprecations
+	// entry is written back where the layout allows it.
+	if out, ok := spliceEntry(data, root, entries, index, deprecations); ok {
+		return out, applied, nil
+	}
+	out, err := encodeYAML(&doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding template: %w", err)
+	}
+	return out, applied, nil
+}
+
+// spliceEntry writes a template entry back into the original template
+// text, replacing the lines of entries[index] or, for an entry that
+// was not parsed from the text, adding it after the last entry. It
+// reports false if the template is not laid out as a block sequence
+// it can splice into.
+func spliceEntry(data []byte, root, entries *yaml.Node, index int, entry *yaml.Node) ([]byte, bool) {
+	added := entry.Line == 0
+	if entries.Style&yaml.FlowStyle != 0 || len(entries.Content) == 0 || entries.Content[0].Column < 3 {
+		return nil, false
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// The entries end at the next top-level key or the end of the
+	// file, less any trailing blank lines and comments, which are
+	// left where they are.
+	end := len(lines)
+	if !added && index+1 < len(entries.Content) {
+		end = entries.Content[index+1].Line - 1
+	} else {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if line := root.Content[i].Line - 1; line > entries.Content[0].Line-1 && line < end {
+				end = line
+			}
+		}
+	}
+	for end > 0 {
+		trimmed := strings.TrimSpace(lines[end-1])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		end--
+	}
+
+	start := end
+	if !added {
+		start = entry.Line - 1
+		if entry.Style&yaml.FlowStyle != 0 || start >= end {
+			return nil, false
+		}
+	}
+
+	// Comments before the entry and after its last line stay in the
+	// surrounding text, and yaml.v3 attaches the latter as foot
+	// comments anywhere in the entry.
+	entry.HeadComment = ""
+	clearFootComments(entry)
+	fragment, err := encodeYAML(&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{entry}})
+	if err != nil {
+		return nil, false
+	}
+	indent := strings.Repeat(" ", entries.Content[0].Column-3)
+	var b strings.Builder
+	for _, line := range lines[:start] {
+		b.WriteString(line)
+	}
+	if start > 0 && !strings.HasSuffix(lines[start-1], "\n") {
+		b.WriteString("\n")
+	}
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(string(fragment), "\n"), "\n") {
+		b.WriteString(indent + line)
+	}
+	b.WriteString("\n")
+	for _, line := range lines[end:] {
+		b.WriteString(line)
+	}
+	return []byte(b.String()), true
+}
+
+// clearFootComments removes the foot comments of a node and its
+// descendants.
+func clearFootComments(node *yaml.Node) {
+	node.FootComment = ""
+	for _, n := range node.Content {
+		clearFootComments(n)
+	}
+}
+
+// encodeYAML encodes a node with the two-space indentation the
+// templates use.
+func encodeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resolveBundleName matches a bundle given by name or by version
+// against the bundle names in a package.
+func resolveBundleName(names []string, bundle string) (string, error) {
+	if slices.Contains(names, bundle) {
+		return bundle, nil
+	}
+	suffix := ".v" + strings.TrimPrefix(bundle, "v")
+	var matches []string
+	for _, name := range names {
+		if strings.HasSuffix(name, suffix) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return "", fmt.Errorf("bundle %q not found (bundles: %s)", bundle, strings.Join(names, ", "))
+	default:
+		return "", fmt.Errorf("bundle %q is ambiguous (matches: %s)", bundle, strings.Join(matches, ", "))
+	}
+}
+
+// scalarValue returns the scalar value for key in a mapping node, or
+// "" if the key is absent.
+func scalarValue(m *yaml.Node, key string) string {
+	if n := mappingValue(m, key); n != nil && n.Kind == yaml.ScalarNode {
+		return n.Value
+	}
+	return ""
+}
+
+// newMapping builds a mapping node from alternating keys and value
+// nodes.
+func newMapping(kv ...any) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(kv); i += 2 {
+		m.Content = append(m.Content, scalarNode(kv[i].(string)), kv[i+1].(*yaml.Node))
+	}
+	return m
+}
+
+// scalarNode builds a string scalar node.
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,186 @@
+package catalog
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"sigs.k8s.io/yaml"
+)
+
+const deprecateTemplate = `schema: olm.template.basic
+entries:
+  - schema: olm.package
+    name: bpfman-operator
+    defaultChannel: stable
+  - schema: olm.channel
+    package: bpfman-operator
+    name: stable
+    entries:
+      - name: bpfman-operator.v0.5.8
+      - name: bpfman-operator.v0.5.9
+        replaces: bpfman-operator.v0.5.8
+  - schema: olm.bundle          # 0.5.8
+    image: quay.io/bpfman/bundle:v0.5.8
+  - schema: olm.bundle          # 0.5.9
+    image: quay.io/bpfman/bundle:v0.5.9
+`
+
+// TestDeprecate tests that olm.deprecations entries are added to or
+// updated in a template without disturbing the rest of it.
+func TestDeprecate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		pkg      string
+		targets  DeprecationTargets
+		message  string
+		want     []declcfg.DeprecationEntry
+		wantErr  string
+	}{
+		{
+			name:     "bundle by version and channel",
+			template: deprecateTemplate,
+			targets:  DeprecationTargets{Channels: []string{"stable"}, Bundles: []string{"0.5.8"}},
+			message:  "0.5.8 is no longer supported",
+			want: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "stable"}, Message: "0.5.8 is no longer supported"},
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "bpfman-operator.v0.5.8"}, Message: "0.5.8 is no longer supported"},
+			},
+		},
+		{
+			name: "existing entry updated",
+			template: deprecateTemplate + `  - schema: olm.deprecations
+    package: bpfman-operator
+    entries:
+      - reference:
+          schema: olm.bundle
+          name: bpfman-operator.v0.5.8
+        message: old message
+`,
+			targets: DeprecationTargets{Package: true, Bundles: []string{"bpfman-operator.v0.5.8"}},
+			message: "retired",
+			want: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "bpfman-operator.v0.5.8"}, Message: "retired"},
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaPackage}, Message: "retired"},
+			},
+		},
+		{
+			name:     "unknown bundle",
+			template: deprecateTemplate,
+			targets:  DeprecationTargets{Bundles: []string{"0.4.0"}},
+			message:  "retired",
+			wantErr:  `bundle "0.4.0" not found`,
+		},
+		{
+			name:     "unknown channel",
+			template: deprecateTemplate,
+			targets:  DeprecationTargets{Channels: []string{"fast"}},
+			message:  "retired",
+			wantErr:  `channel "fast" not found`,
+		},
+		{
+			name: "bundle of another package",
+			template: deprecateTemplate + `  - schema: olm.package
+    name: other-operator
+    defaultChannel: stable
+  - schema: olm.channel
+    package: other-operator
+    name: stable
+    entries:
+      - name: other-operator.v1.0.0
+  - schema: olm.bundle
+    name: other-operator.v1.0.0
+    image: quay.io/other/bundle:v1.0.0
+`,
+			pkg:     "bpfman-operator",
+			targets: DeprecationTargets{Bundles: []string{"other-operator.v1.0.0"}},
+			message: "retired",
+			wantErr: `bundle "other-operator.v1.0.0" not found`,
+		},
+		{
+			name:     "semver template",
+			template: "schema: olm.semver\nstable:\n  bundles:\n    - image: quay.io/bpfman/bundle:v0.5.8\n",
+			targets:  DeprecationTargets{Package: true},
+			message:  "retired",
+			wantErr:  "cannot hold olm.deprecations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, err := Deprecate([]byte(tt.template), tt.pkg, tt.targets, tt.message)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The rest of the template, comments included, is kept
+			// as written.
+			original, _, _ := strings.Cut(tt.template, "  - schema: olm.deprecations")
+			if !strings.HasPrefix(string(out), original) {
+				t.Errorf("template not preserved:\n%s", out)
+			}
+
+			var parsed struct {
+				Entries []declcfg.Deprecation `json:"entries"`
+			}
+			if err := yaml.Unmarshal(out, &parsed); err != nil {
+				t.Fatalf("parsing edited template: %v", err)
+			}
+			var got []declcfg.DeprecationEntry
+			for _, e := range parsed.Entries {
+				if e.Schema == declcfg.SchemaDeprecation {
+					if e.Package != "bpfman-operator" {
+						t.Errorf("deprecations for package %q", e.Package)
+					}
+					got = append(got, e.Entries...)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got entries %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBundleDeprecations tests that a bundle picks up the
+// deprecations of its package, its channels and itself.
+func TestBundleDeprecations(t *testing.T) {
+	cfg := &declcfg.DeclarativeConfig{
+		Channels: []declcfg.Channel{
+			{Package: "bpfman-operator", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "bpfman-operator.v0.5.8"}, {Name: "bpfman-operator.v0.5.9"}}},
+			{Package: "bpfman-operator", Name: "fast", Entries: []declcfg.ChannelEntry{{Name: "bpfman-operator.v0.5.9"}}},
+		},
+		Deprecations: []declcfg.Deprecation{{
+			Package: "bpfman-operator",
+			Entries: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "fast"}, Message: "use stable"},
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "bpfman-operator.v0.5.8"}, Message: "upgrade to 0.5.9"},
+			},
+		}},
+	}
+
+	tests := []struct {
+		bundle string
+		want   []string
+	}{
+		{bundle: "bpfman-operator.v0.5.8", want: []string{"bundle bpfman-operator.v0.5.8: upgrade to 0.5.9"}},
+		{bundle: "bpfman-operator.v0.5.9", want: []string{"channel fast: use stable"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, d := range BundleDeprecations(cfg, "bpfman-operator", tt.bundle) {
+			got = append(got, d.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.bundle, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// ChannelGraph is the update graph of one channel.
+type ChannelGraph struct {
+	Package string
+	Channel string
+	Default bool // Whether this is the package's default channel
+	Nodes   []GraphNode
+	Edges   []UpgradeHop
+}
+
+// GraphNode is a bundle in a channel graph.
+type GraphNode struct {
+	Name    string
+	Version string
+	Digest  string // Short digest, or tag if the image is not pinned
+	Head    bool
+}
+
+// BuildChannelGraphs returns the update graph of each channel of a
+// package. If channelName is set only that channel is included.
+// Edges follow the upgrade direction, from the bundle being replaced,
+// skipped or covered by a skipRange to the bundle that upgrades it.
+func BuildChannelGraphs(cfg *declcfg.DeclarativeConfig, packageName, channelName string) ([]ChannelGraph, error) {
+	defaultChannel, _ := DefaultChannel(cfg, packageName)
+
+	images := make(map[string]string)
+	for _, b := range cfg.Bundles {
+		if b.Package == packageName {
+			images[b.Name] = b.Image
+		}
+	}
+
+	var graphs []ChannelGraph
+	for i := range cfg.Channels {
+		ch := &cfg.Channels[i]
+		if ch.Package != packageName || (channelName != "" && ch.Name != channelName) {
+			continue
+		}
+
+		g := newChannelEdges(cfg, ch)
+		head, _ := ChannelHead(ch) // A channel with several heads is drawn without one.
+
+		graph := ChannelGraph{
+			Package: packageName,
+			Channel: ch.Name,
+			Default: ch.Name == defaultChannel,
+		}
+		for _, e := range ch.Entries {
+			node := GraphNode{
+				Name:   e.Name,
+				Digest: shortImageID(images[e.Name]),
+				Head:   e.Name == head,
+			}
+			if v, ok := g.versions[e.Name]; ok {
+				node.Version = v.String()
+			}
+			graph.Nodes = append(graph.Nodes, node)
+
+			for _, from := range ch.Entries {
+				if from.Name == e.Name {
+					continue
+				}
+				if hop, ok := g.edge(from.Name, e); ok {
+					graph.Edges = append(graph.Edges, hop)
+				}
+			}
+		}
+		graphs = append(graphs, graph)
+	}
+
+	if len(graphs) == 0 {
+		if channelName != "" {
+			return nil, fmt.Errorf("channel %s not found for package %s", channelName, packageName)
+		}
+		return nil, fmt.Errorf("no channels found for package %s", packageName)
+	}
+	return graphs, nil
+}
+
+// shortImageID returns the first 12 characters of an image's digest,
+// or its tag if it is not pinned by digest.
+func shortImageID(image string) string {
+	if _, digest, ok := strings.Cut(image, "@"); ok {
+		digest = strings.TrimPrefix(digest, "sha256:")
+		if len(digest) > 12 {
+			digest = digest[:12]
+		}
+		return digest
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// label returns the display label of a node: its version, or name if
+// the version is unknown, and short digest.
+func (n GraphNode) label(sep string) string {
+	label := n.Version
+	if label == "" {
+		label = n.Name
+	}
+	if n.Digest != "" {
+		label += sep + n.Digest
+	}
+	return label
+}
+
+// FormatGraphs renders channel graphs as Graphviz DOT or Mermaid.
+func FormatGraphs(graphs []ChannelGraph, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "dot":
+		return formatDOT(graphs), nil
+	case "mermaid":
+		return formatMermaid(graphs), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: dot, mermaid)", format)
+	}
+}
+
+func channelTitle(g ChannelGraph) string {
+	if g.Default {
+		return g.Channel + " (default)"
+	}
+	return g.Channel
+}
+
+func formatDOT(graphs []ChannelGraph) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("digraph %q {\n", graphs[0].Package))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	for _, g := range graphs {
+		id := func(name string) string { return fmt.Sprintf("%q", g.Channel+"/"+name) }
+
+		b.WriteString(fmt.Sprintf("\n  subgraph %q {\n", "cluster_"+g.Channel))
+		b.WriteString(fmt.Sprintf("    label=%q;\n", channelTitle(g)))
+		if g.Default {
+			b.WriteString("    style=bold;\n")
+		}
+		for _, n := range g.Nodes {
+			attrs := fmt.Sprintf("label=%q", n.label("\n"))
+			if n.Head {
+				attrs += `, style="filled,bold", fillcolor="palegreen"`
+			}
+			b.WriteString(fmt.Sprintf("    %s [%s];\n", id(n.Name), attrs))
+		}
+		for _, e := range g.Edges {
+			attrs := fmt.Sprintf("label=%q", e.Edge)
+			switch e.Edge {
+			case EdgeSkips:
+				attrs += ", style=dashed"
+			case EdgeSkipRange:
+				attrs += ", style=dotted"
+			}
+			b.WriteString(fmt.Sprintf("    %s -> %s [%s];\n", id(e.From), id(e.To), attrs))
+		}
+		b.WriteString("  }\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func formatMermaid(graphs []ChannelGraph) string {
+	var b strings.Builder
+
+	b.WriteString("flowchart LR\n")
+	for ci, g := range graphs {
+		ids := make(map[string]string)
+		for ni, n := range g.Nodes {
+			ids[n.Name] = fmt.Sprintf("c%dn%d", ci, ni)
+		}
+
+		b.WriteString(fmt.Sprintf("  subgraph c%d[%q]\n", ci, channelTitle(g)))
+		for _, n := range g.Nodes {
+			b.WriteString(fmt.Sprintf("    %s[%q]\n", ids[n.Name], n.label("<br/>")))
+		}
+		b.WriteString("  end\n")
+		for _, e := range g.Edges {
+			arrow := "-->"
+			if e.Edge != EdgeReplaces {
+				arrow = "-.->"
+			}
+			b.WriteString(fmt.Sprintf("  %s %s|%s| %s\n", ids[e.From], arrow, e.Edge, ids[e.To]))
+		}
+		for _, n := range g.Nodes {
+			if n.Head {
+				b.WriteString(fmt.Sprintf("  class %s head\n", ids[n.Name]))
+			}
+		}
+		if g.Default {
+			b.WriteString(fmt.Sprintf("  class c%d defaultChannel\n", ci))
+		}
+	}
+	b.WriteString("  classDef head fill:#98fb98,stroke:#333,stroke-width:3px\n")
+	b.WriteString("  classDef defaultChannel stroke-width:3px\n")
+
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// TestFormatGraphs tests that nodes, edges, the channel head and the
+// default channel are rendered.
+func TestFormatGraphs(t *testing.T) {
+	cfg := testUpgradeCatalog(
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.9", Replaces: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.10", Replaces: "bpfman-operator.v0.5.9", SkipRange: ">=0.5.8 <0.5.10"},
+	)
+	cfg.Bundles[2].Image = "quay.io/bpfman/bpfman-operator-bundle@sha256:f015580da52da53c9fa9e5629804b9ac6b3026208d271f8d7ac0bd55f887bad7"
+
+	graphs, err := BuildChannelGraphs(cfg, "bpfman-operator", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			format: "dot",
+			want: []string{
+				`label="stable (default)";`,
+				`"stable/bpfman-operator.v0.5.10" [label="0.5.10\nf015580da52d", style="filled,bold", fillcolor="palegreen"];`,
+				`"stable/bpfman-operator.v0.5.8" -> "stable/bpfman-operator.v0.5.9" [label="replaces"];`,
+				`"stable/bpfman-operator.v0.5.8" -> "stable/bpfman-operator.v0.5.10" [label="skipRange", style=dotted];`,
+				`"stable/bpfman-operator.v0.5.9" -> "stable/bpfman-operator.v0.5.10" [label="replaces"];`,
+			},
+		},
+		{
+			format: "mermaid",
+			want: []string{
+				`subgraph c0["stable (default)"]`,
+				`c0n2["0.5.10<br/>f015580da52d"]`,
+				`c0n0 -->|replaces| c0n1`,
+				`c0n0 -.->|skipRange| c0n2`,
+				`class c0n2 head`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := FormatGraphs(graphs, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
+	"github.com/sirupsen/logrus"
+)
+
+// Load loads a file-based catalog from a catalog file, a directory of
+// catalog files or a catalog image. Paths that exist locally take
+// precedence over image references.
+func Load(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	info, err := os.Stat(ref)
+	switch {
+	case err == nil && info.IsDir():
+		cfg, err := declcfg.LoadFS(ctx, os.DirFS(ref))
+		if err != nil {
+			return nil, fmt.Errorf("loading catalog from %s: %w", ref, err)
+		}
+		return cfg, nil
+	case err == nil:
+		cfg, err := declcfg.LoadFile(os.DirFS(filepath.Dir(ref)), filepath.Base(ref))
+		if err != nil {
+			return nil, fmt.Errorf("loading catalog from %s: %w", ref, err)
+		}
+		return cfg, nil
+	}
+
+	return LoadImage(ctx, ref)
+}
+
+// LoadImage pulls and unpacks a catalog image and loads the
+// file-based catalog from its configs directory.
+func LoadImage(ctx context.Context, imageRef string) (*declcfg.DeclarativeConfig, error) {
+	tmpDir, err := os.MkdirTemp("", "catalog-extract-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	registry, err := newRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer registry.Destroy()
+
+	imgRef := image.SimpleReference(imageRef)
+
+	if err := registry.Pull(ctx, imgRef); err != nil {
+		return nil, fmt.Errorf("pulling catalog image: %w", err)
+	}
+
+	if err := registry.Unpack(ctx, imgRef, tmpDir); err != nil {
+		return nil, fmt.Errorf("unpacking catalog image: %w", err)
+	}
+
+	labels, err := registry.Labels(ctx, imgRef)
+	if err != nil {
+		return nil, fmt.Errorf("getting image labels: %w", err)
+	}
+
+	configsDir := "/configs" // Default location.
+	if loc, ok := labels[containertools.ConfigsLocationLabel]; ok {
+		configsDir = loc
+	}
+
+	configsPath := filepath.Join(tmpDir, configsDir)
+	if _, err := os.Stat(configsPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("configs directory not found at %s", configsPath)
+	}
+
+	cfg, err := declcfg.LoadFS(ctx, os.DirFS(configsPath))
+	if err != nil {
+		return nil, fmt.Errorf("loading FBC catalog from %s: %w", configsPath, err)
+	}
+
+	return cfg, nil
+}
+
+// newRegistry returns an image registry backed by podman, falling
+// back to docker.
+func newRegistry() (*execregistry.Registry, error) {
+	logger := logrus.NewEntry(logrus.New())
+	logger.Logger.SetLevel(logrus.ErrorLevel) // Minimise logging noise.
+
+	registry, err := execregistry.NewRegistry(containertools.PodmanTool, logger)
+	if err != nil {
+		registry, err = execregistry.NewRegistry(containertools.DockerTool, logger)
+		if err != nil {
+			return nil, fmt.Errorf("creating container registry client: %w", err)
+		}
+	}
+	return registry, nil
+}
@@ -3,18 +3,13 @@ package catalog
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/types"
 	"github.com/opencontainers/go-digest"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
-	"github.com/operator-framework/operator-registry/pkg/containertools"
-	"github.com/operator-framework/operator-registry/pkg/image"
-	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
-	"github.com/sirupsen/logrus"
 )
 
 // ImageMetadata contains metadata extracted from an image reference.
@@ -29,6 +24,7 @@ type ImageMetadata struct {
 	CatalogType    string        // e.g., catalog-ystream, catalog-zstream
 	DefaultChannel string        // Default channel from catalog
 	Channels       []string      // Available channels
+	BundleImages   []string      // Bundle and related images referenced by the catalog
 }
 
 // ExtractMetadata extracts metadata from an image reference. If the
@@ -43,9 +39,11 @@ func ExtractMetadata(ctx context.Context, imageRef string) (*ImageMetadata, erro
 	}
 
 	if meta.Digest == "" {
-		if err := fetchDigest(ctx, imageRef, meta); err != nil {
+		d, err := FetchDigest(ctx, imageRef, nil)
+		if err != nil {
 			return nil, fmt.Errorf("fetching digest: %w", err)
 		}
+		meta.Digest = d
 	}
 
 	if meta.Digest != "" {
@@ -137,82 +135,52 @@ func parseImageReference(imageRef string, meta *ImageMetadata) error {
 	return nil
 }
 
-// fetchDigest resolves the digest for an image reference using
-// containers/image.
-func fetchDigest(ctx context.Context, imageRef string, meta *ImageMetadata) error {
-	sysCtx := &types.SystemContext{}
+// FetchDigest resolves the manifest digest of a registry image
+// reference using containers/image. A nil sysCtx uses the default
+// registry configuration and credentials.
+func FetchDigest(ctx context.Context, imageRef string, sysCtx *types.SystemContext) (digest.Digest, error) {
+	if sysCtx == nil {
+		sysCtx = &types.SystemContext{}
+	}
 
-	ref, err := docker.ParseReference("//" + imageRef)
+	ref, err := docker.ParseReference("//" + strings.TrimPrefix(imageRef, "docker://"))
 	if err != nil {
-		return fmt.Errorf("parsing image reference: %w", err)
+		return "", fmt.Errorf("parsing image reference: %w", err)
 	}
 
 	src, err := ref.NewImageSource(ctx, sysCtx)
 	if err != nil {
-		return fmt.Errorf("creating image source: %w", err)
+		return "", fmt.Errorf("creating image source: %w", err)
 	}
 	defer src.Close()
 
 	manifestBlob, _, err := src.GetManifest(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("getting manifest: %w", err)
+		return "", fmt.Errorf("getting manifest: %w", err)
 	}
 
-	manifestDigest := digest.FromBytes(manifestBlob)
-	meta.Digest = manifestDigest
-
-	return nil
+	return digest.FromBytes(manifestBlob), nil
 }
 
-// extractChannelInfo inspects the FBC catalog image to determine
-// available channels.
-func extractChannelInfo(ctx context.Context, imageRef string, meta *ImageMetadata) error {
-	tmpDir, err := os.MkdirTemp("", "catalog-extract-*")
-	if err != nil {
-		return fmt.Errorf("creating temporary directory: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	logger := logrus.NewEntry(logrus.New())
-	logger.Logger.SetLevel(logrus.ErrorLevel) // Minimise logging noise.
-
-	registry, err := execregistry.NewRegistry(containertools.PodmanTool, logger)
-	if err != nil {
-		registry, err = execregistry.NewRegistry(containertools.DockerTool, logger)
-		if err != nil {
-			return fmt.Errorf("creating container registry client: %w", err)
-		}
-	}
-	defer registry.Destroy()
-
-	imgRef := image.SimpleReference(imageRef)
-
-	if err := registry.Pull(ctx, imgRef); err != nil {
-		return fmt.Errorf("pulling catalog image: %w", err)
-	}
-
-	if err := registry.Unpack(ctx, imgRef, tmpDir); err != nil {
-		return fmt.Errorf("unpacking catalog image: %w", err)
-	}
-
-	labels, err := registry.Labels(ctx, imgRef)
+// ResolveDigest returns the manifest digest of an image reference:
+// the digest it names, or the one the registry serves for its tag.
+func ResolveDigest(ctx context.Context, imageRef string) (digest.Digest, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageRef, "docker://"))
 	if err != nil {
-		return fmt.Errorf("getting image labels: %w", err)
+		return "", fmt.Errorf("parsing image reference: %w", err)
 	}
-
-	configsDir := "/configs" // Default location.
-	if loc, ok := labels[containertools.ConfigsLocationLabel]; ok {
-		configsDir = loc
-	}
-
-	configsPath := filepath.Join(tmpDir, configsDir)
-	if _, err := os.Stat(configsPath); os.IsNotExist(err) {
-		return fmt.Errorf("configs directory not found at %s", configsPath)
+	if canonical, ok := named.(reference.Canonical); ok {
+		return canonical.Digest(), nil
 	}
+	return FetchDigest(ctx, imageRef, nil)
+}
 
-	cfg, err := declcfg.LoadFS(ctx, os.DirFS(configsPath))
+// extractChannelInfo inspects the FBC catalog image to determine
+// available channels.
+func extractChannelInfo(ctx context.Context, imageRef string, meta *ImageMetadata) error {
+	cfg, err := LoadImage(ctx, imageRef)
 	if err != nil {
-		return fmt.Errorf("loading FBC catalog from %s: %w", configsPath, err)
+		return err
 	}
 
 	var bpfmanPackage *declcfg.Package
@@ -248,5 +216,22 @@ func extractChannelInfo(ctx context.Context, imageRef string, meta *ImageMetadat
 		meta.DefaultChannel = meta.Channels[0]
 	}
 
+	seen := make(map[string]bool)
+	addImage := func(img string) {
+		if img != "" && !seen[img] {
+			seen[img] = true
+			meta.BundleImages = append(meta.BundleImages, img)
+		}
+	}
+	for _, b := range cfg.Bundles {
+		if b.Package != "bpfman-operator" {
+			continue
+		}
+		addImage(b.Image)
+		for _, related := range b.RelatedImages {
+			addImage(related.Image)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,255 @@
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaSemverTemplate is the schema of an opm semver catalog
+// template.
+const SchemaSemverTemplate = "olm.semver"
+
+// Semver channel archetypes, in order of increasing stability.
+const (
+	SemverCandidate = "candidate"
+	SemverFast      = "fast"
+	SemverStable    = "stable"
+)
+
+// SemverArchetypes lists the channel archetypes a semver template
+// supports.
+var SemverArchetypes = []string{SemverCandidate, SemverFast, SemverStable}
+
+// SemverTemplate is an olm.semver catalog template. opm generates
+// the package, its channels and their upgrade edges from the
+// versions of the listed bundles. The field names follow opm, which
+// reads the template strictly, so no other fields may be added.
+type SemverTemplate struct {
+	Schema                       string          `json:"schema"`
+	GenerateMajorChannels        bool            `json:"generateMajorChannels,omitempty"`
+	GenerateMinorChannels        bool            `json:"generateMinorChannels,omitempty"`
+	DefaultChannelTypePreference string          `json:"defaultChannelTypePreference,omitempty"`
+	Candidate                    *SemverChannels `json:"candidate,omitempty"`
+	Fast                         *SemverChannels `json:"fast,omitempty"`
+	Stable                       *SemverChannels `json:"stable,omitempty"`
+}
+
+// SemverChannels lists the bundles contributing to one channel
+// archetype.
+type SemverChannels struct {
+	Bundles []SemverBundle `json:"bundles,omitempty"`
+}
+
+// SemverBundle is a bundle entry of a semver template.
+type SemverBundle struct {
+	Image string `json:"image"`
+}
+
+// Archetype returns the bundle list for a channel archetype,
+// creating it if needed.
+func (t *SemverTemplate) Archetype(name string) (*SemverChannels, error) {
+	var field **SemverChannels
+	switch name {
+	case SemverCandidate:
+		field = &t.Candidate
+	case SemverFast:
+		field = &t.Fast
+	case SemverStable:
+		field = &t.Stable
+	default:
+		return nil, fmt.Errorf("unknown semver channel %q (supported: %s)", name, strings.Join(SemverArchetypes, ", "))
+	}
+	if *field == nil {
+		*field = &SemverChannels{}
+	}
+	return *field, nil
+}
+
+// AddBundle adds a bundle image to a channel archetype unless it is
+// already listed there.
+func (t *SemverTemplate) AddBundle(archetype, image string) error {
+	channels, err := t.Archetype(archetype)
+	if err != nil {
+		return err
+	}
+	for _, b := range channels.Bundles {
+		if b.Image == image {
+			return nil
+		}
+	}
+	channels.Bundles = append(channels.Bundles, SemverBundle{Image: image})
+	return nil
+}
+
+// BundleImages returns the distinct bundle images in the template,
+// in the order they are first listed.
+func (t *SemverTemplate) BundleImages() []string {
+	var images []string
+	for _, channels := range []*SemverChannels{t.Candidate, t.Fast, t.Stable} {
+		if channels == nil {
+			continue
+		}
+		for _, b := range channels.Bundles {
+			if !slices.Contains(images, b.Image) {
+				images = append(images, b.Image)
+			}
+		}
+	}
+	return images
+}
+
+// SemverConversion is the result of converting a basic template to
+// the semver form.
+type SemverConversion struct {
+	Template *SemverTemplate
+	Warnings []string // Information the semver form cannot express
+}
+
+// basicTemplate is the subset of an olm.template.basic template that
+// ConvertToSemver reads.
+type basicTemplate struct {
+	Schema  string `yaml:"schema"`
+	Entries []struct {
+		Schema         string `yaml:"schema"`
+		Name           string `yaml:"name"`
+		Image          string `yaml:"image"`
+		DefaultChannel string `yaml:"defaultChannel"`
+		Icon           any    `yaml:"icon"`
+		Description    string `yaml:"description"`
+		Entries        []struct {
+			Name      string   `yaml:"name"`
+			Replaces  string   `yaml:"replaces"`
+			Skips     []string `yaml:"skips"`
+			SkipRange string   `yaml:"skipRange"`
+		} `yaml:"entries"`
+	} `yaml:"entries"`
+}
+
+// ConvertToSemver converts a basic catalog template to the semver
+// form. Each basic channel is mapped to the archetype its name starts
+// with ("stable", "stable-v0.5" and so on); bundles in channels that
+// match no archetype are placed in the fallback archetype. Upgrade
+// edges are not carried over since opm derives them from the bundle
+// versions, so the differences are reported as warnings.
+func ConvertToSemver(data []byte, fallback string, major, minor bool) (*SemverConversion, error) {
+	var basic basicTemplate
+	if err := yaml.Unmarshal(data, &basic); err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	if basic.Schema != SchemaBasicTemplate {
+		return nil, fmt.Errorf("unsupported template schema %q (expected %s)", basic.Schema, SchemaBasicTemplate)
+	}
+	if !slices.Contains(SemverArchetypes, fallback) {
+		return nil, fmt.Errorf("unknown semver channel %q (supported: %s)", fallback, strings.Join(SemverArchetypes, ", "))
+	}
+
+	conv := &SemverConversion{
+		Template: &SemverTemplate{
+			Schema:                SchemaSemverTemplate,
+			GenerateMajorChannels: major,
+			GenerateMinorChannels: minor,
+		},
+	}
+	warn := func(format string, args ...any) {
+		conv.Warnings = append(conv.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	// archetypes maps each channel entry name to the archetypes of
+	// the channels listing it.
+	archetypes := make(map[string][]string)
+	var channelArchetypes []string
+	for _, e := range basic.Entries {
+		switch e.Schema {
+		case declcfg.SchemaPackage:
+			warn("package %s: defaultChannel %q is replaced by the channel opm selects as most stable", e.Name, e.DefaultChannel)
+			if e.Icon != nil || e.Description != "" {
+				warn("package %s: icon and description are not part of the semver template", e.Name)
+			}
+		case declcfg.SchemaChannel:
+			archetype := channelArchetype(e.Name)
+			if archetype == "" {
+				archetype = fallback
+				warn("channel %s: no matching semver channel, bundles placed in %s", e.Name, fallback)
+			}
+			if !slices.Contains(channelArchetypes, archetype) {
+				channelArchetypes = append(channelArchetypes, archetype)
+			}
+			replaces := false
+			for _, entry := range e.Entries {
+				replaces = replaces || entry.Replaces != ""
+				if !slices.Contains(archetypes[entry.Name], archetype) {
+					archetypes[entry.Name] = append(archetypes[entry.Name], archetype)
+				}
+				if len(entry.Skips) > 0 || entry.SkipRange != "" {
+					warn("channel %s: skips and skipRange of %s are dropped", e.Name, entry.Name)
+				}
+			}
+			warn("channel %s: replaced by generated %s channels", e.Name, semverChannelNames(archetype, major, minor))
+			if replaces {
+				warn("channel %s: replaces edges are regenerated by opm from the bundle versions", e.Name)
+			}
+		}
+	}
+
+	for _, e := range basic.Entries {
+		if e.Schema != declcfg.SchemaBundle {
+			continue
+		}
+		if e.Image == "" {
+			return nil, fmt.Errorf("olm.bundle %s has no image", e.Name)
+		}
+
+		// Bundles without a name are named after their CSV when
+		// rendered, so they can only be placed without pulling them
+		// when every channel maps to the same archetype.
+		targets := archetypes[e.Name]
+		switch {
+		case e.Name != "" && len(targets) == 0:
+			warn("bundle %s is in no channel and is dropped", e.Name)
+		case e.Name == "" && len(channelArchetypes) == 1:
+			targets = channelArchetypes
+		case e.Name == "":
+			targets = []string{fallback}
+			warn("bundle %s has no name, so its channel is unknown; placed in %s", e.Image, fallback)
+		}
+		for _, archetype := range targets {
+			if err := conv.Template.AddBundle(archetype, e.Image); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(conv.Template.BundleImages()) == 0 {
+		return nil, fmt.Errorf("template has no bundles in any channel")
+	}
+
+	return conv, nil
+}
+
+// semverChannelNames describes the channel names opm generates for
+// an archetype.
+func semverChannelNames(archetype string, major, minor bool) string {
+	var names []string
+	if major {
+		names = append(names, archetype+"-v<major>")
+	}
+	if minor || !major {
+		names = append(names, archetype+"-v<major>.<minor>")
+	}
+	return strings.Join(names, " and ")
+}
+
+// channelArchetype returns the semver archetype a basic channel name
+// corresponds to, or "" if there is none.
+func channelArchetype(channel string) string {
+	for _, archetype := range SemverArchetypes {
+		if channel == archetype || strings.HasPrefix(channel, archetype+"-") {
+			return archetype
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/alpha/template/semver"
+	"sigs.k8s.io/yaml"
+)
+
+// TestConvertToSemver tests the mapping of basic channels and
+// bundles onto semver archetypes.
+func TestConvertToSemver(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     string
+		wantStable   []string
+		wantFast     []string
+		wantCand     []string
+		wantWarnings []string
+		wantErr      string
+	}{
+		{
+			name: "unnamed bundles in a single channel",
+			template: `schema: olm.template.basic
+entries:
+  - schema: olm.package
+    name: bpfman-operator
+    defaultChannel: stable
+  - schema: olm.channel
+    package: bpfman-operator
+    name: stable
+    entries:
+      - name: bpfman-operator.v0.5.8
+      - name: bpfman-operator.v0.5.9
+        replaces: bpfman-operator.v0.5.8
+  - schema: olm.bundle          # 0.5.8
+    image: quay.io/bpfman/bundle:v0.5.8
+  - schema: olm.bundle          # 0.5.9
+    image: quay.io/bpfman/bundle:v0.5.9
+`,
+			wantStable:   []string{"quay.io/bpfman/bundle:v0.5.8", "quay.io/bpfman/bundle:v0.5.9"},
+			wantWarnings: []string{"replaced by generated stable-v<major>.<minor> channels", "replaces edges are regenerated"},
+		},
+		{
+			name: "named bundles across channels",
+			template: `schema: olm.template.basic
+entries:
+  - schema: olm.channel
+    package: bpfman-operator
+    name: fast-v0.5
+    entries:
+      - name: bpfman-operator.v0.5.8
+      - name: bpfman-operator.v0.5.9
+        skipRange: ">=0.5.0 <0.5.9"
+  - schema: olm.channel
+    package: bpfman-operator
+    name: preview
+    entries:
+      - name: bpfman-operator.v0.6.0
+  - schema: olm.bundle
+    name: bpfman-operator.v0.5.8
+    image: quay.io/bpfman/bundle:v0.5.8
+  - schema: olm.bundle
+    name: bpfman-operator.v0.5.9
+    image: quay.io/bpfman/bundle:v0.5.9
+  - schema: olm.bundle
+    name: bpfman-operator.v0.6.0
+    image: quay.io/bpfman/bundle:v0.6.0
+  - schema: olm.bundle
+    name: bpfman-operator.v0.4.0
+    image: quay.io/bpfman/bundle:v0.4.0
+`,
+			wantFast: []string{"quay.io/bpfman/bundle:v0.5.8", "quay.io/bpfman/bundle:v0.5.9"},
+			wantCand: []string{"quay.io/bpfman/bundle:v0.6.0"},
+			wantWarnings: []string{
+				"skips and skipRange of bpfman-operator.v0.5.9 are dropped",
+				"channel preview: no matching semver channel, bundles placed in candidate",
+				"bundle bpfman-operator.v0.4.0 is in no channel and is dropped",
+			},
+		},
+		{
+			name:     "not a basic template",
+			template: "schema: olm.semver\n",
+			wantErr:  `unsupported template schema "olm.semver"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv, err := ConvertToSemver([]byte(tt.template), SemverCandidate, false, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, c := range []struct {
+				name     string
+				channels *SemverChannels
+				want     []string
+			}{
+				{SemverStable, conv.Template.Stable, tt.wantStable},
+				{SemverFast, conv.Template.Fast, tt.wantFast},
+				{SemverCandidate, conv.Template.Candidate, tt.wantCand},
+			} {
+				var got []string
+				if c.channels != nil {
+					for _, b := range c.channels.Bundles {
+						got = append(got, b.Image)
+					}
+				}
+				if !slices.Equal(got, c.want) {
+					t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+				}
+			}
+
+			warnings := strings.Join(conv.Warnings, "\n")
+			for _, want := range tt.wantWarnings {
+				if !strings.Contains(warnings, want) {
+					t.Errorf("warnings missing %q:\n%s", want, warnings)
+				}
+			}
+		})
+	}
+}
+
+// TestSemverTemplateRenders tests that a generated semver template
+// is accepted by opm's semver renderer.
+func TestSemverTemplateRenders(t *testing.T) {
+	tmpl := &SemverTemplate{Schema: SchemaSemverTemplate}
+	for _, v := range []string{"0.5.8", "0.5.9", "0.6.0"} {
+		if err := tmpl.AddBundle(SemverStable, "quay.io/bpfman/bundle:v"+v); err != nil {
+			t.Fatalf("adding bundle: %v", err)
+		}
+	}
+	data, err := yaml.Marshal(tmpl)
+	if err != nil {
+		t.Fatalf("marshaling template: %v", err)
+	}
+
+	renderer := semver.Template{
+		Data: bytes.NewReader(data),
+		RenderBundle: func(_ context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+			version := strings.TrimPrefix(image[strings.LastIndex(image, ":")+1:], "v")
+			return &declcfg.DeclarativeConfig{
+				Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "bpfman-operator"}},
+				Bundles: []declcfg.Bundle{{
+					Schema:     declcfg.SchemaBundle,
+					Name:       fmt.Sprintf("bpfman-operator.v%s", version),
+					Package:    "bpfman-operator",
+					Image:      image,
+					Properties: []property.Property{property.MustBuildPackage("bpfman-operator", version)},
+				}},
+			}, nil
+		},
+	}
+
+	cfg, err := renderer.Render(context.Background())
+	if err != nil {
+		t.Fatalf("rendering: %v\n%s", err, data)
+	}
+
+	var channels []string
+	for _, ch := range cfg.Channels {
+		channels = append(channels, ch.Name)
+	}
+	slices.Sort(channels)
+	if want := []string{"stable-v0.5", "stable-v0.6"}; !slices.Equal(channels, want) {
+		t.Errorf("channels: got %v, want %v", channels, want)
+	}
+	if got := cfg.Packages[0].DefaultChannel; got != "stable-v0.6" {
+		t.Errorf("default channel: got %q, want stable-v0.6", got)
+	}
+}
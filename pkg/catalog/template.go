@@ -0,0 +1,243 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/basic"
+	"github.com/operator-framework/operator-registry/alpha/template/semver"
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// SchemaBasicTemplate is the schema of an opm basic catalog
+// template.
+const SchemaBasicTemplate = "olm.template.basic"
+
+// MigrationLevel is the opm migration level bundles are rendered
+// with, matching the repository's catalog generation.
+const MigrationLevel = "bundle-object-to-csv-metadata"
+
+// TemplateBundle is an olm.bundle entry of a catalog template.
+type TemplateBundle struct {
+	Image   string // Bundle image reference
+	Comment string // Trailing comment, e.g. "0.5.8", without the leading #
+	Line    int    // Line of the entry in the template
+}
+
+// ReadTemplateBundles returns the olm.bundle entries of a basic
+// catalog template along with the comments that annotate them. The
+// comments are not part of the rendered catalog, so they are read
+// from the YAML node tree.
+func ReadTemplateBundles(data []byte) ([]TemplateBundle, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	entries := mappingValue(doc.Content[0], "entries")
+	if entries == nil || entries.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("template has no entries")
+	}
+
+	var bundles []TemplateBundle
+	for _, entry := range entries.Content {
+		schema := mappingValue(entry, "schema")
+		if schema == nil || schema.Value != declcfg.SchemaBundle {
+			continue
+		}
+		b := TemplateBundle{Line: entry.Line}
+		if img := mappingValue(entry, "image"); img != nil {
+			b.Image = img.Value
+		}
+		b.Comment = entryComment(entry)
+		bundles = append(bundles, b)
+	}
+
+	return bundles, nil
+}
+
+// RenderTemplate renders a basic or semver catalog template, pulling
+// each bundle image to read its metadata.
+func RenderTemplate(ctx context.Context, path string) (*declcfg.DeclarativeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+
+	cfg, err := RenderTemplateData(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// RenderTemplateData renders the contents of a basic or semver
+// catalog template.
+func RenderTemplateData(ctx context.Context, data []byte) (*declcfg.DeclarativeConfig, error) {
+	schema, err := templateSchema(data)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := newRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer registry.Destroy()
+
+	migs, err := migrations.NewMigrations(MigrationLevel)
+	if err != nil {
+		return nil, fmt.Errorf("creating migrations: %w", err)
+	}
+
+	renderBundle := func(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+		r := action.Render{
+			Refs:           []string{image},
+			Registry:       registry,
+			AllowedRefMask: action.RefBundleImage,
+			Migrations:     migs,
+		}
+		return r.Run(ctx)
+	}
+
+	switch schema {
+	case SchemaBasicTemplate:
+		template := basic.Template{RenderBundle: renderBundle}
+		return template.Render(ctx, bytes.NewReader(data))
+	case SchemaSemverTemplate:
+		template := semver.Template{Data: bytes.NewReader(data), RenderBundle: renderBundle}
+		return template.Render(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported template schema %q (supported: %s, %s)", schema, SchemaBasicTemplate, SchemaSemverTemplate)
+	}
+}
+
+// templateSchema returns the top-level schema of a catalog template.
+func templateSchema(data []byte) (string, error) {
+	var doc struct {
+		Schema string `yaml:"schema"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	return doc.Schema, nil
+}
+
+// IsTemplate reports whether a file is a catalog template rather
+// than a rendered catalog.
+func IsTemplate(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	var doc struct {
+		Schema string `yaml:"schema"`
+	}
+	if err := yaml.NewDecoder(f).Decode(&doc); err != nil {
+		return false
+	}
+	return strings.HasPrefix(doc.Schema, "olm.template.") || doc.Schema == SchemaSemverTemplate
+}
+
+// LoadOrRender loads a catalog from a file, directory or image like
+// Load, but renders the file first if it is a catalog template.
+func LoadOrRender(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	if IsTemplate(ref) {
+		return RenderTemplate(ctx, ref)
+	}
+	return Load(ctx, ref)
+}
+
+// LoadPackages returns the olm.package entries of a basic catalog
+// template, a catalog file or directory, or a catalog image. Templates
+// are read as they are, without pulling their bundles.
+func LoadPackages(ctx context.Context, ref string) ([]declcfg.Package, error) {
+	if !IsTemplate(ref) {
+		cfg, err := Load(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Packages, nil
+	}
+
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+	packages, err := TemplatePackages(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ref, err)
+	}
+	return packages, nil
+}
+
+// TemplatePackages returns the olm.package entries of a basic
+// catalog template. Semver templates have none; opm generates the
+// package when rendering them.
+func TemplatePackages(data []byte) ([]declcfg.Package, error) {
+	var template struct {
+		Schema  string            `json:"schema"`
+		Entries []json.RawMessage `json:"entries"`
+	}
+	if err := sigsyaml.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	if template.Schema != SchemaBasicTemplate {
+		return nil, fmt.Errorf("unsupported template schema %q (expected %s)", template.Schema, SchemaBasicTemplate)
+	}
+
+	var packages []declcfg.Package
+	for _, entry := range template.Entries {
+		var meta declcfg.Meta
+		if err := json.Unmarshal(entry, &meta); err != nil {
+			return nil, fmt.Errorf("parsing template entry: %w", err)
+		}
+		if meta.Schema != declcfg.SchemaPackage {
+			continue
+		}
+		var pkg declcfg.Package
+		if err := json.Unmarshal(entry, &pkg); err != nil {
+			return nil, fmt.Errorf("parsing olm.package entry: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// entryComment returns the first line comment found on a template
+// entry or any of its keys and values.
+func entryComment(entry *yaml.Node) string {
+	nodes := append([]*yaml.Node{entry}, entry.Content...)
+	for _, n := range nodes {
+		if c := strings.TrimSpace(strings.TrimPrefix(n.LineComment, "#")); c != "" {
+			return c
+		}
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package catalog
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestTemplatePackages tests reading the olm.package entry, including
+// its icon, from the release templates.
+func TestTemplatePackages(t *testing.T) {
+	data, err := os.ReadFile("../../templates/y-stream.yaml")
+	if err != nil {
+		t.Fatalf("reading template: %v", err)
+	}
+
+	packages, err := TemplatePackages(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(packages) != 1 {
+		t.Fatalf("got %d packages, want 1", len(packages))
+	}
+
+	pkg := packages[0]
+	if pkg.Name != "bpfman-operator" || pkg.DefaultChannel != "stable" {
+		t.Errorf("got package %s with default channel %s", pkg.Name, pkg.DefaultChannel)
+	}
+	if pkg.Icon == nil || pkg.Icon.MediaType != "image/svg+xml" || !bytes.HasPrefix(pkg.Icon.Data, []byte("<?xml")) {
+		t.Errorf("icon not decoded: %+v", pkg.Icon)
+	}
+
+	if _, err := TemplatePackages([]byte("schema: olm.semver\n")); err == nil {
+		t.Error("expected an error for a semver template")
+	}
+}
@@ -0,0 +1,317 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// Upgrade edge kinds.
+const (
+	EdgeReplaces  = "replaces"
+	EdgeSkips     = "skips"
+	EdgeSkipRange = "skipRange"
+)
+
+// UpgradeHop is a single upgrade OLM performs between two bundles.
+type UpgradeHop struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Edge   string `json:"edge"`             // replaces, skips or skipRange
+	Detail string `json:"detail,omitempty"` // e.g. the skipRange expression
+}
+
+// UpgradePath is the sequence of upgrades from one bundle to another
+// within a channel.
+type UpgradePath struct {
+	Package string       `json:"package"`
+	Channel string       `json:"channel"`
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Hops    []UpgradeHop `json:"hops"`
+}
+
+// upgradeGraph is a channel's entries along with what is needed to
+// evaluate OLM's upgrade edges.
+type upgradeGraph struct {
+	channel  *declcfg.Channel
+	entries  map[string]declcfg.ChannelEntry
+	versions map[string]semver.Version
+	position map[string]int // Distance from the channel head along replaces
+	head     string
+}
+
+// FindUpgradePath returns the upgrades OLM v0 performs to take an
+// installed bundle to a target bundle in a channel. An empty target
+// means the channel head; an empty channel means the package's
+// default channel. Bundles may be given by name or by version.
+//
+// From an installed bundle, OLM considers every entry that replaces
+// it, lists it in skips, or has an olm.skipRange covering its
+// version, and upgrades to the candidate nearest the channel head.
+// The returned error explains why a target is unreachable.
+func FindUpgradePath(cfg *declcfg.DeclarativeConfig, packageName, channelName, from, to string) (*UpgradePath, error) {
+	if channelName == "" {
+		var err error
+		channelName, err = DefaultChannel(cfg, packageName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g, err := newUpgradeGraph(cfg, packageName, channelName)
+	if err != nil {
+		return nil, err
+	}
+
+	from = g.resolveInstalled(packageName, from)
+	if to == "" {
+		to = g.head
+	}
+	to, err = g.resolve(to)
+	if err != nil {
+		return nil, err
+	}
+
+	path := &UpgradePath{
+		Package: packageName,
+		Channel: channelName,
+		From:    from,
+		To:      to,
+		Hops:    []UpgradeHop{},
+	}
+	if from == to {
+		return path, nil
+	}
+	if g.isNewer(from, to) {
+		return nil, fmt.Errorf("%s is newer than %s; OLM does not downgrade", from, to)
+	}
+
+	visited := map[string]bool{from: true}
+	for current := from; current != to; {
+		hop, ok := g.next(current)
+		if !ok {
+			msg := fmt.Sprintf("%s is unreachable from %s: no entry in channel %s replaces, skips or has a skipRange covering %s",
+				to, from, channelName, current)
+			if _, ok := g.entries[current]; !ok {
+				msg += fmt.Sprintf(", which is not in the channel (entries: %s)", strings.Join(g.entryNames(), ", "))
+			}
+			return nil, errors.New(msg)
+		}
+		if visited[hop.To] {
+			return nil, fmt.Errorf("channel %s has an upgrade cycle at %s", channelName, hop.To)
+		}
+		visited[hop.To] = true
+		path.Hops = append(path.Hops, hop)
+
+		if hop.To != to && g.isNewer(hop.To, to) {
+			return nil, fmt.Errorf("%s is unreachable from %s: OLM upgrades %s to %s (%s), which is past the target",
+				to, from, hop.From, hop.To, hop.describe())
+		}
+		current = hop.To
+	}
+
+	return path, nil
+}
+
+func newUpgradeGraph(cfg *declcfg.DeclarativeConfig, packageName, channelName string) (*upgradeGraph, error) {
+	channel, err := FindChannel(cfg, packageName, channelName)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newChannelEdges(cfg, channel)
+	g.head, err = ChannelHead(channel)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := ReplacesChain(cfg, packageName, channelName)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range chain {
+		g.position[name] = len(chain) - 1 - i
+	}
+
+	return g, nil
+}
+
+// newChannelEdges returns a graph of a channel's entries and bundle
+// versions, enough to evaluate upgrade edges but without the replaces
+// positions.
+func newChannelEdges(cfg *declcfg.DeclarativeConfig, channel *declcfg.Channel) *upgradeGraph {
+	g := &upgradeGraph{
+		channel:  channel,
+		entries:  make(map[string]declcfg.ChannelEntry),
+		versions: make(map[string]semver.Version),
+		position: make(map[string]int),
+	}
+	for _, e := range channel.Entries {
+		g.entries[e.Name] = e
+	}
+	for _, b := range cfg.Bundles {
+		if _, ok := g.entries[b.Name]; !ok || b.Package != channel.Package {
+			continue
+		}
+		if v, err := semver.ParseTolerant(BundleVersion(b)); err == nil {
+			g.versions[b.Name] = v
+		}
+	}
+	return g
+}
+
+// resolve maps a bundle name or version to a channel entry.
+func (g *upgradeGraph) resolve(ref string) (string, error) {
+	if _, ok := g.entries[ref]; ok {
+		return ref, nil
+	}
+	if v, err := semver.ParseTolerant(ref); err == nil {
+		for _, e := range g.channel.Entries {
+			if bv, ok := g.versions[e.Name]; ok && bv.Equals(v) {
+				return e.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%s is not in channel %s (entries: %s)", ref, g.channel.Name, strings.Join(g.entryNames(), ", "))
+}
+
+func (g *upgradeGraph) entryNames() []string {
+	names := make([]string, 0, len(g.channel.Entries))
+	for _, e := range g.channel.Entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// resolveInstalled maps an installed bundle to a channel entry if it
+// is one. An installed bundle need not be in the channel: OLM can
+// still upgrade it if an entry replaces or skips it by name, or has a
+// skipRange covering its version, so a name or version outside the
+// channel is accepted as is.
+func (g *upgradeGraph) resolveInstalled(packageName, ref string) string {
+	if name, err := g.resolve(ref); err == nil {
+		return name
+	}
+
+	name, version := ref, ref
+	if _, err := semver.ParseTolerant(ref); err == nil {
+		name = fmt.Sprintf("%s.v%s", packageName, strings.TrimPrefix(ref, "v"))
+	} else if i := strings.LastIndex(ref, ".v"); i >= 0 {
+		version = ref[i+2:]
+	}
+	if v, err := semver.ParseTolerant(version); err == nil {
+		g.versions[name] = v
+	}
+	return name
+}
+
+// distance returns how far an entry is from the channel head along
+// replaces. Entries off the replaces chain sort after all others.
+func (g *upgradeGraph) distance(name string) int {
+	if d, ok := g.position[name]; ok {
+		return d
+	}
+	return len(g.entries)
+}
+
+// isNewer reports whether a is further along the channel than b.
+func (g *upgradeGraph) isNewer(a, b string) bool {
+	if da, db := g.distance(a), g.distance(b); da != db {
+		return da < db
+	}
+	va, okA := g.versions[a]
+	vb, okB := g.versions[b]
+	return okA && okB && va.GT(vb)
+}
+
+// next returns the upgrade OLM performs from an installed bundle.
+func (g *upgradeGraph) next(current string) (UpgradeHop, bool) {
+	var candidates []UpgradeHop
+	for _, e := range g.channel.Entries {
+		if e.Name == current {
+			continue
+		}
+		if hop, ok := g.edge(current, e); ok {
+			candidates = append(candidates, hop)
+		}
+	}
+	if len(candidates) == 0 {
+		return UpgradeHop{}, false
+	}
+
+	best := slices.MinFunc(candidates, func(a, b UpgradeHop) int {
+		switch {
+		case g.isNewer(a.To, b.To):
+			return -1
+		case g.isNewer(b.To, a.To):
+			return 1
+		}
+		return 0
+	})
+	return best, true
+}
+
+// edge reports whether entry is an upgrade from current.
+func (g *upgradeGraph) edge(current string, entry declcfg.ChannelEntry) (UpgradeHop, bool) {
+	hop := UpgradeHop{From: current, To: entry.Name}
+	switch {
+	case entry.Replaces == current:
+		hop.Edge = EdgeReplaces
+	case slices.Contains(entry.Skips, current):
+		hop.Edge = EdgeSkips
+	case entry.SkipRange != "":
+		v, ok := g.versions[current]
+		if !ok {
+			return hop, false
+		}
+		r, err := semver.ParseRange(entry.SkipRange)
+		if err != nil || !r(v) {
+			return hop, false
+		}
+		hop.Edge = EdgeSkipRange
+		hop.Detail = entry.SkipRange
+	default:
+		return hop, false
+	}
+	return hop, true
+}
+
+func (h UpgradeHop) describe() string {
+	if h.Detail == "" {
+		return h.Edge
+	}
+	return fmt.Sprintf("%s %s", h.Edge, h.Detail)
+}
+
+// FormatUpgradePath formats an upgrade path according to the
+// specified format.
+func FormatUpgradePath(path *UpgradePath, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := json.MarshalIndent(path, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "text", "":
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Upgrade path from %s to %s (package %s, channel %s):\n", path.From, path.To, path.Package, path.Channel))
+		if len(path.Hops) == 0 {
+			b.WriteString("  Already at the target; no upgrade needed.\n")
+			return b.String(), nil
+		}
+		for i, hop := range path.Hops {
+			b.WriteString(fmt.Sprintf("  %d. %s → %s (%s)\n", i+1, hop.From, hop.To, hop.describe()))
+		}
+		b.WriteString(fmt.Sprintf("%d hop(s)\n", len(path.Hops)))
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: text, json)", format)
+	}
+}
@@ -0,0 +1,125 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func testUpgradeCatalog(entries ...declcfg.ChannelEntry) *declcfg.DeclarativeConfig {
+	cfg := &declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Name: "bpfman-operator", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{{Package: "bpfman-operator", Name: "stable", Entries: entries}},
+	}
+	for _, e := range entries {
+		version := strings.TrimPrefix(e.Name, "bpfman-operator.v")
+		cfg.Bundles = append(cfg.Bundles, declcfg.Bundle{
+			Package:    "bpfman-operator",
+			Name:       e.Name,
+			Properties: []property.Property{property.MustBuildPackage("bpfman-operator", version)},
+		})
+	}
+	return cfg
+}
+
+// TestFindUpgradePath tests OLM v0 replaces, skips and skipRange
+// semantics.
+func TestFindUpgradePath(t *testing.T) {
+	linear := testUpgradeCatalog(
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.9", Replaces: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.10", Replaces: "bpfman-operator.v0.5.9"},
+	)
+	skipping := testUpgradeCatalog(
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.9", Replaces: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.10", Replaces: "bpfman-operator.v0.5.9", SkipRange: ">=0.5.0 <0.5.10"},
+	)
+	skips := testUpgradeCatalog(
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.8"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.9"},
+		declcfg.ChannelEntry{Name: "bpfman-operator.v0.5.10", Replaces: "bpfman-operator.v0.5.8", Skips: []string{"bpfman-operator.v0.5.9"}},
+	)
+
+	tests := []struct {
+		name    string
+		cfg     *declcfg.DeclarativeConfig
+		from    string
+		to      string
+		want    []string // "from -> to (edge)"
+		wantErr string
+	}{
+		{
+			name: "replaces chain to head",
+			cfg:  linear,
+			from: "0.5.8",
+			want: []string{
+				"bpfman-operator.v0.5.8 -> bpfman-operator.v0.5.9 (replaces)",
+				"bpfman-operator.v0.5.9 -> bpfman-operator.v0.5.10 (replaces)",
+			},
+		},
+		{
+			name: "skipRange jumps to head",
+			cfg:  skipping,
+			from: "bpfman-operator.v0.5.8",
+			want: []string{"bpfman-operator.v0.5.8 -> bpfman-operator.v0.5.10 (skipRange >=0.5.0 <0.5.10)"},
+		},
+		{
+			name: "skips",
+			cfg:  skips,
+			from: "0.5.9",
+			want: []string{"bpfman-operator.v0.5.9 -> bpfman-operator.v0.5.10 (skips)"},
+		},
+		{
+			name: "installed bundle outside the channel",
+			cfg:  skipping,
+			from: "0.5.3",
+			want: []string{"bpfman-operator.v0.5.3 -> bpfman-operator.v0.5.10 (skipRange >=0.5.0 <0.5.10)"},
+		},
+		{
+			name:    "target bypassed by skipRange",
+			cfg:     skipping,
+			from:    "0.5.8",
+			to:      "0.5.9",
+			wantErr: "OLM upgrades bpfman-operator.v0.5.8 to bpfman-operator.v0.5.10 (skipRange >=0.5.0 <0.5.10), which is past the target",
+		},
+		{
+			name:    "no upgrade edge",
+			cfg:     linear,
+			from:    "0.5.3",
+			wantErr: "no entry in channel stable replaces, skips or has a skipRange covering bpfman-operator.v0.5.3",
+		},
+		{
+			name:    "downgrade",
+			cfg:     linear,
+			from:    "0.5.10",
+			to:      "0.5.8",
+			wantErr: "OLM does not downgrade",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := FindUpgradePath(tt.cfg, "bpfman-operator", "", tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, hop := range path.Hops {
+				got = append(got, hop.From+" -> "+hop.To+" ("+hop.describe()+")")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("hops =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package manifests
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+)
+
+// SyncWaveAnnotation orders resources within an Argo CD sync.
+const SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+
+// Sync waves mirror the numeric file ordering used by the writer so
+// that Argo CD applies resources in the same order as `kubectl apply
+// -f` on the generated directories.
+const (
+	NamespaceSyncWave     = 0
+	IDMSSyncWave          = 1
+	PullSecretSyncWave    = 1
+	CatalogSourceSyncWave = 2
+	OperatorGroupSyncWave = 3
+	SubscriptionSyncWave  = 4
+)
+
+// Defaults for Argo CD Application generation.
+const (
+	DefaultArgoCDNamespace = "openshift-gitops"
+	DefaultArgoCDProject   = "default"
+	DefaultGitOpsRevision  = "HEAD"
+	inClusterServer        = "https://kubernetes.default.svc"
+)
+
+// catalogSourceHealthCheck reports a CatalogSource as healthy once
+// its registry pod connection is READY.
+const catalogSourceHealthCheck = `hs = {}
+if obj.status ~= nil and obj.status.connectionState ~= nil then
+  local state = obj.status.connectionState.lastObservedState
+  if state == "READY" then
+    hs.status = "Healthy"
+    hs.message = "CatalogSource is ready"
+    return hs
+  end
+  if state == "TRANSIENT_FAILURE" then
+    hs.status = "Degraded"
+    hs.message = "CatalogSource connection is failing"
+    return hs
+  end
+end
+hs.status = "Progressing"
+hs.message = "Waiting for CatalogSource to become ready"
+return hs
+`
+
+// GitOpsConfig contains configuration for Argo CD Application
+// generation.
+type GitOpsConfig struct {
+	RepoURL   string // Git repository holding the generated manifests
+	Revision  string // Git revision to sync (default: HEAD)
+	Path      string // Path to the output directory within the repository
+	Namespace string // Namespace Argo CD runs in (default: openshift-gitops)
+	Project   string // Argo CD project (default: default)
+}
+
+// ApplySyncWaves annotates every resource in the manifest set with
+// the sync wave matching its position in the generated file order.
+func ApplySyncWaves(manifestSet *ManifestSet) {
+	if manifestSet.Namespace != nil {
+		setSyncWave(&manifestSet.Namespace.ObjectMeta, NamespaceSyncWave)
+	}
+	if manifestSet.IDMS != nil {
+		setSyncWave(&manifestSet.IDMS.ObjectMeta, IDMSSyncWave)
+	}
+	if manifestSet.PullSecret != nil {
+		setSyncWave(&manifestSet.PullSecret.ObjectMeta, PullSecretSyncWave)
+	}
+	if manifestSet.CatalogSource != nil {
+		setSyncWave(&manifestSet.CatalogSource.ObjectMeta, CatalogSourceSyncWave)
+	}
+	if manifestSet.OperatorGroup != nil {
+		setSyncWave(&manifestSet.OperatorGroup.ObjectMeta, OperatorGroupSyncWave)
+	}
+	if manifestSet.Subscription != nil {
+		setSyncWave(&manifestSet.Subscription.ObjectMeta, SubscriptionSyncWave)
+	}
+}
+
+func setSyncWave(meta *ObjectMeta, wave int) {
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[SyncWaveAnnotation] = strconv.Itoa(wave)
+}
+
+// NewApplication creates an Argo CD Application manifest with
+// consistent labelling.
+func (g *Generator) NewApplication(baseName, sourcePath string, wave int, cfg GitOpsConfig) *Application {
+	app := &Application{
+		TypeMeta: TypeMeta{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "Application",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:      g.generateResourceName(baseName),
+			Namespace: cfg.Namespace,
+			Labels:    g.getMergedLabels(nil),
+		},
+		Spec: ApplicationSpec{
+			Project: cfg.Project,
+			Source: ApplicationSource{
+				RepoURL:        cfg.RepoURL,
+				TargetRevision: cfg.Revision,
+				Path:           sourcePath,
+			},
+			Destination: ApplicationDestination{
+				Server: inClusterServer,
+			},
+			SyncPolicy: &SyncPolicy{
+				Automated: &SyncPolicyAutomated{
+					Prune:    true,
+					SelfHeal: true,
+				},
+				SyncOptions: []string{"SkipDryRunOnMissingResource=true"},
+			},
+		},
+	}
+	setSyncWave(&app.ObjectMeta, wave)
+	return app
+}
+
+// GenerateArgoCD generates Argo CD Applications for the catalog/ and
+// subscription/ directories of a ManifestSet. The manifest set is
+// annotated with sync waves so each Application applies its
+// resources in file order, and the Applications themselves are
+// ordered so the catalog is synced before the subscription.
+//
+// The CatalogSource health check is a patch for the ArgoCD resource
+// rather than for argocd-cm: the OpenShift GitOps operator generates
+// argocd-cm from the ArgoCD resource and reverts direct edits to it.
+// Subscriptions use Argo CD's built-in health check. There is no CSV
+// check: OLM creates the CSV without an owner reference, so it is
+// never part of an Application's resource tree.
+func (g *Generator) GenerateArgoCD(manifestSet *ManifestSet, cfg GitOpsConfig) (*ArgoCDSet, error) {
+	if cfg.RepoURL == "" {
+		return nil, fmt.Errorf("a git repository URL is required for Argo CD Applications")
+	}
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("a repository path is required for Argo CD Applications")
+	}
+	if cfg.Revision == "" {
+		cfg.Revision = DefaultGitOpsRevision
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultArgoCDNamespace
+	}
+	if cfg.Project == "" {
+		cfg.Project = DefaultArgoCDProject
+	}
+	if g.labelContext == nil {
+		g.setupLabelContext("")
+	}
+
+	ApplySyncWaves(manifestSet)
+
+	return &ArgoCDSet{
+		CatalogApplication:      g.NewApplication("bpfman-argocd-catalog", path.Join(cfg.Path, "catalog"), 0, cfg),
+		SubscriptionApplication: g.NewApplication("bpfman-argocd-subscription", path.Join(cfg.Path, "subscription"), 1, cfg),
+		HealthChecks: &ArgoCDPatch{
+			Spec: ArgoCDPatchSpec{
+				ResourceHealthChecks: []ResourceHealthCheck{
+					{Group: "operators.coreos.com", Kind: "CatalogSource", Check: catalogSourceHealthCheck},
+				},
+			},
+		},
+	}, nil
+}
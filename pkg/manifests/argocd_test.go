@@ -0,0 +1,120 @@
+package manifests
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestApplySyncWaves tests that resources are annotated with waves in
+// the generated file order.
+func TestApplySyncWaves(t *testing.T) {
+	g := NewGenerator(GeneratorConfig{UseDigestName: true})
+	g.setupLabelContext("0123abcd")
+	manifestSet, err := g.buildManifestSet(CatalogMetadata{
+		Image:       "quay.io/example/catalog@sha256:0123abcd00000000000000000000000000000000000000000000000000000000",
+		ShortDigest: "0123abcd",
+		CatalogType: "catalog-ystream",
+	}, "preview")
+	if err != nil {
+		t.Fatalf("building manifest set: %v", err)
+	}
+	manifestSet.PullSecret = &Secret{}
+
+	ApplySyncWaves(manifestSet)
+
+	tests := []struct {
+		kind string
+		meta ObjectMeta
+		want string
+	}{
+		{kind: "Namespace", meta: manifestSet.Namespace.ObjectMeta, want: "0"},
+		{kind: "ImageDigestMirrorSet", meta: manifestSet.IDMS.ObjectMeta, want: "1"},
+		{kind: "Secret", meta: manifestSet.PullSecret.ObjectMeta, want: "1"},
+		{kind: "CatalogSource", meta: manifestSet.CatalogSource.ObjectMeta, want: "2"},
+		{kind: "OperatorGroup", meta: manifestSet.OperatorGroup.ObjectMeta, want: "3"},
+		{kind: "Subscription", meta: manifestSet.Subscription.ObjectMeta, want: "4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if got := tt.meta.Annotations[SyncWaveAnnotation]; got != tt.want {
+				t.Errorf("got sync wave %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGenerateArgoCD tests the Application sources, defaults and
+// health checks.
+func TestGenerateArgoCD(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           GitOpsConfig
+		wantRevision  string
+		wantNamespace string
+		wantErr       string
+	}{
+		{
+			name:          "defaults",
+			cfg:           GitOpsConfig{RepoURL: "https://github.com/example/fleet.git", Path: "clusters/dev"},
+			wantRevision:  DefaultGitOpsRevision,
+			wantNamespace: DefaultArgoCDNamespace,
+		},
+		{
+			name:          "explicit",
+			cfg:           GitOpsConfig{RepoURL: "https://github.com/example/fleet.git", Path: "clusters/dev", Revision: "main", Namespace: "argocd"},
+			wantRevision:  "main",
+			wantNamespace: "argocd",
+		},
+		{
+			name:    "no repository",
+			cfg:     GitOpsConfig{Path: "clusters/dev"},
+			wantErr: "repository URL",
+		},
+		{
+			name:    "no path",
+			cfg:     GitOpsConfig{RepoURL: "https://github.com/example/fleet.git"},
+			wantErr: "repository path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(GeneratorConfig{})
+			argoCDSet, err := g.GenerateArgoCD(&ManifestSet{}, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, app := range []struct {
+				app  *Application
+				path string
+				wave string
+			}{
+				{argoCDSet.CatalogApplication, "clusters/dev/catalog", "0"},
+				{argoCDSet.SubscriptionApplication, "clusters/dev/subscription", "1"},
+			} {
+				src := app.app.Spec.Source
+				if src.Path != app.path || src.TargetRevision != tt.wantRevision || src.RepoURL != tt.cfg.RepoURL {
+					t.Errorf("got source %+v, want path %s at %s", src, app.path, tt.wantRevision)
+				}
+				if app.app.Namespace != tt.wantNamespace || app.app.Annotations[SyncWaveAnnotation] != app.wave {
+					t.Errorf("got namespace %s and sync wave %s", app.app.Namespace, app.app.Annotations[SyncWaveAnnotation])
+				}
+			}
+
+			var kinds []string
+			for _, check := range argoCDSet.HealthChecks.Spec.ResourceHealthChecks {
+				kinds = append(kinds, check.Group+"/"+check.Kind)
+			}
+			if got := strings.Join(kinds, " "); got != "operators.coreos.com/CatalogSource" {
+				t.Errorf("got health checks for %s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package manifests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openshift/bpfman-catalog/pkg/analysis"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
+	"github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// GenerateFromBundle generates manifests that install a bundle image
+// directly, without OLM. The CSV install strategy is rendered into
+// plain Deployments, ServiceAccounts and RBAC, and the bundle CRDs
+// and bpfman-config ConfigMap are copied alongside them.
+func (g *Generator) GenerateFromBundle(ctx context.Context) (*BundleInstallSet, error) {
+	resolvedRef, err := analysis.ResolveToDigest(ctx, g.config.ImageRef)
+	if err != nil {
+		return nil, fmt.Errorf("resolving bundle digest: %w", err)
+	}
+
+	bundleRef, err := analysis.ParseImageRef(resolvedRef)
+	if err != nil {
+		return nil, fmt.Errorf("parsing bundle reference: %w", err)
+	}
+
+	bundleManifests, err := unpackBundleManifests(ctx, bundleRef)
+	if err != nil {
+		return nil, err
+	}
+
+	csv, _, err := analysis.FindCSV(bundleManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	g.setupLabelContext(getDigestSuffix(g.config.UseDigestName, shortDigest(bundleRef.Digest)))
+
+	return g.buildBundleInstallSet(csv, bundleManifests)
+}
+
+// unpackBundleManifests unpacks a bundle image and loads its
+// manifests.
+func unpackBundleManifests(ctx context.Context, bundleRef analysis.ImageRef) ([]analysis.Manifest, error) {
+	logrus.SetLevel(logrus.WarnLevel)
+	logger := logrus.NewEntry(logrus.New())
+	logger.Logger.SetLevel(logrus.WarnLevel)
+
+	registry, err := execregistry.NewRegistry(containertools.PodmanTool, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating image registry: %w", err)
+	}
+	defer registry.Destroy()
+
+	bundleDir, err := analysis.UnpackBundle(ctx, bundleRef, registry)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	bundleManifests, err := analysis.LoadBundleManifests(bundleDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading bundle manifests: %w", err)
+	}
+
+	return bundleManifests, nil
+}
+
+// shortDigest returns the first 8 hex characters of a sha256 digest.
+func shortDigest(d string) string {
+	d = strings.TrimPrefix(d, "sha256:")
+	if len(d) < 8 {
+		return ""
+	}
+	return d[:8]
+}
+
+func (g *Generator) buildBundleInstallSet(csv *v1alpha1.ClusterServiceVersion, bundleManifests []analysis.Manifest) (*BundleInstallSet, error) {
+	strategy := csv.Spec.InstallStrategy
+	if strategy.StrategyName != v1alpha1.InstallStrategyNameDeployment {
+		return nil, fmt.Errorf("unsupported CSV install strategy %q", strategy.StrategyName)
+	}
+
+	if len(csv.Spec.WebhookDefinitions) > 0 {
+		logrus.Warnf("CSV %s defines %d webhooks; these require OLM and are not rendered", csv.Name, len(csv.Spec.WebhookDefinitions))
+	}
+
+	namespace := g.config.Namespace
+	installSet := &BundleInstallSet{
+		Namespace: g.NewNamespace(namespace),
+	}
+
+	for _, m := range bundleManifests {
+		switch {
+		case m.Kind() == "CustomResourceDefinition":
+			installSet.CRDs = append(installSet.CRDs, g.labelledCopy(m.Object, ""))
+		case m.Kind() == "ConfigMap" && m.Name() == analysis.BpfmanConfigMapName:
+			installSet.ConfigMaps = append(installSet.ConfigMaps, g.labelledCopy(m.Object, namespace))
+		}
+	}
+
+	serviceAccounts := make(map[string]bool)
+	addServiceAccount := func(name string) {
+		if serviceAccounts[name] {
+			return
+		}
+		serviceAccounts[name] = true
+		installSet.ServiceAccounts = append(installSet.ServiceAccounts, g.NewServiceAccount(namespace, name))
+	}
+
+	for _, perm := range mergePermissions(strategy.StrategySpec.ClusterPermissions) {
+		addServiceAccount(perm.ServiceAccountName)
+		role := g.NewClusterRole(perm.ServiceAccountName, perm.Rules)
+		installSet.ClusterRoles = append(installSet.ClusterRoles, role)
+		installSet.ClusterRoleBindings = append(installSet.ClusterRoleBindings,
+			g.NewClusterRoleBinding(namespace, perm.ServiceAccountName, role.ObjectMeta.Name))
+	}
+
+	for _, perm := range mergePermissions(strategy.StrategySpec.Permissions) {
+		addServiceAccount(perm.ServiceAccountName)
+		role := g.NewRole(namespace, perm.ServiceAccountName, perm.Rules)
+		installSet.Roles = append(installSet.Roles, role)
+		installSet.RoleBindings = append(installSet.RoleBindings,
+			g.NewRoleBinding(namespace, perm.ServiceAccountName, role.ObjectMeta.Name))
+	}
+
+	for _, spec := range strategy.StrategySpec.DeploymentSpecs {
+		if sa := spec.Spec.Template.Spec.ServiceAccountName; sa != "" {
+			addServiceAccount(sa)
+		}
+		installSet.Deployments = append(installSet.Deployments, g.NewDeployment(namespace, spec))
+	}
+
+	if len(installSet.Deployments) == 0 {
+		return nil, fmt.Errorf("CSV %s has no deployments in its install strategy", csv.Name)
+	}
+
+	return installSet, nil
+}
+
+// labelledCopy returns a copy of obj with the standard labels merged
+// into its metadata and, if set, its namespace replaced.
+func (g *Generator) labelledCopy(obj map[string]any, namespace string) map[string]any {
+	out := make(map[string]any, len(obj))
+	for k, v := range obj {
+		out[k] = v
+	}
+
+	meta := make(map[string]any)
+	if existing, ok := obj["metadata"].(map[string]any); ok {
+		for k, v := range existing {
+			meta[k] = v
+		}
+	}
+
+	var existingLabels map[string]string
+	if labels, ok := meta["labels"].(map[string]any); ok {
+		existingLabels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			existingLabels[k] = fmt.Sprint(v)
+		}
+	}
+	meta["labels"] = g.getMergedLabels(existingLabels)
+
+	if namespace != "" {
+		meta["namespace"] = namespace
+	}
+
+	out["metadata"] = meta
+	return out
+}
+
+// NewServiceAccount creates a service account manifest with
+// consistent labelling.
+func (g *Generator) NewServiceAccount(namespace, name string) *ServiceAccount {
+	return &ServiceAccount{
+		TypeMeta: TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ServiceAccount",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:      name, // Referenced by name from the deployment spec
+			Namespace: namespace,
+			Labels:    g.getMergedLabels(nil),
+		},
+	}
+}
+
+// mergePermissions combines the rules of CSV permissions entries for
+// the same service account, in first-seen order. Roles are named after
+// their service account, so separate entries would otherwise produce
+// roles of the same name that overwrite each other.
+func mergePermissions(perms []v1alpha1.StrategyDeploymentPermissions) []v1alpha1.StrategyDeploymentPermissions {
+	var merged []v1alpha1.StrategyDeploymentPermissions
+	index := make(map[string]int)
+	for _, perm := range perms {
+		i, ok := index[perm.ServiceAccountName]
+		if !ok {
+			index[perm.ServiceAccountName] = len(merged)
+			merged = append(merged, v1alpha1.StrategyDeploymentPermissions{ServiceAccountName: perm.ServiceAccountName})
+			i = len(merged) - 1
+		}
+		merged[i].Rules = append(merged[i].Rules, perm.Rules...)
+	}
+	return merged
+}
+
+// NewClusterRole creates a cluster role manifest from CSV
+// clusterPermissions with consistent labelling.
+func (g *Generator) NewClusterRole(serviceAccount string, rules []rbacv1.PolicyRule) *Role {
+	return &Role{
+		TypeMeta: TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "ClusterRole",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:   g.generateResourceName(serviceAccount),
+			Labels: g.getMergedLabels(nil),
+		},
+		Rules: rules,
+	}
+}
+
+// NewClusterRoleBinding creates a cluster role binding manifest with
+// consistent labelling.
+func (g *Generator) NewClusterRoleBinding(namespace, serviceAccount, roleName string) *RoleBinding {
+	return &RoleBinding{
+		TypeMeta: TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "ClusterRoleBinding",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:   roleName,
+			Labels: g.getMergedLabels(nil),
+		},
+		Subjects: []Subject{
+			{Kind: "ServiceAccount", Name: serviceAccount, Namespace: namespace},
+		},
+		RoleRef: RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     roleName,
+		},
+	}
+}
+
+// NewRole creates a role manifest from CSV permissions with
+// consistent labelling.
+func (g *Generator) NewRole(namespace, serviceAccount string, rules []rbacv1.PolicyRule) *Role {
+	return &Role{
+		TypeMeta: TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "Role",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:      g.generateResourceName(serviceAccount),
+			Namespace: namespace,
+			Labels:    g.getMergedLabels(nil),
+		},
+		Rules: rules,
+	}
+}
+
+// NewRoleBinding creates a role binding manifest with consistent
+// labelling.
+func (g *Generator) NewRoleBinding(namespace, serviceAccount, roleName string) *RoleBinding {
+	return &RoleBinding{
+		TypeMeta: TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "RoleBinding",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:      roleName,
+			Namespace: namespace,
+			Labels:    g.getMergedLabels(nil),
+		},
+		Subjects: []Subject{
+			{Kind: "ServiceAccount", Name: serviceAccount, Namespace: namespace},
+		},
+		RoleRef: RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     roleName,
+		},
+	}
+}
+
+// NewDeployment creates a deployment manifest from a CSV install
+// strategy deployment with consistent labelling.
+func (g *Generator) NewDeployment(namespace string, spec v1alpha1.StrategyDeploymentSpec) *Deployment {
+	return &Deployment{
+		TypeMeta: TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:      spec.Name, // Keep the CSV name; other resources may refer to it
+			Namespace: namespace,
+			Labels:    g.getMergedLabels(spec.Label),
+		},
+		Spec: spec.Spec,
+	}
+}
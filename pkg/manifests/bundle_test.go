@@ -0,0 +1,207 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/openshift/bpfman-catalog/pkg/analysis"
+)
+
+const bundleTestCSV = `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: bpfman-operator.v0.5.9
+  namespace: placeholder
+spec:
+  install:
+    strategy: deployment
+    spec:
+      clusterPermissions:
+        - serviceAccountName: bpfman-operator
+          rules:
+            - apiGroups: ["bpfman.io"]
+              resources: ["*"]
+              verbs: ["*"]
+        - serviceAccountName: bpfman-operator
+          rules:
+            - apiGroups: [""]
+              resources: ["nodes"]
+              verbs: ["get"]
+      permissions:
+        - serviceAccountName: bpfman-operator
+          rules:
+            - apiGroups: [""]
+              resources: ["configmaps"]
+              verbs: ["get", "list"]
+        - serviceAccountName: bpfman-operator
+          rules:
+            - apiGroups: ["coordination.k8s.io"]
+              resources: ["leases"]
+              verbs: ["get", "update"]
+      deployments:
+        - name: bpfman-operator
+          label:
+            control-plane: controller-manager
+          spec:
+            selector:
+              matchLabels:
+                control-plane: controller-manager
+            template:
+              metadata:
+                labels:
+                  control-plane: controller-manager
+              spec:
+                serviceAccountName: bpfman-operator
+                containers:
+                  - name: manager
+                    image: quay.io/bpfman/bpfman-operator:v0.5.9
+        - name: bpfman-metrics-proxy
+          spec:
+            selector:
+              matchLabels:
+                app: metrics-proxy
+            template:
+              metadata:
+                labels:
+                  app: metrics-proxy
+              spec:
+                serviceAccountName: bpfman-metrics
+                containers:
+                  - name: proxy
+                    image: quay.io/bpfman/metrics-proxy:v0.5.9
+`
+
+const bundleTestCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: bpfapplications.bpfman.io
+  labels:
+    app.kubernetes.io/part-of: bpfman
+spec:
+  group: bpfman.io
+`
+
+const bundleTestConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bpfman-config
+  namespace: placeholder
+data:
+  bpfman.agent.image: quay.io/bpfman/bpfman-agent:v0.5.9
+`
+
+const bundleTestOtherConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+data: {}
+`
+
+// TestBuildBundleInstallSet tests that the CSV install strategy is
+// rendered into plain resources, with one role per service account,
+// and that the CRDs and bpfman-config ConfigMap are copied into the
+// install namespace.
+func TestBuildBundleInstallSet(t *testing.T) {
+	bundleDir := t.TempDir()
+	manifestDir := filepath.Join(bundleDir, "manifests")
+	if err := os.MkdirAll(manifestDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"bpfman-operator.clusterserviceversion.yaml": bundleTestCSV,
+		"bpfman.io_bpfapplications.yaml":             bundleTestCRD,
+		"bpfman-config_v1_configmap.yaml":            bundleTestConfigMap,
+		"unrelated_v1_configmap.yaml":                bundleTestOtherConfigMap,
+	} {
+		if err := os.WriteFile(filepath.Join(manifestDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundleManifests, err := analysis.LoadBundleManifests(bundleDir)
+	if err != nil {
+		t.Fatalf("loading bundle manifests: %v", err)
+	}
+	csv, _, err := analysis.FindCSV(bundleManifests)
+	if err != nil {
+		t.Fatalf("finding CSV: %v", err)
+	}
+
+	g := NewGenerator(GeneratorConfig{Namespace: "bpfman-test", UseDigestName: true})
+	g.setupLabelContext("0123abcd")
+	installSet, err := g.buildBundleInstallSet(csv, bundleManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if installSet.Namespace.Name != "bpfman-test" {
+		t.Errorf("got namespace %s", installSet.Namespace.Name)
+	}
+
+	var deployments []string
+	for _, d := range installSet.Deployments {
+		deployments = append(deployments, d.Namespace+"/"+d.Name)
+	}
+	if want := []string{"bpfman-test/bpfman-operator", "bpfman-test/bpfman-metrics-proxy"}; !slices.Equal(deployments, want) {
+		t.Errorf("got deployments %v, want %v", deployments, want)
+	}
+	if got := installSet.Deployments[0].Labels["control-plane"]; got != "controller-manager" {
+		t.Errorf("deployment lost its CSV label: %v", installSet.Deployments[0].Labels)
+	}
+
+	var serviceAccounts []string
+	for _, sa := range installSet.ServiceAccounts {
+		serviceAccounts = append(serviceAccounts, sa.Namespace+"/"+sa.Name)
+	}
+	if want := []string{"bpfman-test/bpfman-operator", "bpfman-test/bpfman-metrics"}; !slices.Equal(serviceAccounts, want) {
+		t.Errorf("got service accounts %v, want %v", serviceAccounts, want)
+	}
+
+	if len(installSet.ClusterRoles) != 1 || installSet.ClusterRoles[0].Kind != "ClusterRole" || installSet.ClusterRoles[0].Name != "bpfman-operator-sha-0123abcd" {
+		t.Fatalf("got cluster roles %+v", installSet.ClusterRoles)
+	}
+	// Both clusterPermissions entries for the service account are
+	// merged into its one ClusterRole.
+	if rules := installSet.ClusterRoles[0].Rules; len(rules) != 2 || rules[0].APIGroups[0] != "bpfman.io" || rules[1].Resources[0] != "nodes" {
+		t.Errorf("got cluster role rules %+v", rules)
+	}
+	if len(installSet.ClusterRoleBindings) != 1 {
+		t.Fatalf("got %d cluster role bindings", len(installSet.ClusterRoleBindings))
+	}
+	crb := installSet.ClusterRoleBindings[0]
+	if crb.RoleRef.Kind != "ClusterRole" || crb.RoleRef.Name != "bpfman-operator-sha-0123abcd" || crb.Subjects[0].Namespace != "bpfman-test" {
+		t.Errorf("got cluster role binding %+v", crb)
+	}
+
+	if len(installSet.Roles) != 1 || installSet.Roles[0].Kind != "Role" || installSet.Roles[0].Namespace != "bpfman-test" {
+		t.Fatalf("got roles %+v", installSet.Roles)
+	}
+	if rules := installSet.Roles[0].Rules; len(rules) != 2 || rules[0].Resources[0] != "configmaps" || rules[1].Resources[0] != "leases" {
+		t.Errorf("got role rules %+v", rules)
+	}
+	if len(installSet.RoleBindings) != 1 || installSet.RoleBindings[0].RoleRef.Name != installSet.Roles[0].Name {
+		t.Errorf("got role bindings %+v", installSet.RoleBindings)
+	}
+
+	if len(installSet.CRDs) != 1 {
+		t.Fatalf("got %d CRDs, want 1", len(installSet.CRDs))
+	}
+	crdMeta := installSet.CRDs[0]["metadata"].(map[string]any)
+	crdLabels := crdMeta["labels"].(map[string]string)
+	if crdMeta["name"] != "bpfapplications.bpfman.io" || crdLabels["app.kubernetes.io/part-of"] != "bpfman" || crdLabels["app.kubernetes.io/created-by"] != "bpfman-catalog-cli" {
+		t.Errorf("got CRD metadata %v", crdMeta)
+	}
+	if _, ok := crdMeta["namespace"]; ok {
+		t.Errorf("cluster-scoped CRD was given a namespace: %v", crdMeta)
+	}
+
+	if len(installSet.ConfigMaps) != 1 {
+		t.Fatalf("got %d ConfigMaps, want only bpfman-config", len(installSet.ConfigMaps))
+	}
+	cmMeta := installSet.ConfigMaps[0]["metadata"].(map[string]any)
+	if cmMeta["name"] != analysis.BpfmanConfigMapName || cmMeta["namespace"] != "bpfman-test" {
+		t.Errorf("got ConfigMap metadata %v", cmMeta)
+	}
+}
@@ -3,6 +3,7 @@ package manifests
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/openshift/bpfman-catalog/pkg/catalog"
@@ -13,6 +14,11 @@ type GeneratorConfig struct {
 	ImageRef      string // Catalog or bundle image reference
 	Namespace     string // Target namespace (default: bpfman)
 	UseDigestName bool   // Whether to suffix resources with digest
+
+	AuthFile         string // Optional auth file to derive a catalog pull secret from
+	GlobalPullSecret string // Optional current cluster pull secret to merge the auths into
+
+	Timestamp time.Time // Time recorded in the CatalogSource display name (default: now)
 }
 
 // LabelContext contains labeling information for consistent resource labeling.
@@ -123,7 +129,11 @@ func (g *Generator) NewCatalogSource(meta CatalogMetadata) *CatalogSource {
 			Labels:    g.getMergedLabels(nil),
 		},
 		Spec: func() CatalogSourceSpec {
-			timestamp := time.Now().Format("2006-01-02T15:04:05")
+			now := g.config.Timestamp
+			if now.IsZero() {
+				now = time.Now()
+			}
+			timestamp := now.Format("2006-01-02T15:04:05")
 			return CatalogSourceSpec{
 				SourceType:  "grpc",
 				Image:       meta.Image,
@@ -211,7 +221,47 @@ func (g *Generator) GenerateFromCatalog(ctx context.Context) (*ManifestSet, erro
 	g.setupLabelContext(digestSuffix)
 
 	catalogMeta := createCatalogMetadata(meta)
-	return g.buildManifestSet(catalogMeta, meta.DefaultChannel)
+	manifestSet, err := g.buildManifestSet(catalogMeta, meta.DefaultChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.config.AuthFile != "" {
+		images := append([]string{catalogMeta.Image}, meta.BundleImages...)
+		if err := g.addPullSecret(manifestSet, images); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifestSet, nil
+}
+
+// addPullSecret adds a pull secret, holding only the credentials for
+// the registries the images live in, and references it from the
+// CatalogSource. If a global pull secret was supplied the same
+// credentials are merged into it as a patch.
+func (g *Generator) addPullSecret(manifestSet *ManifestSet, images []string) error {
+	dockerConfigJSON, err := FilterAuthFile(g.config.AuthFile, images)
+	if err != nil {
+		return fmt.Errorf("building pull secret: %w", err)
+	}
+
+	manifestSet.PullSecret = g.NewPullSecret(dockerConfigJSON)
+	manifestSet.CatalogSource.Spec.Secrets = []string{manifestSet.PullSecret.ObjectMeta.Name}
+
+	if g.config.GlobalPullSecret != "" {
+		current, err := os.ReadFile(g.config.GlobalPullSecret)
+		if err != nil {
+			return fmt.Errorf("reading global pull secret: %w", err)
+		}
+		merged, err := MergeDockerConfig(current, dockerConfigJSON)
+		if err != nil {
+			return fmt.Errorf("merging global pull secret: %w", err)
+		}
+		manifestSet.GlobalPullSecretPatch = NewGlobalPullSecretPatch(merged)
+	}
+
+	return nil
 }
 
 func getDigestSuffix(useDigestName bool, shortDigest string) string {
@@ -0,0 +1,165 @@
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+)
+
+// dockerConfigJSONKey is the data key of a kubernetes.io/dockerconfigjson
+// Secret.
+const dockerConfigJSONKey = ".dockerconfigjson"
+
+// dockerConfig is the on-disk format of container auth files such as
+// ${XDG_RUNTIME_DIR}/containers/auth.json and ~/.docker/config.json.
+// Entries are kept verbatim so credential fields are not lost.
+type dockerConfig struct {
+	Auths map[string]json.RawMessage `json:"auths"`
+}
+
+// FilterAuthFile reads a container auth file and returns a
+// dockerconfigjson containing only the entries that apply to the
+// given images. It is an error if no entry matches.
+func FilterAuthFile(authFile string, images []string) ([]byte, error) {
+	data, err := os.ReadFile(authFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading auth file: %w", err)
+	}
+
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing auth file %s: %w", authFile, err)
+	}
+
+	filtered := dockerConfig{Auths: make(map[string]json.RawMessage)}
+	for _, img := range images {
+		location, err := imageLocation(img)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := matchAuthKey(config.Auths, location); ok {
+			filtered.Auths[key] = config.Auths[key]
+		}
+	}
+
+	if len(filtered.Auths) == 0 {
+		return nil, fmt.Errorf("no credentials in %s match the registries referenced by the catalog", authFile)
+	}
+
+	out, err := json.Marshal(filtered)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling filtered auths: %w", err)
+	}
+
+	return out, nil
+}
+
+// MergeDockerConfig merges the auth entries of extra into base.
+// Entries in extra take precedence.
+func MergeDockerConfig(base, extra []byte) ([]byte, error) {
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(base, &merged); err != nil {
+		return nil, fmt.Errorf("parsing base pull secret: %w", err)
+	}
+	if merged == nil {
+		return nil, fmt.Errorf("parsing base pull secret: not a JSON object")
+	}
+
+	var baseConfig, extraConfig dockerConfig
+	if err := json.Unmarshal(base, &baseConfig); err != nil {
+		return nil, fmt.Errorf("parsing base pull secret: %w", err)
+	}
+	if err := json.Unmarshal(extra, &extraConfig); err != nil {
+		return nil, fmt.Errorf("parsing pull secret: %w", err)
+	}
+
+	if baseConfig.Auths == nil {
+		baseConfig.Auths = make(map[string]json.RawMessage)
+	}
+	for key, entry := range extraConfig.Auths {
+		baseConfig.Auths[key] = entry
+	}
+
+	auths, err := json.Marshal(baseConfig.Auths)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling merged auths: %w", err)
+	}
+	merged["auths"] = auths
+
+	return json.Marshal(merged)
+}
+
+// imageLocation returns the registry host and repository path of an
+// image reference, e.g. quay.io/user/repo.
+func imageLocation(img string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(img, "docker://"))
+	if err != nil {
+		return "", fmt.Errorf("parsing image reference %s: %w", img, err)
+	}
+	return reference.Domain(named) + "/" + reference.Path(named), nil
+}
+
+// matchAuthKey finds the most specific auth entry for an image
+// location. Keys may name a registry or a namespace or repository
+// within it, as in containers-auth.json(5).
+func matchAuthKey(auths map[string]json.RawMessage, location string) (string, bool) {
+	best := ""
+	found := false
+	for key := range auths {
+		normalised := normaliseAuthKey(key)
+		if location != normalised && !strings.HasPrefix(location, normalised+"/") {
+			continue
+		}
+		if !found || len(normalised) > len(normaliseAuthKey(best)) {
+			best = key
+			found = true
+		}
+	}
+	return best, found
+}
+
+// normaliseAuthKey strips URL decoration from legacy auth keys such
+// as https://index.docker.io/v1/.
+func normaliseAuthKey(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	key = strings.TrimSuffix(key, "/")
+	key = strings.TrimSuffix(key, "/v1")
+	if key == "index.docker.io" || key == "registry-1.docker.io" {
+		key = "docker.io"
+	}
+	return key
+}
+
+// NewPullSecret creates a dockerconfigjson secret in the marketplace
+// namespace with consistent labelling.
+func (g *Generator) NewPullSecret(dockerConfigJSON []byte) *Secret {
+	return &Secret{
+		TypeMeta: TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: ObjectMeta{
+			Name:      g.generateResourceName("bpfman-catalog-pull-secret"),
+			Namespace: "openshift-marketplace",
+			Labels:    g.getMergedLabels(nil),
+		},
+		Type: "kubernetes.io/dockerconfigjson",
+		Data: map[string][]byte{
+			dockerConfigJSONKey: dockerConfigJSON,
+		},
+	}
+}
+
+// NewGlobalPullSecretPatch creates a merge patch for the cluster-wide
+// openshift-config/pull-secret.
+func NewGlobalPullSecretPatch(dockerConfigJSON []byte) *SecretPatch {
+	return &SecretPatch{
+		Data: map[string][]byte{
+			dockerConfigJSONKey: dockerConfigJSON,
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package manifests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestFilterAuthFile tests that only credentials for registries
+// referenced by the catalog are copied into the pull secret.
+func TestFilterAuthFile(t *testing.T) {
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	authJSON := `{
+  "auths": {
+    "quay.io": {"auth": "cXVheQ=="},
+    "quay.io/alice": {"auth": "YWxpY2U="},
+    "registry.redhat.io": {"auth": "cmVkaGF0"},
+    "https://index.docker.io/v1/": {"auth": "ZG9ja2Vy"},
+    "ghcr.io": {"auth": "Z2hjcg=="}
+  }
+}`
+	if err := os.WriteFile(authFile, []byte(authJSON), 0600); err != nil {
+		t.Fatalf("writing auth file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		images   []string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			name:     "registry level entry",
+			images:   []string{"quay.io/bob/catalog@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+			wantKeys: []string{"quay.io"},
+		},
+		{
+			name:     "most specific namespace entry wins",
+			images:   []string{"quay.io/alice/catalog:latest"},
+			wantKeys: []string{"quay.io/alice"},
+		},
+		{
+			name: "catalog and bundle registries",
+			images: []string{
+				"quay.io/alice/catalog:latest",
+				"registry.redhat.io/bpfman/bpfman-operator-bundle@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			},
+			wantKeys: []string{"quay.io/alice", "registry.redhat.io"},
+		},
+		{
+			name:     "legacy docker hub key",
+			images:   []string{"library/busybox:latest"},
+			wantKeys: []string{"https://index.docker.io/v1/"},
+		},
+		{
+			name:    "no matching registry",
+			images:  []string{"example.com/foo/bar:latest"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := FilterAuthFile(authFile, tt.images)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var config dockerConfig
+			if err := json.Unmarshal(out, &config); err != nil {
+				t.Fatalf("parsing output: %v", err)
+			}
+
+			var keys []string
+			for key := range config.Auths {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			if len(keys) != len(tt.wantKeys) {
+				t.Fatalf("got keys %v, want %v", keys, tt.wantKeys)
+			}
+			for i := range keys {
+				if keys[i] != tt.wantKeys[i] {
+					t.Errorf("got keys %v, want %v", keys, tt.wantKeys)
+				}
+			}
+		})
+	}
+}
+
+// TestMergeDockerConfig tests that merging preserves existing cluster
+// credentials and unrelated top-level fields.
+func TestMergeDockerConfig(t *testing.T) {
+	base := []byte(`{"auths":{"cloud.openshift.com":{"auth":"b2Nt"},"quay.io":{"auth":"b2xk"}},"credHelpers":{"gcr.io":"gcloud"}}`)
+	extra := []byte(`{"auths":{"quay.io":{"auth":"bmV3"}}}`)
+
+	merged, err := MergeDockerConfig(base, extra)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Auths       map[string]map[string]string `json:"auths"`
+		CredHelpers map[string]string            `json:"credHelpers"`
+	}
+	if err := json.Unmarshal(merged, &out); err != nil {
+		t.Fatalf("parsing output: %v", err)
+	}
+
+	if out.Auths["cloud.openshift.com"]["auth"] != "b2Nt" {
+		t.Errorf("existing credentials were not preserved: %s", merged)
+	}
+	if out.Auths["quay.io"]["auth"] != "bmV3" {
+		t.Errorf("new credentials did not take precedence: %s", merged)
+	}
+	if out.CredHelpers["gcr.io"] != "gcloud" {
+		t.Errorf("unrelated fields were not preserved: %s", merged)
+	}
+}
+
+// TestMergeDockerConfigInvalidBase tests that a base pull secret that
+// is not a JSON object is rejected.
+func TestMergeDockerConfigInvalidBase(t *testing.T) {
+	extra := []byte(`{"auths":{"quay.io":{"auth":"bmV3"}}}`)
+	for _, base := range []string{"null", "[]", `"auths"`, ""} {
+		if _, err := MergeDockerConfig([]byte(base), extra); err == nil || !strings.Contains(err.Error(), "base pull secret") {
+			t.Errorf("base %q: got error %v", base, err)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package manifests
 
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
 // ObjectMeta represents standard Kubernetes object metadata.
 type ObjectMeta struct {
 	Name        string            `json:"name"`
@@ -29,10 +34,11 @@ type CatalogSource struct {
 
 // CatalogSourceSpec defines the spec for a CatalogSource.
 type CatalogSourceSpec struct {
-	SourceType  string `json:"sourceType"`
-	Image       string `json:"image"`
-	DisplayName string `json:"displayName"`
-	Publisher   string `json:"publisher"`
+	SourceType  string   `json:"sourceType"`
+	Image       string   `json:"image"`
+	DisplayName string   `json:"displayName"`
+	Publisher   string   `json:"publisher"`
+	Secrets     []string `json:"secrets,omitempty"`
 }
 
 // OperatorGroup represents an OLM OperatorGroup.
@@ -63,6 +69,19 @@ type SubscriptionSpec struct {
 	InstallPlanApproval string `json:"installPlanApproval"`
 }
 
+// Secret represents a Kubernetes Secret.
+type Secret struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Type       string            `json:"type"`
+	Data       map[string][]byte `json:"data"`
+}
+
+// SecretPatch is a merge patch for the data of an existing Secret.
+type SecretPatch struct {
+	Data map[string][]byte `json:"data"`
+}
+
 // ImageDigestMirrorSet represents an OpenShift ImageDigestMirrorSet.
 type ImageDigestMirrorSet struct {
 	TypeMeta   `json:",inline"`
@@ -91,9 +110,136 @@ type CatalogMetadata struct {
 
 // ManifestSet contains all manifests needed for deployment.
 type ManifestSet struct {
-	Namespace     *Namespace
-	IDMS          *ImageDigestMirrorSet
-	CatalogSource *CatalogSource
-	OperatorGroup *OperatorGroup
-	Subscription  *Subscription
+	Namespace             *Namespace
+	IDMS                  *ImageDigestMirrorSet
+	PullSecret            *Secret // Optional, referenced by the CatalogSource
+	CatalogSource         *CatalogSource
+	OperatorGroup         *OperatorGroup
+	Subscription          *Subscription
+	GlobalPullSecretPatch *SecretPatch // Optional patch for openshift-config/pull-secret
+}
+
+// Application represents an Argo CD Application.
+type Application struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       ApplicationSpec `json:"spec"`
+}
+
+// ApplicationSpec defines the spec for an Argo CD Application.
+type ApplicationSpec struct {
+	Project     string                 `json:"project"`
+	Source      ApplicationSource      `json:"source"`
+	Destination ApplicationDestination `json:"destination"`
+	SyncPolicy  *SyncPolicy            `json:"syncPolicy,omitempty"`
+}
+
+// ApplicationSource defines where an Application's manifests live.
+type ApplicationSource struct {
+	RepoURL        string `json:"repoURL"`
+	TargetRevision string `json:"targetRevision"`
+	Path           string `json:"path"`
+}
+
+// ApplicationDestination defines the cluster an Application syncs
+// to.
+type ApplicationDestination struct {
+	Server string `json:"server"`
+}
+
+// SyncPolicy controls when and how an Application is synced.
+type SyncPolicy struct {
+	Automated   *SyncPolicyAutomated `json:"automated,omitempty"`
+	SyncOptions []string             `json:"syncOptions,omitempty"`
+}
+
+// SyncPolicyAutomated enables automated sync of an Application.
+type SyncPolicyAutomated struct {
+	Prune    bool `json:"prune"`
+	SelfHeal bool `json:"selfHeal"`
+}
+
+// ArgoCDPatch is a merge patch for an ArgoCD resource managed by the
+// OpenShift GitOps operator.
+type ArgoCDPatch struct {
+	Spec ArgoCDPatchSpec `json:"spec"`
+}
+
+// ArgoCDPatchSpec sets the custom resource health checks of an ArgoCD
+// resource.
+type ArgoCDPatchSpec struct {
+	ResourceHealthChecks []ResourceHealthCheck `json:"resourceHealthChecks"`
+}
+
+// ResourceHealthCheck is a Lua health check for a kind of resource.
+type ResourceHealthCheck struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Check string `json:"check"`
+}
+
+// ArgoCDSet contains the Argo CD resources for a GitOps rollout of a
+// ManifestSet.
+type ArgoCDSet struct {
+	CatalogApplication      *Application
+	SubscriptionApplication *Application
+	HealthChecks            *ArgoCDPatch // Patch for the ArgoCD resource
+}
+
+// ServiceAccount represents a Kubernetes ServiceAccount.
+type ServiceAccount struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+}
+
+// Role represents a Kubernetes Role or ClusterRole.
+type Role struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Rules      []rbacv1.PolicyRule `json:"rules"`
+}
+
+// RoleBinding represents a Kubernetes RoleBinding or
+// ClusterRoleBinding.
+type RoleBinding struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Subjects   []Subject `json:"subjects"`
+	RoleRef    RoleRef   `json:"roleRef"`
+}
+
+// Subject identifies the account a binding grants a role to.
+type Subject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RoleRef identifies the role a binding refers to.
+type RoleRef struct {
+	APIGroup string `json:"apiGroup"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+}
+
+// Deployment represents a Kubernetes Deployment. The spec is taken
+// verbatim from a CSV install strategy.
+type Deployment struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       appsv1.DeploymentSpec `json:"spec"`
+}
+
+// BundleInstallSet contains all manifests needed to install a bundle
+// directly, without OLM.
+type BundleInstallSet struct {
+	Namespace           *Namespace
+	CRDs                []map[string]any // Copied from the bundle manifests
+	ConfigMaps          []map[string]any // Copied from the bundle manifests
+	ServiceAccounts     []*ServiceAccount
+	ClusterRoles        []*Role
+	ClusterRoleBindings []*RoleBinding
+	Roles               []*Role
+	RoleBindings        []*RoleBinding
+	Deployments         []*Deployment
 }
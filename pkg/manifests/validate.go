@@ -0,0 +1,308 @@
+package manifests
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/openshift/bpfman-catalog/pkg/analysis"
+	"github.com/openshift/bpfman-catalog/pkg/schema"
+	olmcrds "github.com/operator-framework/api/crds"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed schemas/*.yaml
+var schemaFiles embed.FS
+
+// ValidationIssue describes a problem found in a generated manifest.
+type ValidationIssue struct {
+	File    string `json:"file,omitempty"` // Source file, when validating a directory
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// String returns a one-line description of the issue.
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		b.WriteString(i.File)
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "%s/%s", i.Kind, i.Name)
+	if i.Path != "" {
+		b.WriteString(" ")
+		b.WriteString(i.Path)
+	}
+	b.WriteString(": ")
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// loadSchemas indexes validators for the structural schemas of the
+// OLM CRDs bundled with operator-framework/api and the
+// config.openshift.io CRDs embedded in this package by
+// "group/version, Kind".
+var loadSchemas = sync.OnceValues(func() (map[string]*schema.Validator, error) {
+	crds := []*apiextv1.CustomResourceDefinition{
+		olmcrds.CatalogSource(),
+		olmcrds.OperatorGroup(),
+		olmcrds.Subscription(),
+	}
+
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		return nil, fmt.Errorf("reading embedded schemas: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading embedded schema %s: %w", entry.Name(), err)
+		}
+		var crd apiextv1.CustomResourceDefinition
+		if err := yaml.Unmarshal(data, &crd); err != nil {
+			return nil, fmt.Errorf("parsing embedded schema %s: %w", entry.Name(), err)
+		}
+		crds = append(crds, &crd)
+	}
+
+	schemas := make(map[string]*schema.Validator)
+	for _, crd := range crds {
+		for _, v := range crd.Spec.Versions {
+			s, ok := schema.CRDSchema(crd, v.Name)
+			if !ok {
+				continue
+			}
+			validator, err := schema.NewValidator(s)
+			if err != nil {
+				return nil, fmt.Errorf("loading schema of %s %s: %w", crd.Name, v.Name, err)
+			}
+			schemas[gvkKey(crd.Spec.Group+"/"+v.Name, crd.Spec.Names.Kind)] = validator
+		}
+	}
+
+	return schemas, nil
+})
+
+func gvkKey(apiVersion, kind string) string {
+	return apiVersion + ", " + kind
+}
+
+// ValidateObject validates a decoded object against its embedded
+// schema, if one is known, and against Kubernetes object name and
+// label rules.
+func ValidateObject(obj map[string]any) ([]ValidationIssue, error) {
+	schemas, err := loadSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	meta, _ := obj["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+
+	var issues []ValidationIssue
+	add := func(path, message string) {
+		issues = append(issues, ValidationIssue{Kind: kind, Name: name, Path: path, Message: message})
+	}
+
+	if apiVersion == "" || kind == "" {
+		add("", "apiVersion and kind must be set")
+		return issues, nil
+	}
+
+	if validator, ok := schemas[gvkKey(apiVersion, kind)]; ok {
+		for _, fieldErr := range validator.Validate(obj) {
+			add(fieldErr.Path, fieldErr.Message)
+		}
+	}
+
+	for _, msg := range validateMetadata(kind, meta) {
+		add(msg.path, msg.message)
+	}
+
+	return issues, nil
+}
+
+type metadataError struct {
+	path    string
+	message string
+}
+
+// validateMetadata applies the API server's rules for object names,
+// namespaces, labels and annotations. CatalogSource names are also
+// used for a Service and as a label value on the registry pod, so
+// they must be DNS-1035 labels of at most 63 characters.
+func validateMetadata(kind string, meta map[string]any) []metadataError {
+	var errs []metadataError
+	addAll := func(path string, msgs []string) {
+		for _, msg := range msgs {
+			errs = append(errs, metadataError{path: path, message: msg})
+		}
+	}
+
+	name, _ := meta["name"].(string)
+	if name == "" {
+		errs = append(errs, metadataError{path: ".metadata.name", message: "name is required"})
+	} else {
+		switch kind {
+		case "Namespace":
+			addAll(".metadata.name", validation.IsDNS1123Label(name))
+		case "CatalogSource":
+			addAll(".metadata.name", validation.IsDNS1035Label(name))
+		default:
+			addAll(".metadata.name", validation.IsDNS1123Subdomain(name))
+		}
+	}
+
+	if ns, ok := meta["namespace"].(string); ok && ns != "" {
+		addAll(".metadata.namespace", validation.IsDNS1123Label(ns))
+	}
+
+	if labels, ok := meta["labels"].(map[string]any); ok {
+		for k, v := range labels {
+			path := fmt.Sprintf(".metadata.labels[%s]", k)
+			addAll(path, validation.IsQualifiedName(k))
+			value, ok := v.(string)
+			if !ok {
+				errs = append(errs, metadataError{path: path, message: "label value must be a string"})
+				continue
+			}
+			addAll(path, validation.IsValidLabelValue(value))
+		}
+	}
+
+	if annotations, ok := meta["annotations"].(map[string]any); ok {
+		for k := range annotations {
+			addAll(fmt.Sprintf(".metadata.annotations[%s]", k), validation.IsQualifiedName(strings.ToLower(k)))
+		}
+	}
+
+	return errs
+}
+
+// ValidateBundleInstallSet validates every object in a
+// BundleInstallSet. Kinds without an embedded schema, such as
+// Deployments and RBAC, are checked against the object metadata
+// rules only.
+func ValidateBundleInstallSet(installSet *BundleInstallSet) ([]ValidationIssue, error) {
+	var objects []any
+	if installSet.Namespace != nil {
+		objects = append(objects, installSet.Namespace)
+	}
+	for _, crd := range installSet.CRDs {
+		objects = append(objects, crd)
+	}
+	for _, cm := range installSet.ConfigMaps {
+		objects = append(objects, cm)
+	}
+	for _, sa := range installSet.ServiceAccounts {
+		objects = append(objects, sa)
+	}
+	for _, role := range installSet.ClusterRoles {
+		objects = append(objects, role)
+	}
+	for _, binding := range installSet.ClusterRoleBindings {
+		objects = append(objects, binding)
+	}
+	for _, role := range installSet.Roles {
+		objects = append(objects, role)
+	}
+	for _, binding := range installSet.RoleBindings {
+		objects = append(objects, binding)
+	}
+	for _, deployment := range installSet.Deployments {
+		objects = append(objects, deployment)
+	}
+	return validateObjects(objects)
+}
+
+// ValidateManifestSet validates every object in a ManifestSet.
+func ValidateManifestSet(manifestSet *ManifestSet) ([]ValidationIssue, error) {
+	var objects []any
+	if manifestSet.Namespace != nil {
+		objects = append(objects, manifestSet.Namespace)
+	}
+	if manifestSet.IDMS != nil {
+		objects = append(objects, manifestSet.IDMS)
+	}
+	if manifestSet.PullSecret != nil {
+		objects = append(objects, manifestSet.PullSecret)
+	}
+	if manifestSet.CatalogSource != nil {
+		objects = append(objects, manifestSet.CatalogSource)
+	}
+	if manifestSet.OperatorGroup != nil {
+		objects = append(objects, manifestSet.OperatorGroup)
+	}
+	if manifestSet.Subscription != nil {
+		objects = append(objects, manifestSet.Subscription)
+	}
+	return validateObjects(objects)
+}
+
+// validateObjects validates typed or unstructured objects.
+func validateObjects(objects []any) ([]ValidationIssue, error) {
+	var issues []ValidationIssue
+	for _, object := range objects {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling manifest: %w", err)
+		}
+		var obj map[string]any
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("decoding manifest: %w", err)
+		}
+
+		objIssues, err := ValidateObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, objIssues...)
+	}
+
+	return issues, nil
+}
+
+// ValidateDir validates every object in the YAML files below dir.
+// Files in patches/ directories are skipped as they hold partial
+// objects rather than complete manifests.
+func ValidateDir(dir string) ([]ValidationIssue, error) {
+	loaded, err := analysis.LoadManifestDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading manifests: %w", err)
+	}
+
+	var issues []ValidationIssue
+	for _, m := range loaded {
+		if isPatchFile(m.File) {
+			continue
+		}
+		objIssues, err := ValidateObject(m.Object)
+		if err != nil {
+			return nil, err
+		}
+		for i := range objIssues {
+			objIssues[i].File = m.File
+		}
+		issues = append(issues, objIssues...)
+	}
+
+	return issues, nil
+}
+
+func isPatchFile(file string) bool {
+	for _, part := range strings.Split(path.Dir(file), "/") {
+		if part == "patches" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package manifests
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateManifestSet tests that generated manifests validate
+// cleanly and that names exceeding Kubernetes limits are reported.
+func TestValidateManifestSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		wantIssue string
+	}{
+		{
+			name:      "generated manifests are valid",
+			namespace: "bpfman",
+		},
+		{
+			name:      "namespace name too long",
+			namespace: "bpfman-" + strings.Repeat("x", 60),
+			wantIssue: "Namespace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(GeneratorConfig{Namespace: tt.namespace, UseDigestName: true})
+			g.setupLabelContext("0123abcd")
+
+			manifestSet, err := g.buildManifestSet(CatalogMetadata{
+				Image:       "quay.io/example/catalog@sha256:0123abcd00000000000000000000000000000000000000000000000000000000",
+				ShortDigest: "0123abcd",
+				CatalogType: "catalog-ystream",
+			}, "preview")
+			if err != nil {
+				t.Fatalf("building manifest set: %v", err)
+			}
+
+			issues, err := ValidateManifestSet(manifestSet)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantIssue == "" {
+				if len(issues) != 0 {
+					t.Fatalf("expected no issues, got %v", issues)
+				}
+				return
+			}
+
+			found := false
+			for _, issue := range issues {
+				if issue.Kind == tt.wantIssue && issue.Path == ".metadata.name" {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected a %s name issue, got %v", tt.wantIssue, issues)
+			}
+		})
+	}
+}
+
+// TestValidateBundleInstallSet tests that bundle install objects are
+// checked against Kubernetes name rules.
+func TestValidateBundleInstallSet(t *testing.T) {
+	installSet := &BundleInstallSet{
+		Namespace:       &Namespace{TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: ObjectMeta{Name: "bpfman"}},
+		ServiceAccounts: []*ServiceAccount{{TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"}, ObjectMeta: ObjectMeta{Name: "Bpfman_Operator", Namespace: "bpfman"}}},
+	}
+
+	issues, err := ValidateBundleInstallSet(installSet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Kind != "ServiceAccount" || issues[0].Path != ".metadata.name" {
+		t.Errorf("expected one ServiceAccount name issue, got %v", issues)
+	}
+}
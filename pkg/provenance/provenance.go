@@ -0,0 +1,182 @@
+// Package provenance makes generated artefacts reproducible and
+// records the inputs they were generated from.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// File is the name of the provenance record written alongside
+// generated artefacts.
+const File = "artefacts.json"
+
+// SourceDateEpochEnv is the environment variable fixing timestamps,
+// as defined by https://reproducible-builds.org/specs/source-date-epoch/.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// executableName is recorded in generated files in place of the
+// running binary's path in deterministic mode.
+const executableName = "bpfman-catalog"
+
+// Settings fixes the timestamps and random values in generated
+// artefacts. The zero value generates them afresh on every run.
+type Settings struct {
+	Deterministic   bool
+	SourceDateEpoch *int64 // Fixed timestamp, in seconds since the Unix epoch
+	Seed            string // Seed for generated names and TTLs
+}
+
+// FromEnvironment returns the settings for a seed and the
+// SOURCE_DATE_EPOCH environment variable. Either one enables
+// deterministic mode: without a seed, SOURCE_DATE_EPOCH is used as
+// the seed, and without SOURCE_DATE_EPOCH, timestamps are the Unix
+// epoch.
+func FromEnvironment(seed string) (Settings, error) {
+	settings := Settings{Seed: seed}
+	if value := os.Getenv(SourceDateEpochEnv); value != "" {
+		epoch, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || epoch < 0 {
+			return Settings{}, fmt.Errorf("invalid %s %q: must be a non-negative number of seconds", SourceDateEpochEnv, value)
+		}
+		settings.SourceDateEpoch = &epoch
+		if settings.Seed == "" {
+			settings.Seed = value
+		}
+	}
+	settings.Deterministic = settings.Seed != ""
+	return settings, nil
+}
+
+// Now returns the time to record in generated artefacts.
+func (s Settings) Now() time.Time {
+	if !s.Deterministic {
+		return time.Now()
+	}
+	if s.SourceDateEpoch == nil {
+		return time.Unix(0, 0).UTC()
+	}
+	return time.Unix(*s.SourceDateEpoch, 0).UTC()
+}
+
+// Rand returns a source of random values. In deterministic mode each
+// call returns a new source seeded from the seed, so values drawn in
+// the same order are the same in every run and every call.
+func (s Settings) Rand() *rand.Rand {
+	if !s.Deterministic {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	sum := sha256.Sum256([]byte(s.Seed))
+	return rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+}
+
+// Executable returns the path of the running binary, for generated
+// files that invoke it. In deterministic mode it is the binary's name,
+// which is expected to be on the PATH.
+func (s Settings) Executable() string {
+	if s.Deterministic {
+		return executableName
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return executableName
+	}
+	return path
+}
+
+// ToolVersion returns the version of the running binary: the module
+// version when built from a release, or the VCS revision it was built
+// from.
+func ToolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "devel"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return "devel-" + revision
+}
+
+// Record lists the inputs a set of artefacts was generated from.
+type Record struct {
+	ToolVersion     string            `json:"tool_version"`
+	Command         string            `json:"command"`
+	Bundles         []Bundle          `json:"bundles,omitempty"`
+	Catalog         *Catalog          `json:"catalog,omitempty"`
+	TemplateType    string            `json:"template_type,omitempty"`
+	OpmMode         string            `json:"opm_mode,omitempty"`   // library or binary
+	OpmBinary       string            `json:"opm_binary,omitempty"` // Set in binary mode
+	MigrationLevel  string            `json:"migration_level,omitempty"`
+	Templates       map[string]string `json:"templates,omitempty"` // Digests of overridden templates by name
+	Deterministic   bool              `json:"deterministic"`
+	SourceDateEpoch *int64            `json:"source_date_epoch,omitempty"`
+	Seed            string            `json:"seed,omitempty"`
+}
+
+// Bundle records a bundle image and the digest it resolved to. A
+// bundle whose digest could not be resolved records why instead.
+type Bundle struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Catalog records a catalog file and the digest of its content.
+type Catalog struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+// Opm modes recorded for catalog rendering.
+const (
+	OpmModeLibrary = "library"
+	OpmModeBinary  = "binary"
+)
+
+// NewRecord returns a record for a command run with the settings.
+func NewRecord(command string, settings Settings) *Record {
+	return &Record{
+		ToolVersion:     ToolVersion(),
+		Command:         command,
+		Deterministic:   settings.Deterministic,
+		SourceDateEpoch: settings.SourceDateEpoch,
+		Seed:            settings.Seed,
+	}
+}
+
+// Marshal returns the record as indented JSON.
+func (r *Record) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling provenance record: %w", err)
+	}
+	return append(data, '\n'), nil
+}
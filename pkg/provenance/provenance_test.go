@@ -0,0 +1,65 @@
+package provenance
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFromEnvironment tests which inputs enable deterministic mode
+// and the timestamps and seeds they give.
+func TestFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name              string
+		epoch             string
+		seed              string
+		wantDeterministic bool
+		wantNow           time.Time
+		wantSeed          string
+		wantErr           bool
+	}{
+		{name: "neither"},
+		{name: "seed only", seed: "review", wantDeterministic: true, wantNow: time.Unix(0, 0), wantSeed: "review"},
+		{name: "epoch only", epoch: "1700000000", wantDeterministic: true, wantNow: time.Unix(1700000000, 0), wantSeed: "1700000000"},
+		{name: "epoch and seed", epoch: "1700000000", seed: "review", wantDeterministic: true, wantNow: time.Unix(1700000000, 0), wantSeed: "review"},
+		{name: "invalid epoch", epoch: "yesterday", wantErr: true},
+		{name: "negative epoch", epoch: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SourceDateEpochEnv, tt.epoch)
+			settings, err := FromEnvironment(tt.seed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if settings.Deterministic != tt.wantDeterministic || settings.Seed != tt.wantSeed {
+				t.Fatalf("got %+v", settings)
+			}
+			if tt.wantDeterministic && !settings.Now().Equal(tt.wantNow) {
+				t.Errorf("Now: got %v, want %v", settings.Now(), tt.wantNow)
+			}
+			if !tt.wantDeterministic && settings.Executable() == executableName {
+				t.Errorf("executable path not resolved outside deterministic mode")
+			}
+		})
+	}
+}
+
+// TestRand tests that deterministic sources repeat and depend on the
+// seed.
+func TestRand(t *testing.T) {
+	a := Settings{Deterministic: true, Seed: "a"}
+	b := Settings{Deterministic: true, Seed: "b"}
+	if a.Rand().Int63() != a.Rand().Int63() {
+		t.Error("same seed gave different values")
+	}
+	if a.Rand().Int63() == b.Rand().Int63() {
+		t.Error("different seeds gave the same value")
+	}
+}
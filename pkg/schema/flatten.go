@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"sort"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Field is a single field of a flattened schema.
+type Field struct {
+	Path     string // JSON path, with [] for array items, e.g. .spec.programs[].name
+	Type     string // OpenAPI type, or "int-or-string"
+	Required bool   // Whether the parent object requires the field
+	Enum     []string
+}
+
+// Flatten returns every field declared by a schema keyed by JSON
+// path. Maps declared through additionalProperties are recorded with
+// a path element of [*].
+func Flatten(s *apiextv1.JSONSchemaProps) map[string]Field {
+	fields := make(map[string]Field)
+	flatten(s, "", false, fields)
+	return fields
+}
+
+// SortedPaths returns the paths of a flattened schema in order.
+func SortedPaths(fields map[string]Field) []string {
+	paths := make([]string, 0, len(fields))
+	for p := range fields {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func flatten(s *apiextv1.JSONSchemaProps, path string, required bool, fields map[string]Field) {
+	if s == nil {
+		return
+	}
+
+	if path != "" {
+		field := Field{
+			Path:     path,
+			Type:     schemaType(s),
+			Required: required,
+		}
+		for _, e := range s.Enum {
+			field.Enum = append(field.Enum, string(e.Raw))
+		}
+		fields[path] = field
+	}
+
+	requiredSet := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		requiredSet[name] = true
+	}
+	for name, prop := range s.Properties {
+		flatten(&prop, path+"."+name, requiredSet[name], fields)
+	}
+
+	if s.Items != nil && s.Items.Schema != nil {
+		flatten(s.Items.Schema, path+"[]", false, fields)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		flatten(s.AdditionalProperties.Schema, path+"[*]", false, fields)
+	}
+}
+
+func schemaType(s *apiextv1.JSONSchemaProps) string {
+	if s.XIntOrString {
+		return "int-or-string"
+	}
+	if s.Type == "" {
+		return "any"
+	}
+	return s.Type
+}
@@ -0,0 +1,134 @@
+// Package schema validates objects against the structural OpenAPI v3
+// schemas found in CustomResourceDefinitions, without a cluster.
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/listtype"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+)
+
+// FieldError describes a single schema violation.
+type FieldError struct {
+	Path    string `json:"path"`    // JSON path of the offending field, e.g. .spec.sourceType
+	Message string `json:"message"` // What is wrong with it
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validator validates objects against a CRD version's schema with
+// the API server's own validation: the OpenAPI value validations
+// (including oneOf, anyOf, allOf, not and formats), list types and
+// x-kubernetes-validations rules.
+type Validator struct {
+	structural *structuralschema.Structural
+	openAPI    validation.SchemaValidator
+	cel        *cel.Validator // nil when the schema has no rules
+}
+
+// NewValidator returns a validator for a schema, which must be
+// structural, as the API server requires of CRD schemas.
+func NewValidator(s *apiextv1.JSONSchemaProps) (*Validator, error) {
+	var internal apiextensions.JSONSchemaProps
+	if err := apiextv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(s, &internal, nil); err != nil {
+		return nil, fmt.Errorf("converting schema: %w", err)
+	}
+	structural, err := structuralschema.NewStructural(&internal)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not structural: %w", err)
+	}
+	openAPI, _, err := validation.NewSchemaValidator(&internal)
+	if err != nil {
+		return nil, fmt.Errorf("building schema validator: %w", err)
+	}
+	return &Validator{
+		structural: structural,
+		openAPI:    openAPI,
+		cel:        cel.NewValidator(structural, true, celconfig.PerCallLimit),
+	}, nil
+}
+
+// Validate checks obj as the API server does when it is created.
+// Fields that are not declared by the schema are reported, rather
+// than silently pruned, which catches misspelt field names.
+//
+// Root metadata is not validated; object metadata rules are
+// validated separately by the caller.
+func (v *Validator) Validate(obj any) []FieldError {
+	// Validate a JSON copy, as pruning modifies the object and the
+	// validators expect integers to be decoded as int64.
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return []FieldError{{Message: fmt.Sprintf("encoding object: %v", err)}}
+	}
+	var copied any
+	if err := utiljson.Unmarshal(data, &copied); err != nil {
+		return []FieldError{{Message: fmt.Sprintf("decoding object: %v", err)}}
+	}
+
+	var errs []FieldError
+	for _, path := range pruning.PruneWithOptions(copied, v.structural, true, structuralschema.UnknownFieldPathOptions{TrackUnknownFieldPaths: true}) {
+		errs = append(errs, FieldError{Path: fieldPath(path), Message: "unknown field"})
+	}
+
+	fieldErrs := validation.ValidateCustomResource(nil, copied, v.openAPI)
+	if m, ok := copied.(map[string]any); ok {
+		fieldErrs = append(fieldErrs, listtype.ValidateListSetsAndMaps(nil, v.structural, m)...)
+	}
+	if v.cel != nil {
+		celErrs, _ := v.cel.Validate(context.Background(), nil, v.structural, copied, nil, celconfig.RuntimeCELCostBudget)
+		fieldErrs = append(fieldErrs, celErrs...)
+	}
+	for _, fe := range fieldErrs {
+		errs = append(errs, FieldError{Path: fieldPath(fe.Field), Message: fe.ErrorBody()})
+	}
+	return errs
+}
+
+// Validate checks obj against a structural schema; see
+// Validator.Validate.
+func Validate(s *apiextv1.JSONSchemaProps, obj any) ([]FieldError, error) {
+	v, err := NewValidator(s)
+	if err != nil {
+		return nil, err
+	}
+	return v.Validate(obj), nil
+}
+
+// CRDSchema returns the schema of the named version of a CRD.
+func CRDSchema(crd *apiextv1.CustomResourceDefinition, version string) (*apiextv1.JSONSchemaProps, bool) {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+			return v.Schema.OpenAPIV3Schema, true
+		}
+	}
+	return nil, false
+}
+
+// fieldPath converts an API server field path, e.g. spec.items[0],
+// to the JSON path form used in reports, e.g. .spec.items[0]. Errors
+// from oneOf, anyOf and not have no path and name the field in their
+// message instead.
+func fieldPath(path string) string {
+	if path == "" || path == "<nil>" || strings.HasPrefix(path, "[") {
+		return ""
+	}
+	return "." + path
+}
@@ -0,0 +1,175 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testSchema = `
+type: object
+properties:
+  apiVersion:
+    type: string
+  kind:
+    type: string
+  metadata:
+    type: object
+  spec:
+    type: object
+    required:
+    - sourceType
+    properties:
+      sourceType:
+        type: string
+        enum: [grpc, configmap]
+      displayName:
+        type: string
+        maxLength: 10
+      priority:
+        type: integer
+      config:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+      source:
+        type: object
+        properties:
+          image:
+            type: string
+          url:
+            type: string
+        oneOf:
+        - required: [image]
+        - required: [url]
+      started:
+        type: string
+        format: date-time
+      interfaces:
+        type: array
+        items:
+          type: string
+        x-kubernetes-list-type: set
+      port:
+        x-kubernetes-int-or-string: true
+      replicas:
+        type: object
+        properties:
+          min:
+            type: integer
+          max:
+            type: integer
+        x-kubernetes-validations:
+        - rule: self.min <= self.max
+          message: min must not exceed max
+`
+
+// TestValidate tests the schema checks applied to decoded objects.
+func TestValidate(t *testing.T) {
+	var s apiextv1.JSONSchemaProps
+	if err := yaml.Unmarshal([]byte(testSchema), &s); err != nil {
+		t.Fatalf("parsing schema: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		object   string
+		wantPath string
+		wantMsg  string
+	}{
+		{
+			name:   "valid object",
+			object: `{"apiVersion": "v1", "kind": "Test", "metadata": {"name": "x"}, "spec": {"sourceType": "grpc", "config": {"anything": 1}, "source": {"url": "https://example.com"}, "port": "http", "replicas": {"min": 1, "max": 2}}}`,
+		},
+		{
+			name:     "unknown field",
+			object:   `{"spec": {"sourceType": "grpc", "sorceType": "grpc"}}`,
+			wantPath: ".spec.sorceType",
+			wantMsg:  "unknown field",
+		},
+		{
+			name:     "wrong type",
+			object:   `{"spec": {"sourceType": "grpc", "priority": "high"}}`,
+			wantPath: ".spec.priority",
+			wantMsg:  "must be of type integer",
+		},
+		{
+			name:     "value not in enum",
+			object:   `{"spec": {"sourceType": "http"}}`,
+			wantPath: ".spec.sourceType",
+			wantMsg:  "Unsupported value",
+		},
+		{
+			name:     "missing required field",
+			object:   `{"spec": {}}`,
+			wantPath: ".spec.sourceType",
+			wantMsg:  "Required value",
+		},
+		{
+			name:     "string too long",
+			object:   `{"spec": {"sourceType": "grpc", "displayName": "a very long name"}}`,
+			wantPath: ".spec.displayName",
+			wantMsg:  "Too long",
+		},
+		{
+			name:    "more than one oneOf branch matches",
+			object:  `{"spec": {"sourceType": "grpc", "source": {"image": "quay.io/bpfman/go-xdp-counter", "url": "https://example.com"}}}`,
+			wantMsg: `"spec.source" must validate one and only one schema (oneOf)`,
+		},
+		{
+			name:     "invalid format",
+			object:   `{"spec": {"sourceType": "grpc", "started": "yesterday"}}`,
+			wantPath: ".spec.started",
+			wantMsg:  "date-time",
+		},
+		{
+			name:     "duplicate set item",
+			object:   `{"spec": {"sourceType": "grpc", "interfaces": ["eth0", "eth0"]}}`,
+			wantPath: ".spec.interfaces[1]",
+			wantMsg:  "Duplicate value",
+		},
+		{
+			name:     "int-or-string",
+			object:   `{"spec": {"sourceType": "grpc", "port": true}}`,
+			wantPath: ".spec.port",
+			wantMsg:  "must be of type integer,string",
+		},
+		{
+			name:     "validation rule",
+			object:   `{"spec": {"sourceType": "grpc", "replicas": {"min": 3, "max": 2}}}`,
+			wantPath: ".spec.replicas",
+			wantMsg:  "min must not exceed max",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj map[string]any
+			if err := yaml.Unmarshal([]byte(tt.object), &obj); err != nil {
+				t.Fatalf("parsing object: %v", err)
+			}
+
+			errs, err := Validate(&s, obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantMsg == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+
+			if len(errs) != 1 {
+				t.Fatalf("expected one error, got %v", errs)
+			}
+			if errs[0].Path != tt.wantPath {
+				t.Errorf("got path %q, want %q", errs[0].Path, tt.wantPath)
+			}
+			if !strings.Contains(errs[0].Message, tt.wantMsg) {
+				t.Errorf("got message %q, want it to contain %q", errs[0].Message, tt.wantMsg)
+			}
+		})
+	}
+}
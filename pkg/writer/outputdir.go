@@ -0,0 +1,247 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/yaml"
+)
+
+// MarkerFile marks a directory as generated by bpfman-catalog, so
+// that it may be replaced when the artefacts are regenerated.
+const MarkerFile = ".bpfman-catalog"
+
+const markerContent = "This directory was generated by bpfman-catalog and is replaced when it is regenerated.\n"
+
+// OutputDir stages generated files in a temporary directory beside
+// the output directory and replaces the output directory with them
+// once generation has succeeded.
+type OutputDir struct {
+	path    string
+	staging string
+	force   bool
+}
+
+// NewOutputDir checks that dir may be replaced and creates the
+// staging directory. A directory that is missing, empty or holds the
+// marker file may be replaced; any other directory is only replaced
+// when force is set.
+func NewOutputDir(dir string, force bool) (*OutputDir, error) {
+	if err := CheckOutputDir(dir, force); err != nil {
+		return nil, err
+	}
+	return newOutputDir(dir, force)
+}
+
+// NewPreviewDir creates a staging directory for files that are only
+// to be compared with dir, so dir is not checked. Commit still refuses
+// to replace a directory that NewOutputDir would.
+func NewPreviewDir(dir string) (*OutputDir, error) {
+	return newOutputDir(dir, false)
+}
+
+func newOutputDir(dir string, force bool) (*OutputDir, error) {
+	parent := filepath.Dir(filepath.Clean(dir))
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, fmt.Errorf("creating output directory parent: %w", err)
+	}
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(dir)+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	return &OutputDir{path: dir, staging: staging, force: force}, nil
+}
+
+// CheckOutputDir returns an error if dir exists, is not empty and
+// holds no marker file, unless force is set.
+func CheckOutputDir(dir string, force bool) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading output directory: %w", err)
+	}
+	if len(entries) == 0 || force {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, MarkerFile)); err == nil {
+		return nil
+	}
+	return fmt.Errorf("output directory %s is not empty and was not generated by bpfman-catalog (no %s file); use --force to replace it", dir, MarkerFile)
+}
+
+// Path returns the output directory.
+func (o *OutputDir) Path() string {
+	return o.path
+}
+
+// Staging returns the directory generated files are written to.
+func (o *OutputDir) Staging() string {
+	return o.staging
+}
+
+// Diff writes a unified diff from the output directory's existing
+// files to the staged ones. Secret values are redacted, so a changed
+// credential is reported without being shown.
+func (o *OutputDir) Diff(w io.Writer) error {
+	existing, err := readTree(o.path)
+	if err != nil {
+		return fmt.Errorf("reading output directory: %w", err)
+	}
+	staged, err := readTree(o.staging)
+	if err != nil {
+		return fmt.Errorf("reading staged files: %w", err)
+	}
+
+	var names []string
+	for name := range existing {
+		names = append(names, name)
+	}
+	for name := range staged {
+		if _, ok := existing[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changed := 0
+	for _, name := range names {
+		before, hadBefore := existing[name]
+		after, hasAfter := staged[name]
+		if hadBefore && hasAfter && bytes.Equal(before, after) {
+			continue
+		}
+		changed++
+		before, after = redactSecrets(name, before), redactSecrets(name, after)
+		if hadBefore && hasAfter && bytes.Equal(before, after) {
+			fmt.Fprintf(w, "diff %s\nSecret values changed (redacted)\n", name)
+			continue
+		}
+		fromFile, toFile := filepath.Join("a", name), filepath.Join("b", name)
+		if !hadBefore {
+			fromFile = "/dev/null"
+		}
+		if !hasAfter {
+			toFile = "/dev/null"
+		}
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(before)),
+			B:        difflib.SplitLines(string(after)),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return fmt.Errorf("diffing %s: %w", name, err)
+		}
+		fmt.Fprintf(w, "diff %s\n%s", name, text)
+	}
+	if changed == 0 {
+		fmt.Fprintf(w, "No changes to %s\n", o.path)
+	}
+	return nil
+}
+
+// redactedValue replaces each secret value in a diff.
+const redactedValue = "<redacted>"
+
+// redactSecrets returns a file's content with the data and stringData
+// values of a Secret, or of a secret patch under patches/, replaced.
+// Other files, and files that are not YAML objects, are returned
+// unchanged.
+func redactSecrets(name string, data []byte) []byte {
+	var obj map[string]any
+	if err := yaml.Unmarshal(data, &obj); err != nil || obj == nil {
+		return data
+	}
+	if obj["kind"] != "Secret" && !strings.HasPrefix(name, "patches/") {
+		return data
+	}
+
+	redacted := false
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := obj[field].(map[string]any)
+		if !ok {
+			continue
+		}
+		for key := range values {
+			values[key] = redactedValue
+			redacted = true
+		}
+	}
+	if !redacted {
+		return data
+	}
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// Commit writes the marker file and replaces the output directory
+// with the staged files.
+func (o *OutputDir) Commit() error {
+	if err := os.WriteFile(filepath.Join(o.staging, MarkerFile), []byte(markerContent), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", MarkerFile, err)
+	}
+	// The directory may have changed while the files were generated.
+	if err := CheckOutputDir(o.path, o.force); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(o.path); err != nil {
+		return fmt.Errorf("cleaning output directory: %w", err)
+	}
+	if err := os.Rename(o.staging, o.path); err != nil {
+		return fmt.Errorf("moving generated files to %s: %w", o.path, err)
+	}
+	return nil
+}
+
+// Cleanup removes the staging directory if the files were not
+// committed.
+func (o *OutputDir) Cleanup() {
+	os.RemoveAll(o.staging)
+}
+
+// readTree returns the contents of the regular files below dir by
+// slash-separated relative path, ignoring the marker file. A missing
+// directory has no files.
+func readTree(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == MarkerFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
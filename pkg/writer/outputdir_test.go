@@ -0,0 +1,187 @@
+package writer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOutputDir tests which directories may be replaced and that
+// replacing one leaves only the generated files and the marker.
+func TestOutputDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string]string
+		force    bool
+		wantErr  bool
+	}{
+		{name: "missing directory"},
+		{name: "empty directory", existing: map[string]string{}},
+		{name: "generated directory", existing: map[string]string{MarkerFile: "", "old.yaml": "old\n"}},
+		{name: "unmarked directory", existing: map[string]string{"main.go": "package main\n"}, wantErr: true},
+		{name: "unmarked directory with force", existing: map[string]string{"main.go": "package main\n"}, force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "out")
+			if tt.existing != nil {
+				writeFiles(t, dir, tt.existing)
+			}
+
+			out, err := NewOutputDir(dir, tt.force)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "--force") {
+					t.Fatalf("got error %v, want one suggesting --force", err)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+					t.Errorf("unmarked directory was modified: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer out.Cleanup()
+
+			writeFiles(t, out.Staging(), map[string]string{"catalog.yaml": "new\n"})
+			files, err := out.StagedFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// sha256 of "new\n".
+			if len(files) != 1 || files[0].Path != "catalog.yaml" || files[0].SHA256 != "7aa7a5359173d05b63cfd682e3c38487f3cb4f7f1d60659fe59fab1505977d4c" {
+				t.Errorf("got files %+v", files)
+			}
+
+			if err := out.Commit(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			if got := strings.Join(names, " "); got != MarkerFile+" catalog.yaml" {
+				t.Errorf("got files %s", got)
+			}
+			if err := CheckOutputDir(dir, false); err != nil {
+				t.Errorf("generated directory cannot be replaced: %v", err)
+			}
+
+		})
+	}
+}
+
+// TestOutputDirDiff tests the diff of staged files against the
+// existing ones.
+func TestOutputDirDiff(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	writeFiles(t, dir, map[string]string{MarkerFile: "", "Makefile": "all:\n\tbuild\n", "removed.yaml": "gone\n", "same.yaml": "same\n"})
+
+	out, err := NewOutputDir(dir, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Cleanup()
+	writeFiles(t, out.Staging(), map[string]string{"Makefile": "all:\n\tdeploy\n", "added.yaml": "new\n", "same.yaml": "same\n"})
+
+	var buf bytes.Buffer
+	if err := out.Diff(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"--- a/Makefile\n+++ b/Makefile\n", "-\tbuild\n+\tdeploy\n", "--- /dev/null\n+++ b/added.yaml\n", "--- a/removed.yaml\n+++ /dev/null\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "same.yaml") || strings.Contains(got, MarkerFile) {
+		t.Errorf("diff includes unchanged files:\n%s", got)
+	}
+}
+
+// TestOutputDirDiffRedactsSecrets tests that secret values are not
+// shown in the diff, whether or not the rest of the file changed.
+func TestOutputDirDiffRedactsSecrets(t *testing.T) {
+	secret := func(name, value string) string {
+		return "apiVersion: v1\nkind: Secret\nmetadata:\n  name: " + name + "\ndata:\n  .dockerconfigjson: " + value + "\n"
+	}
+	dir := filepath.Join(t.TempDir(), "out")
+	writeFiles(t, dir, map[string]string{MarkerFile: ""})
+	writeFiles(t, filepath.Join(dir, "catalog"), map[string]string{"01-pull-secret.yaml": secret("old", "b2xkLXRva2Vu")})
+	writeFiles(t, filepath.Join(dir, "patches"), map[string]string{"global-pull-secret.yaml": "data:\n  .dockerconfigjson: b2xkLWdsb2JhbA==\n"})
+
+	out, err := NewOutputDir(dir, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Cleanup()
+	writeFiles(t, filepath.Join(out.Staging(), "catalog"), map[string]string{"01-pull-secret.yaml": secret("new", "bmV3LXRva2Vu")})
+	writeFiles(t, filepath.Join(out.Staging(), "patches"), map[string]string{"global-pull-secret.yaml": "data:\n  .dockerconfigjson: bmV3LWdsb2JhbA==\n"})
+
+	var buf bytes.Buffer
+	if err := out.Diff(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"-  name: old\n+  name: new\n", "  .dockerconfigjson: <redacted>\n", "diff patches/global-pull-secret.yaml\nSecret values changed (redacted)\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff missing %q:\n%s", want, got)
+		}
+	}
+	for _, value := range []string{"b2xkLXRva2Vu", "bmV3LXRva2Vu", "b2xkLWdsb2JhbA==", "bmV3LWdsb2JhbA=="} {
+		if strings.Contains(got, value) {
+			t.Errorf("diff shows secret value %s:\n%s", value, got)
+		}
+	}
+}
+
+// TestPreviewDir tests that an unmarked directory can be diffed but
+// not replaced.
+func TestPreviewDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+
+	out, err := NewPreviewDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Cleanup()
+	writeFiles(t, out.Staging(), map[string]string{"catalog.yaml": "new\n"})
+
+	var buf bytes.Buffer
+	if err := out.Diff(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"--- a/main.go\n+++ /dev/null\n", "--- /dev/null\n+++ b/catalog.yaml\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("diff missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	if err := out.Commit(); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("got error %v, want one suggesting --force", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+		t.Errorf("unmarked directory was modified: %v", err)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package writer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/openshift/bpfman-catalog/pkg/provenance"
+)
+
+// Result describes what a prepare command generated, for scripts
+// that chain the prepare, build and deploy steps.
+type Result struct {
+	Command            string              `json:"command"`
+	OutputDir          string              `json:"output_dir"`
+	Files              []File              `json:"files"`
+	Package            string              `json:"package,omitempty"`
+	DefaultChannel     string              `json:"default_channel,omitempty"`
+	Channels           []string            `json:"channels,omitempty"`
+	CatalogImage       string              `json:"catalog_image,omitempty"`        // Digest reference of the deployed catalog image
+	CatalogImageDigest string              `json:"catalog_image_digest,omitempty"` // Manifest digest of the deployed catalog image
+	CatalogFileDigest  string              `json:"catalog_file_digest,omitempty"`  // Digest of the generated or given catalog.yaml
+	Bundles            []provenance.Bundle `json:"bundles,omitempty"`
+	RenderMode         string              `json:"render_mode,omitempty"` // library or binary
+	NextSteps          []string            `json:"next_steps"`
+}
+
+// File is a generated file and the digest of its content.
+type File struct {
+	Path   string `json:"path"` // Relative to the output directory
+	SHA256 string `json:"sha256"`
+}
+
+// StagedFiles returns the staged files that Commit will move into the
+// output directory, excluding the marker file, sorted by path.
+func (o *OutputDir) StagedFiles() ([]File, error) {
+	tree, err := readTree(o.staging)
+	if err != nil {
+		return nil, fmt.Errorf("reading staged files: %w", err)
+	}
+	return treeFiles(tree), nil
+}
+
+func treeFiles(tree map[string][]byte) []File {
+	files := make([]File, 0, len(tree))
+	for path, data := range tree {
+		sum := sha256.Sum256(data)
+		files = append(files, File{Path: path, SHA256: hex.EncodeToString(sum[:])})
+	}
+	slices.SortFunc(files, func(a, b File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return files
+}
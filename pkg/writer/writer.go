@@ -32,6 +32,7 @@ func (w *ManifestWriter) WriteAll(manifestSet *manifests.ManifestSet) error {
 // Creates:
 //   - catalog/ - Namespace, IDMS, CatalogSource (catalog infrastructure)
 //   - subscription/ - OperatorGroup, Subscription (operator installation)
+//   - patches/ - optional patch for the global pull secret
 func (w *ManifestWriter) WriteAllSeparated(manifestSet *manifests.ManifestSet) error {
 	if err := os.MkdirAll(w.outputDir, 0755); err != nil {
 		return fmt.Errorf("creating output directory: %w", err)
@@ -60,6 +61,12 @@ func (w *ManifestWriter) WriteAllSeparated(manifestSet *manifests.ManifestSet) e
 		}
 	}
 
+	if manifestSet.PullSecret != nil {
+		if err := w.writeSecretToDir(catalogDir, "01-pull-secret.yaml", manifestSet.PullSecret); err != nil {
+			return fmt.Errorf("writing pull secret: %w", err)
+		}
+	}
+
 	if manifestSet.CatalogSource != nil {
 		if err := w.writeManifestToDir(catalogDir, "02-catalogsource.yaml", manifestSet.CatalogSource); err != nil {
 			return fmt.Errorf("writing CatalogSource: %w", err)
@@ -78,19 +85,149 @@ func (w *ManifestWriter) WriteAllSeparated(manifestSet *manifests.ManifestSet) e
 		}
 	}
 
+	if manifestSet.GlobalPullSecretPatch != nil {
+		patchesDir := filepath.Join(w.outputDir, "patches")
+		if err := os.MkdirAll(patchesDir, 0755); err != nil {
+			return fmt.Errorf("creating patches directory: %w", err)
+		}
+		if err := w.writeSecretToDir(patchesDir, "global-pull-secret.yaml", manifestSet.GlobalPullSecretPatch); err != nil {
+			return fmt.Errorf("writing global pull secret patch: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// WriteArgoCD writes Argo CD resources for a GitOps rollout.
+//
+// Creates:
+//   - argocd/ - Applications for the catalog/ and subscription/ directories
+//   - argocd/patches/ - ArgoCD resource patch adding the CatalogSource health check
+func (w *ManifestWriter) WriteArgoCD(argoCDSet *manifests.ArgoCDSet) error {
+	argoCDDir := filepath.Join(w.outputDir, "argocd")
+	patchesDir := filepath.Join(argoCDDir, "patches")
+
+	if err := os.MkdirAll(patchesDir, 0755); err != nil {
+		return fmt.Errorf("creating argocd directory: %w", err)
+	}
+
+	if argoCDSet.CatalogApplication != nil {
+		if err := w.writeManifestToDir(argoCDDir, "00-application-catalog.yaml", argoCDSet.CatalogApplication); err != nil {
+			return fmt.Errorf("writing catalog Application: %w", err)
+		}
+	}
+
+	if argoCDSet.SubscriptionApplication != nil {
+		if err := w.writeManifestToDir(argoCDDir, "01-application-subscription.yaml", argoCDSet.SubscriptionApplication); err != nil {
+			return fmt.Errorf("writing subscription Application: %w", err)
+		}
+	}
+
+	if argoCDSet.HealthChecks != nil {
+		if err := w.writeManifestToDir(patchesDir, "argocd-health.yaml", argoCDSet.HealthChecks); err != nil {
+			return fmt.Errorf("writing Argo CD health check patch: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// WriteBundleInstall writes manifests for installing a bundle
+// without OLM. Files are prefixed so that `kubectl apply -f` creates
+// them in dependency order: namespace, CRDs, ConfigMaps, RBAC, then
+// Deployments.
+func (w *ManifestWriter) WriteBundleInstall(installSet *manifests.BundleInstallSet) error {
+	if err := os.MkdirAll(w.outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	if installSet.Namespace != nil {
+		if err := w.writeManifestToDir(w.outputDir, "00-namespace.yaml", installSet.Namespace); err != nil {
+			return fmt.Errorf("writing namespace: %w", err)
+		}
+	}
+
+	for _, crd := range installSet.CRDs {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("01-crd-%s.yaml", objectName(crd)), crd); err != nil {
+			return fmt.Errorf("writing CRD: %w", err)
+		}
+	}
+
+	for _, cm := range installSet.ConfigMaps {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("02-configmap-%s.yaml", objectName(cm)), cm); err != nil {
+			return fmt.Errorf("writing ConfigMap: %w", err)
+		}
+	}
+
+	for _, sa := range installSet.ServiceAccounts {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("03-serviceaccount-%s.yaml", sa.ObjectMeta.Name), sa); err != nil {
+			return fmt.Errorf("writing ServiceAccount: %w", err)
+		}
+	}
+
+	for _, role := range installSet.ClusterRoles {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("04-clusterrole-%s.yaml", role.ObjectMeta.Name), role); err != nil {
+			return fmt.Errorf("writing ClusterRole: %w", err)
+		}
+	}
+
+	for _, binding := range installSet.ClusterRoleBindings {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("05-clusterrolebinding-%s.yaml", binding.ObjectMeta.Name), binding); err != nil {
+			return fmt.Errorf("writing ClusterRoleBinding: %w", err)
+		}
+	}
+
+	for _, role := range installSet.Roles {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("06-role-%s.yaml", role.ObjectMeta.Name), role); err != nil {
+			return fmt.Errorf("writing Role: %w", err)
+		}
+	}
+
+	for _, binding := range installSet.RoleBindings {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("07-rolebinding-%s.yaml", binding.ObjectMeta.Name), binding); err != nil {
+			return fmt.Errorf("writing RoleBinding: %w", err)
+		}
+	}
+
+	for _, deployment := range installSet.Deployments {
+		if err := w.writeManifestToDir(w.outputDir, fmt.Sprintf("08-deployment-%s.yaml", deployment.ObjectMeta.Name), deployment); err != nil {
+			return fmt.Errorf("writing Deployment: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// objectName returns metadata.name of an unstructured object.
+func objectName(obj map[string]any) string {
+	meta, _ := obj["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+	return name
+}
+
 // writeManifestToDir writes a single manifest to a file in a specific
 // directory.
 func (w *ManifestWriter) writeManifestToDir(dir, filename string, manifest any) error {
+	return writeManifestFile(filepath.Join(dir, filename), manifest, 0644)
+}
+
+// writeSecretToDir writes a manifest holding credentials to a file
+// in a specific directory, readable only by the current user.
+func (w *ManifestWriter) writeSecretToDir(dir, filename string, manifest any) error {
+	return writeManifestFile(filepath.Join(dir, filename), manifest, 0600)
+}
+
+func writeManifestFile(path string, manifest any, perm os.FileMode) error {
 	data, err := yaml.Marshal(manifest)
 	if err != nil {
 		return fmt.Errorf("marshaling manifest: %w", err)
 	}
 
-	path := filepath.Join(dir, filename)
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("writing file %s: %w", path, err)
+	}
+	// WriteFile only applies perm to new files.
+	if err := os.Chmod(path, perm); err != nil {
 		return fmt.Errorf("writing file %s: %w", path, err)
 	}
 
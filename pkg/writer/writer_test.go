@@ -0,0 +1,128 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openshift/bpfman-catalog/pkg/manifests"
+)
+
+// TestWriteArgoCD tests the layout and content of the Argo CD files.
+func TestWriteArgoCD(t *testing.T) {
+	argoCDSet, err := manifests.NewGenerator(manifests.GeneratorConfig{}).GenerateArgoCD(&manifests.ManifestSet{}, manifests.GitOpsConfig{
+		RepoURL: "https://github.com/example/fleet.git",
+		Path:    "manifests",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := New(dir).WriteArgoCD(argoCDSet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := readTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"argocd/00-application-catalog.yaml", "argocd/01-application-subscription.yaml", "argocd/patches/argocd-health.yaml"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got files %v, want %v", names, want)
+	}
+
+	for name, content := range map[string]string{
+		"argocd/00-application-catalog.yaml":      "path: manifests/catalog\n",
+		"argocd/01-application-subscription.yaml": "path: manifests/subscription\n",
+		"argocd/patches/argocd-health.yaml":       "  resourceHealthChecks:\n  - check: |\n",
+	} {
+		if !strings.Contains(string(files[name]), content) {
+			t.Errorf("%s missing %q:\n%s", name, content, files[name])
+		}
+	}
+}
+
+// TestWriteBundleInstall tests that bundle install files are named so
+// that they apply in dependency order.
+func TestWriteBundleInstall(t *testing.T) {
+	meta := func(name string) manifests.ObjectMeta {
+		return manifests.ObjectMeta{Name: name, Namespace: "bpfman"}
+	}
+	installSet := &manifests.BundleInstallSet{
+		Namespace:           &manifests.Namespace{ObjectMeta: meta("bpfman")},
+		CRDs:                []map[string]any{{"kind": "CustomResourceDefinition", "metadata": map[string]any{"name": "bpfapplications.bpfman.io"}}},
+		ConfigMaps:          []map[string]any{{"kind": "ConfigMap", "metadata": map[string]any{"name": "bpfman-config"}}},
+		ServiceAccounts:     []*manifests.ServiceAccount{{ObjectMeta: meta("bpfman-operator")}},
+		ClusterRoles:        []*manifests.Role{{ObjectMeta: meta("bpfman-operator")}},
+		ClusterRoleBindings: []*manifests.RoleBinding{{ObjectMeta: meta("bpfman-operator")}},
+		Roles:               []*manifests.Role{{ObjectMeta: meta("bpfman-operator")}},
+		RoleBindings:        []*manifests.RoleBinding{{ObjectMeta: meta("bpfman-operator")}},
+		Deployments:         []*manifests.Deployment{{ObjectMeta: meta("bpfman-operator")}},
+	}
+
+	dir := t.TempDir()
+	if err := New(dir).WriteBundleInstall(installSet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := readTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{
+		"00-namespace.yaml",
+		"01-crd-bpfapplications.bpfman.io.yaml",
+		"02-configmap-bpfman-config.yaml",
+		"03-serviceaccount-bpfman-operator.yaml",
+		"04-clusterrole-bpfman-operator.yaml",
+		"05-clusterrolebinding-bpfman-operator.yaml",
+		"06-role-bpfman-operator.yaml",
+		"07-rolebinding-bpfman-operator.yaml",
+		"08-deployment-bpfman-operator.yaml",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("got files %v, want %v", names, want)
+	}
+}
+
+// TestWriteAllSecretModes tests that files holding registry
+// credentials are readable only by their owner.
+func TestWriteAllSecretModes(t *testing.T) {
+	manifestSet := &manifests.ManifestSet{
+		Namespace:             &manifests.Namespace{ObjectMeta: manifests.ObjectMeta{Name: "bpfman"}},
+		PullSecret:            &manifests.Secret{ObjectMeta: manifests.ObjectMeta{Name: "bpfman-catalog-pull-secret"}, Data: map[string][]byte{".dockerconfigjson": []byte("{}")}},
+		GlobalPullSecretPatch: &manifests.SecretPatch{Data: map[string][]byte{".dockerconfigjson": []byte("{}")}},
+	}
+
+	dir := t.TempDir()
+	if err := New(dir).WriteAll(manifestSet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, want := range map[string]os.FileMode{
+		"catalog/00-namespace.yaml":       0644,
+		"catalog/01-pull-secret.yaml":     0600,
+		"patches/global-pull-secret.yaml": 0600,
+	} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s has mode %o, want %o", name, got, want)
+		}
+	}
+}